@@ -0,0 +1,483 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/aaronland/go-uid"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DEFAULT_CONCURRENCY is the default number of accounts that messages are published to at the same time when the
+// credentials contain multiple accounts.
+const DEFAULT_CONCURRENCY int = 4
+
+// ACCOUNT_PLACEHOLDER is the string in a `?token-sink=` URI which is replaced by the name of each account when the
+// credentials contain multiple accounts.
+const ACCOUNT_PLACEHOLDER string = "{account}"
+
+// AccountUID is a `uid.UID` identifying the tweet, or thread, published to a named account by a `TwitterBroadcaster`
+// whose credentials contain multiple accounts (see `oauth.AccountsCredentials`). Its value is the value of the tweet
+// UID it wraps. It is encoded as a string in the form "{ACCOUNT}#{TWEET_ID},{TWEET_ID}...".
+type AccountUID struct {
+	account string
+	tweets  uid.UID
+}
+
+// NewAccountUID returns a new `AccountUID` instance for the tweet, or thread, 'u' published to 'account'.
+func NewAccountUID(ctx context.Context, account string, u uid.UID) (uid.UID, error) {
+
+	if account == "" {
+		return nil, fmt.Errorf("Missing account name")
+	}
+
+	if u == nil {
+		return nil, fmt.Errorf("Invalid UID")
+	}
+
+	a := &AccountUID{
+		account: account,
+		tweets:  u,
+	}
+
+	return a, nil
+}
+
+// ParseAccountUID parses 'str_uid', which is expected to take the form "{ACCOUNT}#{TWEET_ID},{TWEET_ID}..." (optionally
+// prefixed by the "*twitter.AccountUID#" label output by `uid.MultiUID`), and returns a new `AccountUID` instance.
+func ParseAccountUID(ctx context.Context, str_uid string) (uid.UID, error) {
+
+	if idx := strings.Index(str_uid, "AccountUID#"); idx != -1 {
+		str_uid = str_uid[idx+len("AccountUID#"):]
+	}
+
+	account, str_ids, ok := strings.Cut(str_uid, "#")
+
+	if !ok || account == "" || str_ids == "" {
+		return nil, fmt.Errorf("Invalid account UID '%s'", str_uid)
+	}
+
+	tweet_ids := strings.Split(str_ids, ",")
+
+	for _, id := range tweet_ids {
+
+		if id == "" {
+			return nil, fmt.Errorf("Invalid account UID '%s', empty tweet ID", str_uid)
+		}
+	}
+
+	u, err := newBroadcastUID(ctx, tweet_ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAccountUID(ctx, account, u)
+}
+
+// Account returns the name of the account that 'u' was published to.
+func (u *AccountUID) Account() string {
+	return u.account
+}
+
+// TweetUID returns the UID of the tweet, or thread, that 'u' wraps.
+func (u *AccountUID) TweetUID() uid.UID {
+	return u.tweets
+}
+
+// Value returns the value of the tweet UID that 'u' wraps.
+func (u *AccountUID) Value() any {
+	return u.tweets.Value()
+}
+
+// String returns the string representation of 'u'.
+func (u *AccountUID) String() string {
+
+	tweet_ids, err := tweetIdsFromUID(u.tweets)
+
+	if err != nil {
+		return fmt.Sprintf("%s#%s", u.account, u.tweets)
+	}
+
+	return fmt.Sprintf("%s#%s", u.account, strings.Join(tweet_ids, ","))
+}
+
+// AccountsError is the error returned when a message could not be published to one or more accounts.
+type AccountsError struct {
+	// Errors maps the names of the accounts which failed to the error publishing to them.
+	Errors map[string]error
+}
+
+// Error returns the string representation of 'e'.
+func (e *AccountsError) Error() string {
+
+	names := e.names()
+	reasons := make([]string, len(names))
+
+	for idx, name := range names {
+		reasons[idx] = fmt.Sprintf("%s: %v", name, e.Errors[name])
+	}
+
+	return fmt.Sprintf("Failed to publish to %d account(s), %s", len(names), strings.Join(reasons, "; "))
+}
+
+// Is returns a boolean value indicating whether the error for any of the accounts in 'e' matches 'target'
+// (using `errors.Is`).
+func (e *AccountsError) Is(target error) bool {
+
+	for _, name := range e.names() {
+
+		if errors.Is(e.Errors[name], target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error, in account name order, for the accounts in 'e' that matches 'target' (using `errors.As`)
+// and if one is found sets 'target' to that error value and returns true. Otherwise it returns false.
+func (e *AccountsError) As(target any) bool {
+
+	for _, name := range e.names() {
+
+		if errors.As(e.Errors[name], target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// names returns the sorted list of the names of the accounts in 'e'.
+func (e *AccountsError) names() []string {
+
+	names := make([]string, 0, len(e.Errors))
+
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// setAccounts configures 'b' to publish to each of the accounts in 'creds'. A `TwitterBroadcaster` is created for
+// each account which shares the configuration of 'b' but has its own client, verified account, posting budget, token sink and
+// dedupe records. If 'token_sink_uri' is not empty a `oauth.TokenSink` is created for each account by replacing
+// `ACCOUNT_PLACEHOLDER` with the name of the account.
+func (b *TwitterBroadcaster) setAccounts(ctx context.Context, creds *oauth.AccountsCredentials, token_sink_uri string) error {
+
+	accounts := make(map[string]*TwitterBroadcaster)
+	names := creds.Names()
+
+	for _, name := range names {
+
+		account_creds := creds.Accounts[name]
+		factory := *b.factory
+
+		if token_sink_uri != "" {
+
+			sink, err := oauth.NewTokenSink(ctx, strings.Replace(token_sink_uri, ACCOUNT_PLACEHOLDER, name, -1))
+
+			if err != nil {
+				return fmt.Errorf("Invalid ?token-sink= parameter for account '%s', %w", name, err)
+			}
+
+			factory.token_sink = sink
+		}
+
+		tw_client, err := factory.newClient(ctx, account_creds)
+
+		if err != nil {
+			return fmt.Errorf("Failed to create client for account '%s', %w", name, err)
+		}
+
+		var account *Account
+
+		if b.verify == VERIFY_EAGER {
+
+			a, err := verifyWithBackoff(ctx, tw_client, b.verify_timeout, b.logger)
+
+			if err != nil {
+				return fmt.Errorf("Failed to verify account '%s', %w", name, err)
+			}
+
+			b.logger.Printf("Verified Twitter credentials for %s as %s", name, a)
+			account = a
+		}
+
+		child := *b
+
+		child.factory = &factory
+		child.client_state = newClientState(tw_client)
+		child.client_mu = new(sync.RWMutex)
+		child.account = account
+		child.account_mu = new(sync.Mutex)
+		child.account_name = name
+		child.accounts = nil
+		child.account_names = nil
+
+		// Scheduled messages are queued, and published, for all the accounts by 'b'
+		child.schedule = nil
+
+		if b.throttle != nil {
+
+			t := *b.throttle
+			t.ledger = ledgerForAccount(accountKey(account_creds, account, name))
+
+			child.throttle = &t
+		}
+
+		accounts[name] = &child
+	}
+
+	b.accounts = accounts
+	b.account_names = names
+	b.throttle = nil
+
+	return nil
+}
+
+// broadcastAccounts publishes 'msg' to each of the accounts in 'names', at most 'b.concurrency' at a time, and returns a
+// `uid.MultiUID` containing an `AccountUID` for each account, in the order of 'names'. If the message could not be
+// published to one or more accounts an `AccountsError` is returned along with a `uid.MultiUID` for the accounts which
+// succeeded, and any partially published threads, (or nil if there are none).
+func (b *TwitterBroadcaster) broadcastAccounts(ctx context.Context, msg *broadcaster.Message, names []string) (uid.UID, error) {
+
+	uids := make([]uid.UID, len(names))
+	errs := make([]error, len(names))
+
+	throttle := make(chan bool, b.concurrency)
+	wg := new(sync.WaitGroup)
+
+	for idx, name := range names {
+
+		wg.Add(1)
+
+		go func(idx int, name string) {
+
+			defer wg.Done()
+
+			select {
+			case <-ctx.Done():
+				errs[idx] = ctx.Err()
+				return
+			case throttle <- true:
+				// pass
+			}
+
+			defer func() {
+				<-throttle
+			}()
+
+			u, err := b.accounts[name].BroadcastMessage(ctx, msg)
+
+			if err != nil {
+
+				errs[idx] = err
+
+				// Keep the UID for a partially published thread so it can be retracted
+				if u == nil {
+					return
+				}
+			}
+
+			account_uid, err := NewAccountUID(ctx, name, u)
+
+			if err != nil {
+				errs[idx] = err
+				return
+			}
+
+			uids[idx] = account_uid
+
+		}(idx, name)
+	}
+
+	wg.Wait()
+
+	published := make([]uid.UID, 0)
+	failed := make(map[string]error)
+
+	for idx, name := range names {
+
+		if errs[idx] != nil {
+			failed[name] = errs[idx]
+		}
+
+		if uids[idx] != nil {
+			published = append(published, uids[idx])
+		}
+	}
+
+	if len(failed) == 0 {
+		return uid.NewMultiUID(ctx, published...), nil
+	}
+
+	err := &AccountsError{
+		Errors: failed,
+	}
+
+	if len(published) == 0 {
+		return nil, err
+	}
+
+	return uid.NewMultiUID(ctx, published...), err
+}
+
+// retractAccounts retracts the tweets, identified by the `AccountUID` instances in 'u', from each of the accounts for 'b'.
+// Scheduled messages are cancelled.
+func (b *TwitterBroadcaster) retractAccounts(ctx context.Context, u uid.UID) error {
+
+	by_account, err := tweetIdsByAccount(u)
+
+	if err != nil {
+		return err
+	}
+
+	if len(by_account) == 0 {
+		return fmt.Errorf("UID does not contain any tweet IDs")
+	}
+
+	for _, tweet_id := range by_account[""] {
+
+		if !strings.HasPrefix(tweet_id, SCHEDULED_ID_PREFIX+"-") {
+			return fmt.Errorf("Tweet %s is not associated with an account", tweet_id)
+		}
+	}
+
+	for name := range by_account {
+
+		if _, ok := b.accounts[name]; name != "" && !ok {
+			return fmt.Errorf("Unknown account '%s'", name)
+		}
+	}
+
+	for _, tweet_id := range by_account[""] {
+
+		err := b.cancelScheduled(ctx, strings.TrimPrefix(tweet_id, SCHEDULED_ID_PREFIX+"-"))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range b.account_names {
+
+		tweet_ids, ok := by_account[name]
+
+		if !ok {
+			continue
+		}
+
+		account_uid, err := newBroadcastUID(ctx, tweet_ids)
+
+		if err != nil {
+			return err
+		}
+
+		err = b.accounts[name].Retract(ctx, account_uid)
+
+		if err != nil {
+			return fmt.Errorf("Failed to retract from account '%s', %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Accounts returns the Twitter accounts associated with the credentials used by 'b', keyed by account name. Accounts
+// which have not been verified yet are verified first (see `Account`). If 'b' was created with the credentials for a
+// single account the map contains one entry whose name is an empty string.
+func (b *TwitterBroadcaster) Accounts(ctx context.Context) (map[string]*Account, error) {
+
+	if b.accounts == nil {
+
+		a, err := b.Account(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]*Account{"": a}, nil
+	}
+
+	accounts := make(map[string]*Account)
+
+	for _, name := range b.account_names {
+
+		a, err := b.accounts[name].Account(ctx)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to retrieve account '%s', %w", name, err)
+		}
+
+		accounts[name] = a
+	}
+
+	return accounts, nil
+}
+
+// tweetIdsByAccount returns the list of (string) tweet IDs contained in 'u' keyed by the name of the account they were
+// published to. Tweet IDs which are not wrapped in an `AccountUID` are keyed by an empty string.
+func tweetIdsByAccount(u uid.UID) (map[string][]string, error) {
+
+	by_account := make(map[string][]string)
+
+	var walk func(uid.UID) error
+
+	walk = func(u uid.UID) error {
+
+		if a, ok := u.(*AccountUID); ok {
+
+			tweet_ids, err := tweetIdsFromUID(a.tweets)
+
+			if err != nil {
+				return err
+			}
+
+			by_account[a.account] = append(by_account[a.account], tweet_ids...)
+			return nil
+		}
+
+		if u == nil {
+			return fmt.Errorf("Invalid UID")
+		}
+
+		if children, ok := u.Value().([]uid.UID); ok {
+
+			for _, child := range children {
+
+				err := walk(child)
+
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		tweet_ids, err := tweetIdsFromUID(u)
+
+		if err != nil {
+			return err
+		}
+
+		by_account[""] = append(by_account[""], tweet_ids...)
+		return nil
+	}
+
+	err := walk(u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return by_account, nil
+}
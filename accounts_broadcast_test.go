@@ -0,0 +1,112 @@
+package twitter_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/aaronland/go-broadcaster-twitter/twittertest"
+)
+
+// countPosts returns the number of requests to publish a tweet received by 's' for the account whose credentials are 'creds'.
+func countPosts(s *twittertest.Server, creds oauth.Credentials) int {
+
+	access_token := creds.(*oauth.OAuth1Credentials).AccessToken
+	count := 0
+
+	for _, r := range s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_TWEETS) {
+
+		if r.AccessToken == access_token {
+			count += 1
+		}
+	}
+
+	return count
+}
+
+func TestBroadcastAccounts(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+	accounts := newTestAccounts(t, s, "a", "b", "c")
+
+	s.InjectAccountRateLimit(accounts["c"].(*oauth.OAuth1Credentials).AccessToken, twittertest.ENDPOINT_TWEETS, 1, time.Hour)
+
+	params := url.Values{}
+	params.Set("max-retries", "0")
+	params.Set("concurrency", "2")
+
+	br := newTestAccountsBroadcaster(t, s, accounts, params)
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+	}
+
+	// Account "c" is rate limited so the message is only published to "a" and "b"
+
+	u, err := br.BroadcastMessage(ctx, msg)
+
+	if !errors.Is(err, twitter.ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+
+	var accounts_err *twitter.AccountsError
+
+	if !errors.As(err, &accounts_err) {
+		t.Fatalf("Expected AccountsError, got %v", err)
+	}
+
+	if len(accounts_err.Errors) != 1 || accounts_err.Errors["c"] == nil {
+		t.Fatalf("Expected only account 'c' to fail, got %v", err)
+	}
+
+	if u == nil {
+		t.Fatalf("Expected UID for the accounts which were published to")
+	}
+
+	str_uid := u.String()
+
+	for _, name := range []string{"a", "b"} {
+
+		if !strings.Contains(str_uid, name+"#") {
+			t.Fatalf("Expected '%s' to contain a tweet for account '%s'", str_uid, name)
+		}
+
+		posts := countPosts(s, accounts[name])
+
+		if posts != 1 {
+			t.Fatalf("Expected 1 post for account '%s', got %d", name, posts)
+		}
+	}
+
+	if strings.Contains(str_uid, "c#") {
+		t.Fatalf("Expected '%s' not to contain a tweet for account 'c'", str_uid)
+	}
+
+	// Once the rate limit has reset every account is published to
+
+	msg = &broadcaster.Message{
+		Body: "hello again",
+	}
+
+	u, err = br.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+
+		if !strings.Contains(u.String(), name+"#") {
+			t.Fatalf("Expected '%s' to contain a tweet for account '%s'", u.String(), name)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package twitter_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/aaronland/go-broadcaster-twitter/twittertest"
+)
+
+func TestAccountsTokenSink(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server_creds := twittertest.DefaultOAuth2Credentials()
+	server_creds.ExpiresAt = time.Now().Add(-1 * time.Minute)
+
+	s := newTestServer(t, &twittertest.ServerOptions{OAuth2: server_creds})
+
+	accounts := map[string]oauth.Credentials{
+		"oauth1": s.Credentials(),
+		"oauth2": server_creds,
+	}
+
+	root := t.TempDir()
+
+	opts := &twitter.TwitterBroadcasterOptions{
+		HTTPClient: s.Client(),
+	}
+
+	// The ?token-sink= URI must be templated when there are multiple accounts
+
+	params := url.Values{}
+	params.Set("token-sink", fmt.Sprintf("file://%s", filepath.Join(root, "credentials.json")))
+
+	uri, err := s.AccountsBroadcasterURI(accounts, params)
+
+	if err != nil {
+		t.Fatalf("Failed to derive broadcaster URI, %v", err)
+	}
+
+	_, err = twitter.NewTwitterBroadcasterWithOptions(ctx, uri, opts)
+
+	if err == nil {
+		t.Fatalf("Expected ?token-sink= without an %s placeholder to fail", twitter.ACCOUNT_PLACEHOLDER)
+	}
+
+	// Eager verification refreshes the expired access token for the "oauth2" account
+
+	params.Set("token-sink", fmt.Sprintf("file://%s", filepath.Join(root, twitter.ACCOUNT_PLACEHOLDER+".json")))
+
+	uri, err = s.AccountsBroadcasterURI(accounts, params)
+
+	if err != nil {
+		t.Fatalf("Failed to derive broadcaster URI, %v", err)
+	}
+
+	_, err = twitter.NewTwitterBroadcasterWithOptions(ctx, uri, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create broadcaster, %v", err)
+	}
+
+	body, err := os.ReadFile(filepath.Join(root, "oauth2.json"))
+
+	if err != nil {
+		t.Fatalf("Expected refreshed credentials to be written for the oauth2 account, %v", err)
+	}
+
+	written, err := oauth.NewOAuth2CredentialsFromString(ctx, string(body))
+
+	if err != nil {
+		t.Fatalf("Failed to parse refreshed credentials, %v", err)
+	}
+
+	current, _ := s.OAuth2Credentials()
+
+	if written.RefreshToken != current.RefreshToken {
+		t.Fatalf("Expected the rotated refresh token to be written, got '%s'", written.RefreshToken)
+	}
+
+	_, err = os.Stat(filepath.Join(root, "oauth1.json"))
+
+	if err == nil {
+		t.Fatalf("Expected nothing to be written for the oauth1 account")
+	}
+}
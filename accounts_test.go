@@ -0,0 +1,86 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAccountsError(t *testing.T) {
+
+	api_err := &APIError{StatusCode: http.StatusForbidden}
+
+	err := fmt.Errorf("Failed to publish, %w", &AccountsError{
+		Errors: map[string]error{
+			"b": &Error{Kind: ErrDuplicateStatus, Err: api_err},
+			"a": ErrRateLimited,
+		},
+	})
+
+	if !errors.Is(err, ErrRateLimited) || !errors.Is(err, ErrDuplicateStatus) {
+		t.Fatalf("Expected AccountsError to match the errors for each account")
+	}
+
+	if errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected AccountsError not to match ErrNotFound")
+	}
+
+	var target *APIError
+
+	if !errors.As(err, &target) || target != api_err {
+		t.Fatalf("Expected AccountsError to match APIError")
+	}
+
+	var accounts_err *AccountsError
+
+	if !errors.As(err, &accounts_err) || len(accounts_err.Errors) != 2 {
+		t.Fatalf("Expected error to be an AccountsError")
+	}
+
+	if !strings.HasPrefix(accounts_err.Error(), "Failed to publish to 2 account(s), a: ") {
+		t.Fatalf("Unexpected error string '%s'", accounts_err.Error())
+	}
+}
+
+func TestAccountUID(t *testing.T) {
+
+	ctx := context.Background()
+
+	u, err := ParseAccountUID(ctx, "*twitter.AccountUID#sfo#1234,5678")
+
+	if err != nil {
+		t.Fatalf("Failed to parse account UID, %v", err)
+	}
+
+	account_uid := u.(*AccountUID)
+
+	if account_uid.Account() != "sfo" {
+		t.Fatalf("Unexpected account '%s'", account_uid.Account())
+	}
+
+	if u.String() != "sfo#1234,5678" {
+		t.Fatalf("Unexpected string '%s'", u.String())
+	}
+
+	tweet_ids, err := tweetIdsFromUID(u)
+
+	if err != nil {
+		t.Fatalf("Failed to derive tweet IDs, %v", err)
+	}
+
+	if strings.Join(tweet_ids, ",") != "1234,5678" {
+		t.Fatalf("Unexpected tweet IDs %v", tweet_ids)
+	}
+
+	for _, str_uid := range []string{"sfo", "#1234", "sfo#", "sfo#1234,"} {
+
+		_, err := ParseAccountUID(ctx, str_uid)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to be invalid", str_uid)
+		}
+	}
+}
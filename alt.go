@@ -0,0 +1,65 @@
+package twitter
+
+// https://developer.twitter.com/en/docs/twitter-api/v1/media/upload-media/api-reference/post-media-metadata-create
+
+import (
+	"fmt"
+	"image"
+	"unicode/utf8"
+)
+
+// MAX_ALT_TEXT_LENGTH is the maximum number of characters Twitter allows for an image description (alt text).
+const MAX_ALT_TEXT_LENGTH int = 1000
+
+// DescribedImage is an `image.Image` instance with an associated description (alt text). When a
+// `DescribedImage` is included in a `broadcaster.Message` instance's `Images` property the `TwitterBroadcaster`
+// will attach its description to the uploaded media. The wrapped image may itself be an `EncodedImage`.
+type DescribedImage struct {
+	image.Image
+	// AltText is the description of the image.
+	AltText string
+}
+
+// NewDescribedImage returns a new `DescribedImage` instance for 'im' with the description 'alt_text'.
+func NewDescribedImage(im image.Image, alt_text string) *DescribedImage {
+
+	d := &DescribedImage{
+		Image:   im,
+		AltText: alt_text,
+	}
+
+	return d
+}
+
+// imageWithAltText returns the image wrapped by 'im' and its alt text if 'im' is a `DescribedImage`.
+// Otherwise it returns 'im' and an empty string.
+func imageWithAltText(im image.Image) (image.Image, string) {
+
+	if d, ok := im.(*DescribedImage); ok {
+		return d.Image, d.AltText
+	}
+
+	return im, ""
+}
+
+// validateAltText ensures that 'alt_text' is not longer than `MAX_ALT_TEXT_LENGTH` characters and, if
+// 'required' is true, that it is not empty.
+func validateAltText(alt_text string, required bool) error {
+
+	if alt_text == "" {
+
+		if required {
+			return fmt.Errorf("Missing alt text")
+		}
+
+		return nil
+	}
+
+	count := utf8.RuneCountInString(alt_text)
+
+	if count > MAX_ALT_TEXT_LENGTH {
+		return fmt.Errorf("Alt text exceeds maximum length (%d > %d characters)", count, MAX_ALT_TEXT_LENGTH)
+	}
+
+	return nil
+}
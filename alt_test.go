@@ -0,0 +1,90 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestImageWithAltText(t *testing.T) {
+
+	im := image.NewRGBA(image.Rect(0, 0, 10, 10))
+
+	_, alt_text := imageWithAltText(im)
+
+	if alt_text != "" {
+		t.Fatalf("Expected plain image not to have alt text")
+	}
+
+	unwrapped, alt_text := imageWithAltText(NewDescribedImage(im, "A transparent square"))
+
+	if unwrapped != im || alt_text != "A transparent square" {
+		t.Fatalf("Expected described image to be unwrapped")
+	}
+}
+
+func TestValidateAltText(t *testing.T) {
+
+	err := validateAltText("", false)
+
+	if err != nil {
+		t.Fatalf("Expected missing optional alt text to be valid, %v", err)
+	}
+
+	err = validateAltText("", true)
+
+	if err == nil {
+		t.Fatalf("Expected missing required alt text to be invalid")
+	}
+
+	// Alt text is counted in characters rather than bytes
+
+	err = validateAltText(strings.Repeat("é", MAX_ALT_TEXT_LENGTH), true)
+
+	if err != nil {
+		t.Fatalf("Expected alt text with %d characters to be valid, %v", MAX_ALT_TEXT_LENGTH, err)
+	}
+
+	err = validateAltText(strings.Repeat("a", MAX_ALT_TEXT_LENGTH+1), false)
+
+	if err == nil {
+		t.Fatalf("Expected over-length alt text to be invalid")
+	}
+}
+
+func TestCreateMediaMetadata(t *testing.T) {
+
+	ctx := context.Background()
+
+	var md *mediaMetadataRequest
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Method != http.MethodPost || req.URL.Path != "/1.1/media/metadata/create.json" {
+			http.Error(rsp, "Not found", http.StatusNotFound)
+			return
+		}
+
+		err := json.NewDecoder(req.Body).Decode(&md)
+
+		if err != nil {
+			http.Error(rsp, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		rsp.WriteHeader(http.StatusOK)
+	})
+
+	err := c.CreateMediaMetadata(ctx, "5678", "A red square")
+
+	if err != nil {
+		t.Fatalf("Failed to create media metadata, %v", err)
+	}
+
+	if md == nil || md.MediaId != "5678" || md.AltText == nil || md.AltText.Text != "A red square" {
+		t.Fatalf("Unexpected media metadata %+v", md)
+	}
+}
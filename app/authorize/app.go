@@ -0,0 +1,199 @@
+// Package authorize provides methods for implementing a command line tool for running the Twitter OAuth1 3-legged
+// authorization flow and writing the resulting `oauth.OAuth1Credentials` document.
+package authorize
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/sfomuseum/go-flags/flagset"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+func Run(ctx context.Context, logger *log.Logger) error {
+	fs := DefaultFlagSet()
+	return RunWithFlagSet(ctx, fs, logger)
+}
+
+func RunWithFlagSet(ctx context.Context, fs *flag.FlagSet, logger *log.Logger) error {
+
+	flagset.Parse(fs)
+
+	err := flagset.SetFlagsFromEnvVars(fs, "TWITTER")
+
+	if err != nil {
+		return fmt.Errorf("Failed to assign flags from environment variables, %w", err)
+	}
+
+	if consumer_key == "" || consumer_secret == "" {
+		return fmt.Errorf("Missing -consumer-key or -consumer-secret flag")
+	}
+
+	opts := &oauth.OAuth1AuthorizerOptions{
+		Base: authorize_base,
+	}
+
+	a, err := oauth.NewOAuth1Authorizer(ctx, consumer_key, consumer_secret, opts)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create authorizer, %w", err)
+	}
+
+	var creds *oauth.OAuth1Credentials
+
+	if callback_url == "" {
+		creds, err = authorizeWithPIN(ctx, a, logger)
+	} else {
+		creds, err = authorizeWithCallback(ctx, a, callback_url, logger)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	enc_creds, err := json.MarshalIndent(creds, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("Failed to marshal credentials, %w", err)
+	}
+
+	err = writeCredentials(ctx, output, enc_creds)
+
+	if err != nil {
+		return fmt.Errorf("Failed to write credentials, %w", err)
+	}
+
+	if output != "-" {
+		logger.Printf("Wrote credentials to %s", output)
+	}
+
+	return nil
+}
+
+// authorizeWithPIN runs the PIN (out-of-band) authorization flow, reading the PIN from STDIN.
+func authorizeWithPIN(ctx context.Context, a *oauth.OAuth1Authorizer, logger *log.Logger) (*oauth.OAuth1Credentials, error) {
+
+	t, err := a.RequestToken(ctx, oauth.OOB_CALLBACK)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "Visit the following URL to authorize the application:\n\n%s\n\nEnter the PIN: ", a.AuthorizationURL(t))
+
+	scanner := bufio.NewScanner(os.Stdin)
+
+	if !scanner.Scan() {
+
+		err := scanner.Err()
+
+		if err == nil {
+			err = fmt.Errorf("No input")
+		}
+
+		return nil, fmt.Errorf("Failed to read PIN, %w", err)
+	}
+
+	return a.AccessToken(ctx, t, scanner.Text())
+}
+
+// authorizeWithCallback runs the callback authorization flow, listening for the redirect to 'str_callback' locally.
+func authorizeWithCallback(ctx context.Context, a *oauth.OAuth1Authorizer, str_callback string, logger *log.Logger) (*oauth.OAuth1Credentials, error) {
+
+	u, err := url.Parse(str_callback)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid -callback-url flag, %w", err)
+	}
+
+	if u.Scheme != "http" || u.Host == "" {
+		return nil, fmt.Errorf("Invalid -callback-url flag, must be an http:// URL with a host")
+	}
+
+	path := u.Path
+
+	if path == "" {
+		path = "/"
+	}
+
+	listener, err := net.Listen("tcp", u.Host)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to listen on %s, %w", u.Host, err)
+	}
+
+	t, err := a.RequestToken(ctx, u.String())
+
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	verifier_ch := make(chan string, 1)
+	err_ch := make(chan error, 1)
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(path, func(rsp http.ResponseWriter, req *http.Request) {
+
+		q := req.URL.Query()
+
+		if q.Has("denied") {
+			http.Error(rsp, "Authorization was denied.", http.StatusForbidden)
+			err_ch <- fmt.Errorf("Authorization was denied")
+			return
+		}
+
+		if q.Get("oauth_token") != t.Token || q.Get("oauth_verifier") == "" {
+			http.Error(rsp, "Invalid or missing token.", http.StatusBadRequest)
+			return
+		}
+
+		rsp.Header().Set("Content-Type", "text/plain")
+		rsp.Write([]byte("The application has been authorized. You can close this window."))
+
+		select {
+		case verifier_ch <- q.Get("oauth_verifier"):
+		default:
+		}
+	})
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go server.Serve(listener)
+
+	defer func() {
+		shutdown_ctx, shutdown_cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdown_cancel()
+		server.Shutdown(shutdown_ctx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Visit the following URL to authorize the application:\n\n%s\n\n", a.AuthorizationURL(t))
+	logger.Printf("Waiting for callback on %s", u.String())
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case err := <-err_ch:
+		return nil, err
+	case verifier := <-verifier_ch:
+		return a.AccessToken(ctx, t, verifier)
+	}
+}
+
+// isStdout returns a boolean value indicating whether 'uri' refers to STDOUT.
+func isStdout(uri string) bool {
+	return uri == "" || uri == "-" || strings.HasPrefix(uri, "stdout://")
+}
@@ -0,0 +1,38 @@
+package authorize
+
+import (
+	"flag"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/sfomuseum/go-flags/flagset"
+)
+
+// The consumer (application) key.
+var consumer_key string
+
+// The consumer (application) secret.
+var consumer_secret string
+
+// The URL of a local callback listener. If empty the PIN (out-of-band) flow is used.
+var callback_url string
+
+// The destination to write the credentials to.
+var output string
+
+// The base URL for the OAuth1 endpoints.
+var authorize_base string
+
+func DefaultFlagSet() *flag.FlagSet {
+
+	fs := flagset.NewFlagSet("authorize")
+
+	fs.StringVar(&consumer_key, "consumer-key", "", "The consumer (application) key.")
+	fs.StringVar(&consumer_secret, "consumer-secret", "", "The consumer (application) secret.")
+
+	fs.StringVar(&callback_url, "callback-url", "", "The URL of a local callback listener (for example http://127.0.0.1:8765/callback) that Twitter will redirect to once the application has been authorized. The URL must be registered as a callback URL for the application. If empty the PIN (out-of-band) flow is used.")
+
+	fs.StringVar(&output, "output", "-", "Where to write the JSON-encoded credentials. Valid options are \"-\" (STDOUT), a file:// URI or an awsparamstore:// URI (written as a SecureString parameter). These are the same URIs used to read credentials with the ?credentials= parameter.")
+
+	fs.StringVar(&authorize_base, "authorize-base", oauth.DEFAULT_AUTHORIZE_BASE, "The base URL for the OAuth1 request token, authorize and access token endpoints.")
+
+	return fs
+}
@@ -0,0 +1,118 @@
+package authorize
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-aws-session"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// writeCredentials writes 'body' to 'uri' which is expected to be "-" (STDOUT), a "file://" URI or an
+// "awsparamstore://" URI. The latter two take the same form as the `gocloud.dev/runtimevar` URIs used
+// to read credentials.
+func writeCredentials(ctx context.Context, uri string, body []byte) error {
+
+	if isStdout(uri) {
+
+		_, err := os.Stdout.Write(append(body, '\n'))
+		return err
+	}
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return err
+	}
+
+	switch u.Scheme {
+	case "file":
+		return writeFile(u.Path, body)
+	case "awsparamstore":
+		return writeParameter(ctx, u, body)
+	default:
+		return fmt.Errorf("Unsupported destination '%s'", u.Scheme)
+	}
+}
+
+// writeFile atomically replaces the contents of 'path' with 'body'. The file is only readable by the current user.
+func writeFile(path string, body []byte) error {
+
+	if path == "" {
+		return fmt.Errorf("Missing path")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary file, %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(body)
+
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write temporary file, %w", err)
+	}
+
+	err = tmp.Close()
+
+	if err != nil {
+		return fmt.Errorf("Failed to close temporary file, %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+
+	if err != nil {
+		return fmt.Errorf("Failed to replace %s, %w", path, err)
+	}
+
+	return nil
+}
+
+// writeParameter writes 'body' to the AWS Systems Manager parameter defined by 'u' as a SecureString, replacing any
+// existing value. 'u' is expected to take the form of:
+//
+//	awsparamstore://{NAME}?region={REGION}&credentials={CREDENTIALS}
+//
+// Where {CREDENTIALS} is a valid `aaronland/go-aws-session` credentials string.
+func writeParameter(ctx context.Context, u *url.URL, body []byte) error {
+
+	name := u.Host + u.Path
+
+	if name == "" {
+		return fmt.Errorf("Missing parameter name")
+	}
+
+	q := u.Query()
+
+	dsn := fmt.Sprintf("region=%s credentials=%s", q.Get("region"), q.Get("credentials"))
+
+	sess, err := session.NewSessionWithDSN(dsn)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create AWS session, %w", err)
+	}
+
+	svc := ssm.New(sess)
+
+	input := &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(string(body)),
+		Type:      aws.String(ssm.ParameterTypeSecureString),
+		Overwrite: aws.Bool(true),
+	}
+
+	_, err = svc.PutParameterWithContext(ctx, input)
+
+	if err != nil {
+		return fmt.Errorf("Failed to write parameter %s, %w", name, err)
+	}
+
+	return nil
+}
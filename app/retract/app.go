@@ -0,0 +1,126 @@
+// Package retract provides methods for implementing a command line tool for retracting (deleting) messages
+// previously broadcast to Twitter.
+package retract
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-uid"
+	"github.com/sfomuseum/go-flags/flagset"
+	"log"
+	"strconv"
+	"strings"
+)
+
+func Run(ctx context.Context, logger *log.Logger) error {
+	fs := DefaultFlagSet()
+	return RunWithFlagSet(ctx, fs, logger)
+}
+
+func RunWithFlagSet(ctx context.Context, fs *flag.FlagSet, logger *log.Logger) error {
+
+	flagset.Parse(fs)
+
+	if broadcaster_uri == "" {
+		return fmt.Errorf("Missing -broadcaster URI")
+	}
+
+	if len(uids) == 0 {
+		return fmt.Errorf("Missing -id flag")
+	}
+
+	br, err := twitter.NewTwitterBroadcaster(ctx, broadcaster_uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create broadcaster, %w", err)
+	}
+
+	br.SetLogger(ctx, logger)
+
+	tw_br, ok := br.(*twitter.TwitterBroadcaster)
+
+	if !ok {
+		return fmt.Errorf("Broadcaster does not support retractions")
+	}
+
+	for _, str_uid := range uids {
+
+		u, err := parseUID(ctx, str_uid)
+
+		if err != nil {
+			return fmt.Errorf("Failed to parse UID '%s', %w", str_uid, err)
+		}
+
+		err = tw_br.Retract(ctx, u)
+
+		if err != nil {
+			return fmt.Errorf("Failed to retract %s, %w", str_uid, err)
+		}
+	}
+
+	return nil
+}
+
+// parseUID returns a `uid.UID` instance for 'str_uid' which is expected to be either a (space-separated) list
+// of tweet IDs or the string representation of a UID as output by the broadcast command, for example
+// "Int64UID#1234", "MultiUID#Int64UID#1234 Int64UID#5678" or, for tweets published to one of several accounts,
+// "*twitter.AccountUID#sfo#1234 *twitter.AccountUID#sfomuseum#5678".
+func parseUID(ctx context.Context, str_uid string) (uid.UID, error) {
+
+	uids := make([]uid.UID, 0)
+
+	for _, token := range strings.Fields(str_uid) {
+
+		// Tweets published to one of several accounts, for example "*twitter.AccountUID#sfo#1234,5678"
+
+		if idx := strings.Index(token, "AccountUID#"); idx != -1 {
+
+			u, err := twitter.ParseAccountUID(ctx, token[idx:])
+
+			if err != nil {
+				return nil, err
+			}
+
+			uids = append(uids, u)
+			continue
+		}
+
+		idx := strings.LastIndex(token, "#")
+
+		if idx != -1 {
+			token = token[idx+1:]
+		}
+
+		if token == "" {
+			return nil, fmt.Errorf("Empty tweet ID")
+		}
+
+		var u uid.UID
+		var err error
+
+		id, parse_err := strconv.ParseInt(token, 10, 64)
+
+		if parse_err == nil {
+			u, err = uid.NewInt64UID(ctx, id)
+		} else {
+			u, err = uid.NewStringUID(ctx, token)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		uids = append(uids, u)
+	}
+
+	switch len(uids) {
+	case 0:
+		return nil, fmt.Errorf("Empty UID")
+	case 1:
+		return uids[0], nil
+	default:
+		return uid.NewMultiUID(ctx, uids...), nil
+	}
+}
@@ -0,0 +1,23 @@
+package retract
+
+import (
+	"flag"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/sfomuseum/go-flags/multi"
+)
+
+// A valid aaronland/go-broadcaster-twitter URI.
+var broadcaster_uri string
+
+// One or more UIDs, or tweet IDs, of messages to retract.
+var uids multi.MultiString
+
+func DefaultFlagSet() *flag.FlagSet {
+
+	fs := flagset.NewFlagSet("retract")
+
+	fs.StringVar(&broadcaster_uri, "broadcaster", "", "A valid aaronland/go-broadcaster-twitter URI.")
+	fs.Var(&uids, "id", "One or more UIDs, as output by the broadcast command, or tweet IDs of messages to retract. Multiple tweet IDs (for example a thread) may be passed in a single space-separated string.")
+
+	return fs
+}
@@ -0,0 +1,112 @@
+// Package schedule provides methods for implementing a command line tool for adding messages to a
+// `TwitterBroadcaster` schedule queue.
+package schedule
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/sfomuseum/go-flags/flagset"
+	"image"
+	"log"
+	"os"
+	"time"
+)
+
+func Run(ctx context.Context, logger *log.Logger) error {
+	fs := DefaultFlagSet()
+	return RunWithFlagSet(ctx, fs, logger)
+}
+
+func RunWithFlagSet(ctx context.Context, fs *flag.FlagSet, logger *log.Logger) error {
+
+	flagset.Parse(fs)
+
+	if broadcaster_uri == "" {
+		return fmt.Errorf("Missing -broadcaster URI")
+	}
+
+	t, err := parseSendAt(send_at)
+
+	if err != nil {
+		return fmt.Errorf("Invalid -send-at flag, %w", err)
+	}
+
+	br, err := twitter.NewTwitterBroadcaster(ctx, broadcaster_uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create broadcaster, %w", err)
+	}
+
+	br.SetLogger(ctx, logger)
+
+	tw_br, ok := br.(*twitter.TwitterBroadcaster)
+
+	if !ok {
+		return fmt.Errorf("Broadcaster does not support scheduled messages")
+	}
+
+	msg := &broadcaster.Message{
+		Title: title,
+		Body:  body,
+	}
+
+	count_images := len(image_paths)
+
+	if count_images > 0 {
+
+		msg.Images = make([]image.Image, count_images)
+
+		for idx, path := range image_paths {
+
+			r, err := os.Open(path)
+
+			if err != nil {
+				return fmt.Errorf("Failed to open image %s, %w", path, err)
+			}
+
+			defer r.Close()
+
+			im, err := twitter.NewEncodedImageFromReader(ctx, r)
+
+			if err != nil {
+				return fmt.Errorf("Failed to decode image %s, %w", path, err)
+			}
+
+			msg.Images[idx] = im
+		}
+	}
+
+	id, err := tw_br.ScheduleMessage(ctx, msg, t)
+
+	if err != nil {
+		return fmt.Errorf("Failed to schedule message, %w", err)
+	}
+
+	fmt.Println(id.String())
+	return nil
+}
+
+// parseSendAt parses 'str_t' as either an RFC3339 timestamp or a duration relative to the current time.
+func parseSendAt(str_t string) (time.Time, error) {
+
+	if str_t == "" {
+		return time.Time{}, fmt.Errorf("Missing value")
+	}
+
+	d, err := time.ParseDuration(str_t)
+
+	if err == nil {
+		return time.Now().Add(d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, str_t)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is neither an RFC3339 timestamp nor a duration", str_t)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,37 @@
+package schedule
+
+import (
+	"flag"
+	"github.com/sfomuseum/go-flags/flagset"
+	"github.com/sfomuseum/go-flags/multi"
+)
+
+// A valid aaronland/go-broadcaster-twitter URI. It must include a ?schedule= parameter.
+var broadcaster_uri string
+
+// The time to publish the message.
+var send_at string
+
+// The title of the message to schedule.
+var title string
+
+// The body of the message to schedule.
+var body string
+
+// Zero or more paths to images to include with the message to schedule.
+var image_paths multi.MultiString
+
+func DefaultFlagSet() *flag.FlagSet {
+
+	fs := flagset.NewFlagSet("schedule")
+
+	fs.StringVar(&broadcaster_uri, "broadcaster", "", "A valid aaronland/go-broadcaster-twitter URI. It must include a ?schedule= parameter.")
+	fs.StringVar(&send_at, "send-at", "", "The time to publish the message, either an RFC3339 timestamp or a duration (for example \"2h30m\") relative to now.")
+
+	fs.StringVar(&title, "title", "", "The title of the message to schedule.")
+	fs.StringVar(&body, "body", "", "The body of the message to schedule.")
+
+	fs.Var(&image_paths, "image", "Zero or more paths to images to include with the message to schedule.")
+
+	return fs
+}
@@ -0,0 +1,79 @@
+// Package scheduler provides methods for implementing a long-running command line tool for publishing messages
+// that have been added to a `TwitterBroadcaster` schedule queue.
+package scheduler
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/sfomuseum/go-flags/flagset"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func Run(ctx context.Context, logger *log.Logger) error {
+	fs := DefaultFlagSet()
+	return RunWithFlagSet(ctx, fs, logger)
+}
+
+func RunWithFlagSet(ctx context.Context, fs *flag.FlagSet, logger *log.Logger) error {
+
+	flagset.Parse(fs)
+
+	if broadcaster_uri == "" {
+		return fmt.Errorf("Missing -broadcaster URI")
+	}
+
+	if interval <= 0 {
+		return fmt.Errorf("Invalid -interval, must be greater than zero")
+	}
+
+	br, err := twitter.NewTwitterBroadcaster(ctx, broadcaster_uri)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create broadcaster, %w", err)
+	}
+
+	br.SetLogger(ctx, logger)
+
+	tw_br, ok := br.(*twitter.TwitterBroadcaster)
+
+	if !ok {
+		return fmt.Errorf("Broadcaster does not support scheduled messages")
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+
+		count, err := tw_br.PublishScheduled(ctx)
+
+		if err != nil && ctx.Err() == nil {
+			logger.Printf("Failed to publish scheduled messages, %v", err)
+		}
+
+		if count > 0 {
+			logger.Printf("Processed %d scheduled message(s)", count)
+		}
+
+		if once {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Printf("Shutting down")
+			return nil
+		case <-ticker.C:
+			// pass
+		}
+	}
+}
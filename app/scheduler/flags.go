@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"flag"
+	"github.com/sfomuseum/go-flags/flagset"
+	"time"
+)
+
+// A valid aaronland/go-broadcaster-twitter URI. It must include a ?schedule= parameter.
+var broadcaster_uri string
+
+// How often to check the queue for messages which are due to be published.
+var interval time.Duration
+
+// Publish any messages which are due and then exit.
+var once bool
+
+func DefaultFlagSet() *flag.FlagSet {
+
+	fs := flagset.NewFlagSet("scheduler")
+
+	fs.StringVar(&broadcaster_uri, "broadcaster", "", "A valid aaronland/go-broadcaster-twitter URI. It must include a ?schedule= parameter.")
+	fs.DurationVar(&interval, "interval", 30*time.Second, "How often to check the queue for messages which are due to be published.")
+	fs.BoolVar(&once, "once", false, "Publish any messages which are due and then exit.")
+
+	return fs
+}
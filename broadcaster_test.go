@@ -0,0 +1,473 @@
+package twitter_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/aaronland/go-broadcaster-twitter/twittertest"
+)
+
+// newTestServer returns a new `twittertest.Server` instance which is closed when 't' finishes.
+func newTestServer(t *testing.T, opts *twittertest.ServerOptions) *twittertest.Server {
+
+	t.Helper()
+
+	s, err := twittertest.NewServer(context.Background(), opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create test server, %v", err)
+	}
+
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newTestBroadcaster returns a new `TwitterBroadcaster` instance targeting 's' configured by 'params'. If 'http_client'
+// is nil the client for 's' is used.
+func newTestBroadcaster(t *testing.T, s *twittertest.Server, params url.Values, http_client *http.Client) *twitter.TwitterBroadcaster {
+
+	t.Helper()
+
+	uri, err := s.BroadcasterURI(params)
+
+	if err != nil {
+		t.Fatalf("Failed to derive broadcaster URI, %v", err)
+	}
+
+	return newTestBroadcasterWithURI(t, s, uri, http_client)
+}
+
+// newTestAccounts adds an account to 's' for each of 'names' and returns their credentials keyed by name.
+func newTestAccounts(t *testing.T, s *twittertest.Server, names ...string) map[string]oauth.Credentials {
+
+	t.Helper()
+
+	accounts := make(map[string]oauth.Credentials)
+
+	for idx, name := range names {
+
+		user := &twittertest.User{
+			Id:       strconv.Itoa(1000 + idx),
+			Name:     name,
+			Username: name,
+		}
+
+		creds, err := s.AddAccount(user)
+
+		if err != nil {
+			t.Fatalf("Failed to add account '%s', %v", name, err)
+		}
+
+		accounts[name] = creds
+	}
+
+	return accounts
+}
+
+// newTestAccountsBroadcaster returns a new `TwitterBroadcaster` instance targeting 's', using the credentials for
+// 'accounts', configured by 'params'.
+func newTestAccountsBroadcaster(t *testing.T, s *twittertest.Server, accounts map[string]oauth.Credentials, params url.Values) *twitter.TwitterBroadcaster {
+
+	t.Helper()
+
+	uri, err := s.AccountsBroadcasterURI(accounts, params)
+
+	if err != nil {
+		t.Fatalf("Failed to derive broadcaster URI, %v", err)
+	}
+
+	return newTestBroadcasterWithURI(t, s, uri, nil)
+}
+
+// newTestBroadcasterWithURI returns a new `TwitterBroadcaster` instance for 'uri' which targets 's'. If 'http_client'
+// is nil the client for 's' is used.
+func newTestBroadcasterWithURI(t *testing.T, s *twittertest.Server, uri string, http_client *http.Client) *twitter.TwitterBroadcaster {
+
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	if http_client == nil {
+		http_client = s.Client()
+	}
+
+	opts := &twitter.TwitterBroadcasterOptions{
+		HTTPClient: http_client,
+	}
+
+	br, err := twitter.NewTwitterBroadcasterWithOptions(ctx, uri, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create broadcaster for %s, %v", uri, err)
+	}
+
+	tw_br := br.(*twitter.TwitterBroadcaster)
+	tw_br.SetLogger(ctx, log.New(io.Discard, "", 0))
+
+	return tw_br
+}
+
+// broadcast publishes a message whose body is 'body' using 'br'.
+func broadcast(t *testing.T, br broadcaster.Broadcaster, body string) (string, error) {
+
+	t.Helper()
+
+	msg := &broadcaster.Message{
+		Body: body,
+	}
+
+	u, err := br.BroadcastMessage(context.Background(), msg)
+
+	if u == nil {
+		return "", err
+	}
+
+	return u.String(), err
+}
+
+// newTestImage returns a new 'w' x 'h' image filled with a single colour.
+func newTestImage(w int, h int) image.Image {
+
+	im := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(im, im.Bounds(), &image.Uniform{color.RGBA{255, 0, 0, 255}}, image.Point{}, draw.Src)
+
+	return im
+}
+
+func TestBroadcastMessage(t *testing.T) {
+
+	s := newTestServer(t, nil)
+	br := newTestBroadcaster(t, s, nil, nil)
+
+	id, err := broadcast(t, br, "hello world")
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	tweets := s.Tweets()
+
+	if len(tweets) != 1 {
+		t.Fatalf("Expected 1 tweet, got %d", len(tweets))
+	}
+
+	tw := tweets[0]
+
+	if tw.Text != "hello world" || tw.API != "v2" {
+		t.Fatalf("Unexpected tweet %+v", tw)
+	}
+
+	if !strings.Contains(id, tw.Id) {
+		t.Fatalf("Expected '%s' to contain tweet ID %s", id, tw.Id)
+	}
+
+	if len(s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_STATUSES_UPDATE)) != 0 {
+		t.Fatalf("Expected the v1.1 API not to be used")
+	}
+}
+
+func TestBroadcastMessageV1(t *testing.T) {
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("api", "v1.1")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	_, err := broadcast(t, br, "hello world")
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	tweets := s.Tweets()
+
+	if len(tweets) != 1 || tweets[0].API != "v1.1" {
+		t.Fatalf("Expected 1 tweet published using the v1.1 API, got %d", len(tweets))
+	}
+
+	if len(s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_STATUSES_UPDATE)) != 1 {
+		t.Fatalf("Expected 1 request to %s", twittertest.ENDPOINT_STATUSES_UPDATE)
+	}
+
+	if len(s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_TWEETS)) != 0 {
+		t.Fatalf("Expected the v2 API not to be used")
+	}
+}
+
+func TestBroadcastMessageImages(t *testing.T) {
+
+	s := newTestServer(t, nil)
+	br := newTestBroadcaster(t, s, nil, nil)
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+		Images: []image.Image{
+			twitter.NewDescribedImage(newTestImage(64, 48), "A red rectangle"),
+			newTestImage(32, 32),
+		},
+	}
+
+	_, err := br.BroadcastMessage(context.Background(), msg)
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	tweets := s.Tweets()
+
+	if len(tweets) != 1 || len(tweets[0].MediaIds) != 2 {
+		t.Fatalf("Expected 1 tweet with 2 images")
+	}
+
+	described, ok := s.Media(tweets[0].MediaIds[0])
+
+	if !ok {
+		t.Fatalf("Failed to find media %s", tweets[0].MediaIds[0])
+	}
+
+	if described.AltText != "A red rectangle" || described.Width != 64 || described.Height != 48 {
+		t.Fatalf("Unexpected media %s (%dx%d) '%s'", described.Id, described.Width, described.Height, described.AltText)
+	}
+
+	other, ok := s.Media(tweets[0].MediaIds[1])
+
+	if !ok {
+		t.Fatalf("Failed to find media %s", tweets[0].MediaIds[1])
+	}
+
+	if other.AltText != "" {
+		t.Fatalf("Expected media %s not to have alt text", other.Id)
+	}
+
+	if len(s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_MEDIA_METADATA)) != 1 {
+		t.Fatalf("Expected 1 request to %s", twittertest.ENDPOINT_MEDIA_METADATA)
+	}
+}
+
+func TestBroadcastMessageDuplicate(t *testing.T) {
+
+	s := newTestServer(t, nil)
+	br := newTestBroadcaster(t, s, nil, nil)
+
+	_, err := broadcast(t, br, "hello world")
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	_, err = broadcast(t, br, "hello world")
+
+	if !errors.Is(err, twitter.ErrDuplicateStatus) {
+		t.Fatalf("Expected ErrDuplicateStatus, got %v", err)
+	}
+
+	s.InjectDuplicateStatus(1)
+
+	_, err = broadcast(t, br, "hello again")
+
+	if !errors.Is(err, twitter.ErrDuplicateStatus) {
+		t.Fatalf("Expected injected ErrDuplicateStatus, got %v", err)
+	}
+
+	// Duplicate statuses are not retried
+
+	if len(s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_TWEETS)) != 3 {
+		t.Fatalf("Expected 3 requests to %s", twittertest.ENDPOINT_TWEETS)
+	}
+
+	if len(s.Tweets()) != 1 {
+		t.Fatalf("Expected 1 tweet, got %d", len(s.Tweets()))
+	}
+}
+
+func TestBroadcastMessageRateLimited(t *testing.T) {
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("max-wait", "5s")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	s.InjectRateLimit(twittertest.ENDPOINT_TWEETS, 1, time.Hour)
+
+	_, err := broadcast(t, br, "hello world")
+
+	if !errors.Is(err, twitter.ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+
+	var rate_err *twitter.RateLimitError
+
+	if !errors.As(err, &rate_err) || time.Until(rate_err.Reset) < 30*time.Minute {
+		t.Fatalf("Expected RateLimitError with a reset time, got %v", err)
+	}
+
+	// Rate limits which reset within ?max-wait= are retried
+
+	s.InjectRateLimit(twittertest.ENDPOINT_TWEETS, 1, 0)
+
+	_, err = broadcast(t, br, "hello world")
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message after rate limit reset, %v", err)
+	}
+
+	if len(s.Tweets()) != 1 {
+		t.Fatalf("Expected 1 tweet, got %d", len(s.Tweets()))
+	}
+
+	if len(s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_TWEETS)) != 3 {
+		t.Fatalf("Expected 3 requests to %s", twittertest.ENDPOINT_TWEETS)
+	}
+}
+
+func TestBroadcastMessageDedupe(t *testing.T) {
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("dedupe", "mem://")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	first, err := broadcast(t, br, "hello world")
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	second, err := broadcast(t, br, "hello world")
+
+	if err != nil {
+		t.Fatalf("Expected duplicate message to return the previous tweet, %v", err)
+	}
+
+	if first != second {
+		t.Fatalf("Expected '%s' to equal '%s'", second, first)
+	}
+
+	if len(s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_TWEETS)) != 1 {
+		t.Fatalf("Expected duplicate message not to be published")
+	}
+}
+
+func TestBroadcastRequireAltText(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("require-alt-text", "true")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	msg := &broadcaster.Message{
+		Body:   "hello world",
+		Images: []image.Image{newTestImage(32, 32)},
+	}
+
+	_, err := br.BroadcastMessage(ctx, msg)
+
+	if err == nil {
+		t.Fatalf("Expected image without alt text to fail")
+	}
+
+	if len(s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_MEDIA_UPLOAD)) != 0 || len(s.Tweets()) != 0 {
+		t.Fatalf("Expected nothing to be uploaded or published")
+	}
+
+	msg.Images = []image.Image{
+		twitter.NewDescribedImage(newTestImage(32, 32), strings.Repeat("a", twitter.MAX_ALT_TEXT_LENGTH+1)),
+	}
+
+	_, err = br.BroadcastMessage(ctx, msg)
+
+	if err == nil {
+		t.Fatalf("Expected image with over-length alt text to fail")
+	}
+
+	s.InjectFailure(&twittertest.Failure{
+		Method:     http.MethodPost,
+		Path:       twittertest.ENDPOINT_MEDIA_METADATA,
+		StatusCode: http.StatusBadRequest,
+		Message:    "Invalid metadata",
+		Times:      1,
+	})
+
+	msg.Images = []image.Image{
+		twitter.NewDescribedImage(newTestImage(32, 32), "A red square"),
+	}
+
+	_, err = br.BroadcastMessage(ctx, msg)
+
+	if err == nil {
+		t.Fatalf("Expected failure to publish alt text to fail")
+	}
+
+	if len(s.Tweets()) != 0 {
+		t.Fatalf("Expected nothing to be published")
+	}
+}
+
+func TestBroadcastOptionalAltText(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+	br := newTestBroadcaster(t, s, nil, nil)
+
+	var buf bytes.Buffer
+	br.SetLogger(ctx, log.New(&buf, "", 0))
+
+	s.InjectFailure(&twittertest.Failure{
+		Method:     http.MethodPost,
+		Path:       twittertest.ENDPOINT_MEDIA_METADATA,
+		StatusCode: http.StatusBadRequest,
+		Message:    "Invalid metadata",
+		Times:      1,
+	})
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+		Images: []image.Image{
+			twitter.NewDescribedImage(newTestImage(32, 32), "A red square"),
+			newTestImage(32, 32),
+		},
+	}
+
+	_, err := br.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+		t.Fatalf("Expected message to be published without alt text, %v", err)
+	}
+
+	tweets := s.Tweets()
+
+	if len(tweets) != 1 || len(tweets[0].MediaIds) != 2 {
+		t.Fatalf("Expected 1 tweet with 2 images")
+	}
+
+	if buf.Len() == 0 {
+		t.Fatalf("Expected missing and failed alt text to be logged")
+	}
+}
@@ -0,0 +1,185 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// API_V2 is the value of the `?api=` parameter used to select the Twitter v2 API (the default).
+const API_V2 string = "v2"
+
+// API_V1 is the value of the `?api=` parameter used to select the legacy Twitter v1.1 API.
+const API_V1 string = "v1.1"
+
+// DEFAULT_API_BASE is the default base URL for Twitter API requests.
+const DEFAULT_API_BASE string = "https://api.twitter.com"
+
+// DEFAULT_UPLOAD_BASE is the default base URL for Twitter media upload requests.
+const DEFAULT_UPLOAD_BASE string = "https://upload.twitter.com"
+
+// client defines the subset of the Twitter API used by `TwitterBroadcaster`.
+type client interface {
+	// VerifyCredentials ensures that the credentials associated with the client are valid and returns the account they belong to.
+	VerifyCredentials(context.Context) (*Account, error)
+	// UploadMedia uploads a media file with a given content type and returns its media ID.
+	UploadMedia(context.Context, []byte, string) (string, error)
+	// CreateMediaMetadata associates a description (alt text) with a previously uploaded media ID.
+	CreateMediaMetadata(context.Context, string, string) error
+	// PostTweet publishes a new tweet and returns its ID.
+	PostTweet(context.Context, *tweetRequest) (string, error)
+	// DeleteTweet deletes a tweet.
+	DeleteTweet(context.Context, string) error
+}
+
+// Account describes the Twitter account associated with a set of credentials.
+type Account struct {
+	// Id is the numeric ID of the account.
+	Id string `json:"id"`
+	// ScreenName is the screen name (username) of the account.
+	ScreenName string `json:"screen_name"`
+	// Name is the display name of the account.
+	Name string `json:"name"`
+}
+
+// String returns a string representation of 'a'.
+func (a *Account) String() string {
+	return fmt.Sprintf("@%s (%s)", a.ScreenName, a.Id)
+}
+
+// clientOptions defines configuration options for creating a new `client` instance.
+type clientOptions struct {
+	// Credentials are the OAuth1 credentials used to sign API requests. Exactly one of 'Credentials' or 'OAuth2' must be set.
+	Credentials *oauth.OAuth1Credentials
+	// OAuth2 is the source of the OAuth2 access tokens used to authorize API requests. Exactly one of 'Credentials' or 'OAuth2' must be set.
+	OAuth2 *oauth.OAuth2TokenSource
+	// ChunkSize is the number of bytes to send with each chunked media upload APPEND request.
+	ChunkSize int64
+	// APIBase is the base URL for Twitter API requests. If empty `DEFAULT_API_BASE` is used.
+	APIBase string
+	// UploadBase is the base URL for Twitter media upload requests. If empty `DEFAULT_UPLOAD_BASE` is used.
+	UploadBase string
+	// HTTPClient is the `http.Client` used to execute API requests. If nil `http.DefaultClient` is used.
+	HTTPClient *http.Client
+}
+
+// apiBase returns the base URL for Twitter API requests defined by 'opts'.
+func (opts *clientOptions) apiBase() string {
+
+	if opts.APIBase == "" {
+		return DEFAULT_API_BASE
+	}
+
+	return opts.APIBase
+}
+
+// uploadBase returns the base URL for Twitter media upload requests defined by 'opts'.
+func (opts *clientOptions) uploadBase() string {
+
+	if opts.UploadBase == "" {
+		return DEFAULT_UPLOAD_BASE
+	}
+
+	return opts.UploadBase
+}
+
+// httpClient returns the `http.Client` defined by 'opts'.
+func (opts *clientOptions) httpClient() *http.Client {
+
+	if opts.HTTPClient == nil {
+		return http.DefaultClient
+	}
+
+	return opts.HTTPClient
+}
+
+// tweetRequest defines the properties of a tweet to publish. It is encoded as the JSON body of
+// a Twitter v2 `POST /2/tweets` request.
+type tweetRequest struct {
+	// Text is the text of the tweet.
+	Text string `json:"text"`
+	// Media is the (optional) media to attach to the tweet.
+	Media *tweetRequestMedia `json:"media,omitempty"`
+	// Reply is the (optional) tweet that this tweet is a reply to.
+	Reply *tweetRequestReply `json:"reply,omitempty"`
+}
+
+// tweetRequestMedia defines media properties for a `tweetRequest`.
+type tweetRequestMedia struct {
+	// MediaIds is the list of previously uploaded media IDs to attach to a tweet.
+	MediaIds []string `json:"media_ids"`
+}
+
+// tweetRequestReply defines reply properties for a `tweetRequest`.
+type tweetRequestReply struct {
+	// InReplyToTweetId is the ID of the tweet being replied to.
+	InReplyToTweetId string `json:"in_reply_to_tweet_id"`
+}
+
+// SetInReplyTo marks 'r' as a reply to the tweet 'tweet_id'.
+func (r *tweetRequest) SetInReplyTo(tweet_id string) {
+
+	r.Reply = &tweetRequestReply{
+		InReplyToTweetId: tweet_id,
+	}
+}
+
+// InReplyTo returns the ID of the tweet that 'r' is a reply to, or an empty string.
+func (r *tweetRequest) InReplyTo() string {
+
+	if r.Reply == nil {
+		return ""
+	}
+
+	return r.Reply.InReplyToTweetId
+}
+
+// AddMediaId appends 'media_id' to the list of media IDs associated with 'r'.
+func (r *tweetRequest) AddMediaId(media_id string) {
+
+	if r.Media == nil {
+		r.Media = &tweetRequestMedia{
+			MediaIds: make([]string, 0),
+		}
+	}
+
+	r.Media.MediaIds = append(r.Media.MediaIds, media_id)
+}
+
+// MediaIds returns the list of media IDs associated with 'r'.
+func (r *tweetRequest) MediaIds() []string {
+
+	if r.Media == nil {
+		return []string{}
+	}
+
+	return r.Media.MediaIds
+}
+
+// parseBaseURL ensures that 'uri' is an absolute HTTP(S) URL suitable for use as an API base URL and returns
+// it without a trailing slash.
+func parseBaseURL(uri string) (string, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("Unsupported scheme '%s'", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return "", fmt.Errorf("Missing host")
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("Base URL must not contain a query or fragment")
+	}
+
+	return strings.TrimRight(u.String(), "/"), nil
+}
@@ -0,0 +1,114 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DRYRUN_ID_PREFIX is the prefix for the synthetic tweet and media IDs returned in "dry-run" mode.
+const DRYRUN_ID_PREFIX string = "dryrun"
+
+// dryRunClient implements the `client` interface by logging a description of every request that would publish,
+// or delete, something, using the endpoints for the configured Twitter API, instead of sending it to the Twitter API.
+// Read-only requests, like credential verification, are sent using the wrapped `client`.
+type dryRunClient struct {
+	client
+	logger      *log.Logger
+	api         string
+	api_base    string
+	upload_base string
+	prefix      string
+	counter     int64
+}
+
+func newDryRunClient(ctx context.Context, c client, api string, opts *clientOptions, logger *log.Logger) (*dryRunClient, error) {
+
+	dr := &dryRunClient{
+		client:      c,
+		logger:      logger,
+		api:         api,
+		api_base:    opts.apiBase(),
+		upload_base: opts.uploadBase(),
+		prefix:      fmt.Sprintf("%s-%d", DRYRUN_ID_PREFIX, time.Now().Unix()),
+	}
+
+	return dr, nil
+}
+
+// UploadMedia logs the media that would have been uploaded and returns a synthetic media ID.
+func (c *dryRunClient) UploadMedia(ctx context.Context, body []byte, content_type string) (string, error) {
+
+	media_id := c.nextId()
+
+	c.logger.Printf("[dry-run] POST %s/1.1/media/upload.json %s (%d bytes, %s) -> %s", c.upload_base, MediaCategory(content_type), len(body), content_type, media_id)
+	return media_id, nil
+}
+
+// CreateMediaMetadata logs the alt text that would have been associated with 'media_id'.
+func (c *dryRunClient) CreateMediaMetadata(ctx context.Context, media_id string, alt_text string) error {
+	c.logger.Printf("[dry-run] POST %s/1.1/media/metadata/create.json media_id=%s alt_text=%q", c.upload_base, media_id, alt_text)
+	return nil
+}
+
+// PostTweet logs the body of the tweet that would have been published and returns a synthetic tweet ID. For the
+// v2 API the body is the JSON-encoded tweet; for the v1.1 API it is the form-encoded `statuses/update` parameters.
+func (c *dryRunClient) PostTweet(ctx context.Context, tw *tweetRequest) (string, error) {
+
+	if c.api == API_V1 {
+
+		params := url.Values{}
+		params.Set("status", tw.Text)
+
+		media_ids := tw.MediaIds()
+
+		if len(media_ids) > 0 {
+			params.Set("media_ids", strings.Join(media_ids, ","))
+		}
+
+		reply_to := tw.InReplyTo()
+
+		if reply_to != "" {
+			params.Set("in_reply_to_status_id", reply_to)
+			params.Set("auto_populate_reply_metadata", "true")
+		}
+
+		tweet_id := c.nextId()
+
+		c.logger.Printf("[dry-run] POST %s/1.1/statuses/update.json %s (%d characters) -> %s", c.api_base, params.Encode(), statusLength(tw.Text), tweet_id)
+		return tweet_id, nil
+	}
+
+	enc_tw, err := json.Marshal(tw)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to marshal tweet, %w", err)
+	}
+
+	tweet_id := c.nextId()
+
+	c.logger.Printf("[dry-run] POST %s/2/tweets %s (%d characters) -> %s", c.api_base, enc_tw, statusLength(tw.Text), tweet_id)
+	return tweet_id, nil
+}
+
+// DeleteTweet logs the tweet that would have been deleted.
+func (c *dryRunClient) DeleteTweet(ctx context.Context, tweet_id string) error {
+
+	if c.api == API_V1 {
+		c.logger.Printf("[dry-run] POST %s/1.1/statuses/destroy/%s.json", c.api_base, tweet_id)
+		return nil
+	}
+
+	c.logger.Printf("[dry-run] DELETE %s/2/tweets/%s", c.api_base, tweet_id)
+	return nil
+}
+
+func (c *dryRunClient) nextId() string {
+	i := atomic.AddInt64(&c.counter, 1)
+	return fmt.Sprintf("%s-%d", c.prefix, i)
+}
@@ -0,0 +1,77 @@
+package twitter
+
+import (
+	"context"
+	"net/url"
+	"testing"
+)
+
+func TestParseBaseURL(t *testing.T) {
+
+	valid := map[string]string{
+		"https://api.twitter.com":        "https://api.twitter.com",
+		"http://localhost:8080/":         "http://localhost:8080",
+		"https://example.com/twitter/v/": "https://example.com/twitter/v",
+	}
+
+	for uri, expected := range valid {
+
+		base, err := parseBaseURL(uri)
+
+		if err != nil {
+			t.Fatalf("Failed to parse '%s', %v", uri, err)
+		}
+
+		if base != expected {
+			t.Fatalf("Expected '%s' to be parsed as '%s', got '%s'", uri, expected, base)
+		}
+	}
+
+	invalid := []string{
+		"ftp://example.com",
+		"example.com",
+		"https://",
+		"https://example.com?debug=1",
+		"https://example.com#fragment",
+	}
+
+	for _, uri := range invalid {
+
+		_, err := parseBaseURL(uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to be invalid", uri)
+		}
+	}
+}
+
+func TestNewTwitterBroadcasterInvalidBaseURL(t *testing.T) {
+
+	ctx := context.Background()
+
+	q := url.Values{}
+	q.Set("credentials", `constant://?val={"consumer_key":"a","consumer_secret":"b","access_token":"c","access_secret":"d"}`)
+	q.Set("mode", MODE_DRYRUN)
+	q.Set("verify", VERIFY_NEVER)
+	q.Set("api-base", "http://localhost:8080")
+	q.Set("upload-base", "http://localhost:8080")
+
+	_, err := NewTwitterBroadcaster(ctx, "twitter://?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create broadcaster, %v", err)
+	}
+
+	for _, param := range []string{"api-base", "upload-base"} {
+
+		q.Set(param, "ftp://example.com")
+
+		_, err := NewTwitterBroadcaster(ctx, "twitter://?"+q.Encode())
+
+		if err == nil {
+			t.Fatalf("Expected invalid ?%s= parameter to fail", param)
+		}
+
+		q.Set(param, "http://localhost:8080")
+	}
+}
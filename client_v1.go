@@ -0,0 +1,136 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"github.com/ChimeraCoder/anaconda"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// v1Client implements the `client` interface for the legacy Twitter v1.1 API using
+// the `ChimeraCoder/anaconda` package. Media are uploaded using the same (native) uploader
+// as `v2Client` since anaconda does not support chunked uploads for anything but video.
+type v1Client struct {
+	client
+	twitter_client *anaconda.TwitterApi
+	uploader       *mediaUploader
+}
+
+func newV1Client(ctx context.Context, opts *clientOptions) (*v1Client, error) {
+
+	creds := opts.Credentials
+
+	if creds == nil {
+		return nil, fmt.Errorf("The v1.1 API requires OAuth1 credentials")
+	}
+
+	tw_client := anaconda.NewTwitterApiWithCredentials(creds.AccessToken, creds.AccessSecret, creds.ConsumerKey, creds.ConsumerSecret)
+	tw_client.HttpClient = opts.httpClient()
+
+	// Rate limit errors are handled by `retryClient` rather than anaconda's (unbounded) internal queue
+	tw_client.ReturnRateLimitError(true)
+	tw_client.SetBaseUrl(opts.apiBase() + "/1.1")
+
+	signer := newOAuth1Signer(creds.ConsumerKey, creds.ConsumerSecret, creds.AccessToken, creds.AccessSecret)
+	http_client := newSignedClient(opts.httpClient(), signer)
+
+	uploader, err := newMediaUploader(ctx, http_client, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &v1Client{
+		twitter_client: tw_client,
+		uploader:       uploader,
+	}
+
+	return c, nil
+}
+
+// VerifyCredentials ensures that the credentials associated with 'c' are valid and returns the account they belong to.
+func (c *v1Client) VerifyCredentials(ctx context.Context) (*Account, error) {
+
+	params := url.Values{}
+	params.Set("include_entities", "false")
+	params.Set("skip_status", "true")
+
+	u, err := c.twitter_client.GetSelf(params)
+
+	if err != nil {
+		return nil, classifyError(err)
+	}
+
+	if u.IdStr == "" {
+		return nil, fmt.Errorf("Failed to verify credentials, response is missing user ID")
+	}
+
+	a := &Account{
+		Id:         u.IdStr,
+		ScreenName: u.ScreenName,
+		Name:       u.Name,
+	}
+
+	return a, nil
+}
+
+// PostTweet publishes 'tw' using the v1.1 `statuses/update` endpoint and returns the ID of the new tweet.
+func (c *v1Client) PostTweet(ctx context.Context, tw *tweetRequest) (string, error) {
+
+	params := url.Values{}
+
+	media_ids := tw.MediaIds()
+
+	if len(media_ids) > 0 {
+		params.Set("media_ids", strings.Join(media_ids, ","))
+	}
+
+	reply_to := tw.InReplyTo()
+
+	if reply_to != "" {
+		params.Set("in_reply_to_status_id", reply_to)
+		params.Set("auto_populate_reply_metadata", "true")
+	}
+
+	rsp, err := c.twitter_client.PostTweet(tw.Text, params)
+
+	if err != nil {
+		return "", classifyError(err)
+	}
+
+	if rsp.IdStr == "" {
+		return "", fmt.Errorf("Failed to post tweet, response is missing tweet ID")
+	}
+
+	return rsp.IdStr, nil
+}
+
+// DeleteTweet deletes the tweet 'tweet_id' using the v1.1 `statuses/destroy` endpoint.
+func (c *v1Client) DeleteTweet(ctx context.Context, tweet_id string) error {
+
+	id, err := strconv.ParseInt(tweet_id, 10, 64)
+
+	if err != nil {
+		return fmt.Errorf("Invalid tweet ID '%s', %w", tweet_id, err)
+	}
+
+	_, err = c.twitter_client.DeleteTweet(id, true)
+
+	if err != nil {
+		return classifyError(err)
+	}
+
+	return nil
+}
+
+// UploadMedia uploads 'body' whose content type is 'content_type' and returns its media ID.
+func (c *v1Client) UploadMedia(ctx context.Context, body []byte, content_type string) (string, error) {
+	return c.uploader.Upload(ctx, body, content_type)
+}
+
+// CreateMediaMetadata associates 'alt_text' with the previously uploaded media 'media_id'.
+func (c *v1Client) CreateMediaMetadata(ctx context.Context, media_id string, alt_text string) error {
+	return c.uploader.CreateMetadata(ctx, media_id, alt_text)
+}
@@ -0,0 +1,165 @@
+package twitter
+
+// https://developer.twitter.com/en/docs/twitter-api/tweets/manage-tweets/api-reference/post-tweets
+// https://developer.twitter.com/en/docs/twitter-api/users/lookup/api-reference/get-users-me
+// https://developer.twitter.com/en/support/twitter-api/error-troubleshooting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// v2Client implements the `client` interface for the Twitter v2 API. Tweets are published using
+// the `POST /2/tweets` endpoint and media are uploaded using the (v1.1) media upload endpoint
+// since that is what the v2 API expects.
+type v2Client struct {
+	client
+	http_client *signedClient
+	uploader    *mediaUploader
+	api_base    string
+}
+
+type v2TweetResponse struct {
+	Data struct {
+		Id   string `json:"id"`
+		Text string `json:"text"`
+	} `json:"data"`
+}
+
+type v2DeleteResponse struct {
+	Data struct {
+		Deleted bool `json:"deleted"`
+	} `json:"data"`
+}
+
+type v2UserResponse struct {
+	Data struct {
+		Id       string `json:"id"`
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"data"`
+}
+
+func newV2Client(ctx context.Context, opts *clientOptions) (*v2Client, error) {
+
+	signer, err := newRequestSigner(opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	http_client := newSignedClient(opts.httpClient(), signer)
+
+	uploader, err := newMediaUploader(ctx, http_client, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := &v2Client{
+		http_client: http_client,
+		uploader:    uploader,
+		api_base:    opts.apiBase(),
+	}
+
+	return c, nil
+}
+
+// VerifyCredentials ensures that the credentials associated with 'c' are valid, and returns the account they belong to, by requesting
+// the `GET /2/users/me` endpoint.
+func (c *v2Client) VerifyCredentials(ctx context.Context) (*Account, error) {
+
+	var rsp v2UserResponse
+
+	err := c.http_client.get(ctx, c.api_base+"/2/users/me", nil, &rsp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rsp.Data.Id == "" {
+		return nil, fmt.Errorf("Failed to verify credentials, response is missing user ID")
+	}
+
+	a := &Account{
+		Id:         rsp.Data.Id,
+		ScreenName: rsp.Data.Username,
+		Name:       rsp.Data.Name,
+	}
+
+	return a, nil
+}
+
+// PostTweet publishes 'tw' using the `POST /2/tweets` endpoint and returns the ID of the new tweet.
+func (c *v2Client) PostTweet(ctx context.Context, tw *tweetRequest) (string, error) {
+
+	enc_tw, err := json.Marshal(tw)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to marshal tweet, %w", err)
+	}
+
+	uri := c.api_base + "/2/tweets"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(enc_tw))
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	var rsp v2TweetResponse
+
+	err = c.http_client.do(req, nil, &rsp)
+
+	if err != nil {
+		return "", err
+	}
+
+	if rsp.Data.Id == "" {
+		return "", fmt.Errorf("Failed to post tweet, response is missing tweet ID")
+	}
+
+	return rsp.Data.Id, nil
+}
+
+// DeleteTweet deletes the tweet 'tweet_id' using the `DELETE /2/tweets/{ID}` endpoint.
+func (c *v2Client) DeleteTweet(ctx context.Context, tweet_id string) error {
+
+	uri := c.api_base + "/2/tweets/" + url.PathEscape(tweet_id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, uri, nil)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	var rsp v2DeleteResponse
+
+	err = c.http_client.do(req, nil, &rsp)
+
+	if err != nil {
+		return err
+	}
+
+	if !rsp.Data.Deleted {
+		return fmt.Errorf("Failed to delete tweet %s, response did not confirm deletion", tweet_id)
+	}
+
+	return nil
+}
+
+// UploadMedia uploads 'body' whose content type is 'content_type' and returns its media ID.
+func (c *v2Client) UploadMedia(ctx context.Context, body []byte, content_type string) (string, error) {
+	return c.uploader.Upload(ctx, body, content_type)
+}
+
+// CreateMediaMetadata associates 'alt_text' with the previously uploaded media 'media_id'.
+func (c *v2Client) CreateMediaMetadata(ctx context.Context, media_id string, alt_text string) error {
+	return c.uploader.CreateMetadata(ctx, media_id, alt_text)
+}
@@ -0,0 +1,96 @@
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+)
+
+func newTestV2Client(t *testing.T, handler http.HandlerFunc) *v2Client {
+
+	t.Helper()
+
+	s := httptest.NewServer(handler)
+	t.Cleanup(s.Close)
+
+	opts := &clientOptions{
+		Credentials: &oauth.OAuth1Credentials{
+			ConsumerKey:    "consumer-key",
+			ConsumerSecret: "consumer-secret",
+			AccessToken:    "access-token",
+			AccessSecret:   "access-secret",
+		},
+		APIBase:    s.URL,
+		UploadBase: s.URL,
+		HTTPClient: s.Client(),
+	}
+
+	c, err := newV2Client(context.Background(), opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create client, %v", err)
+	}
+
+	return c
+}
+
+func TestV2ClientEmptyResponse(t *testing.T) {
+
+	ctx := context.Background()
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+		rsp.WriteHeader(http.StatusOK)
+	})
+
+	_, err := c.VerifyCredentials(ctx)
+
+	if err == nil {
+		t.Fatalf("Expected VerifyCredentials to fail with an empty response")
+	}
+
+	_, err = c.PostTweet(ctx, &tweetRequest{Text: "hello world"})
+
+	if err == nil {
+		t.Fatalf("Expected PostTweet to fail with an empty response")
+	}
+
+	err = c.DeleteTweet(ctx, "1234")
+
+	if err == nil {
+		t.Fatalf("Expected DeleteTweet to fail with an empty response")
+	}
+}
+
+func TestV2ClientPostTweet(t *testing.T) {
+
+	ctx := context.Background()
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.Method != http.MethodPost || req.URL.Path != "/2/tweets" {
+			http.Error(rsp, "Not found", http.StatusNotFound)
+			return
+		}
+
+		if req.Header.Get("Authorization") == "" {
+			http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		rsp.Header().Set("Content-Type", "application/json")
+		rsp.Write([]byte(`{"data":{"id":"1234","text":"hello world"}}`))
+	})
+
+	id, err := c.PostTweet(ctx, &tweetRequest{Text: "hello world"})
+
+	if err != nil {
+		t.Fatalf("Failed to post tweet, %v", err)
+	}
+
+	if id != "1234" {
+		t.Fatalf("Unexpected tweet ID '%s'", id)
+	}
+}
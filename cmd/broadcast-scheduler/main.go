@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"github.com/aaronland/go-broadcaster-twitter/app/scheduler"
+	"log"
+)
+
+func main() {
+
+	ctx := context.Background()
+	logger := log.Default()
+
+	err := scheduler.Run(ctx, logger)
+
+	if err != nil {
+		logger.Fatalf("Failed to run broadcast scheduler application, %v", err)
+	}
+}
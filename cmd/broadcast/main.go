@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	_ "github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-broadcaster-twitter/app/retract"
+	"github.com/aaronland/go-broadcaster-twitter/app/schedule"
 	"github.com/aaronland/go-broadcaster/app/broadcast"
-	_ "github.com/aaronland/go-broadcaster-twitter"	
 	"log"
+	"os"
 )
 
 func main() {
@@ -12,6 +15,39 @@ func main() {
 	ctx := context.Background()
 	logger := log.Default()
 
+	// Usage:
+	//	broadcast retract -broadcaster {URI} -id {UID}
+	//	broadcast schedule -broadcaster {URI} -send-at {TIME} -body {BODY}
+
+	if len(os.Args) > 1 {
+
+		switch os.Args[1] {
+		case "retract":
+
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+
+			err := retract.Run(ctx, logger)
+
+			if err != nil {
+				logger.Fatalf("Failed to run retract application, %v", err)
+			}
+
+			return
+
+		case "schedule":
+
+			os.Args = append(os.Args[:1], os.Args[2:]...)
+
+			err := schedule.Run(ctx, logger)
+
+			if err != nil {
+				logger.Fatalf("Failed to run schedule application, %v", err)
+			}
+
+			return
+		}
+	}
+
 	err := broadcast.Run(ctx, logger)
 
 	if err != nil {
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"github.com/aaronland/go-broadcaster-twitter/app/authorize"
+	"log"
+)
+
+func main() {
+
+	ctx := context.Background()
+	logger := log.Default()
+
+	err := authorize.Run(ctx, logger)
+
+	if err != nil {
+		logger.Fatalf("Failed to run authorize application, %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package twitter
+
+// https://developer.twitter.com/en/docs/counting-characters
+// https://github.com/twitter/twitter-text/blob/master/config/v3.json
+
+import (
+	"fmt"
+	"golang.org/x/text/unicode/norm"
+	"regexp"
+	"strings"
+)
+
+// TRANSFORMED_URL_LENGTH is the number of characters every URL is counted as, regardless of its
+// actual length, because Twitter wraps all URLs using its t.co link shortener.
+const TRANSFORMED_URL_LENGTH int = 23
+
+// weight_scale, default_weight and weighted_ranges mirror the Twitter "v3" character counting configuration.
+const weight_scale int = 100
+
+const default_weight int = 200
+
+const emoji_weight int = 200
+
+type weightedRange struct {
+	start  rune
+	end    rune
+	weight int
+}
+
+var weighted_ranges = []weightedRange{
+	{start: 0, end: 4351, weight: 100},
+	{start: 8192, end: 8205, weight: 100},
+	{start: 8208, end: 8223, weight: 100},
+	{start: 8242, end: 8247, weight: 100},
+}
+
+// invalid_characters are characters which Twitter does not allow in a status.
+var invalid_characters = []rune{
+	'\uFFFE',
+	'\uFEFF',
+	'\uFFFF',
+}
+
+// url_tlds is the list of top-level domains recognized for URLs without a scheme. It is a (deliberately)
+// incomplete subset of the list used by Twitter but covers the overwhelming majority of URLs that people
+// write without a leading "http(s)://".
+var url_tlds = []string{
+	"com", "net", "org", "edu", "gov", "mil", "int", "info", "biz", "name", "pro", "aero", "coop", "museum",
+	"io", "co", "me", "ly", "tv", "fm", "ai", "app", "dev", "art", "gg", "xyz", "online", "site", "blog", "news",
+	"us", "uk", "ca", "de", "fr", "es", "it", "nl", "be", "ch", "at", "se", "no", "dk", "fi", "ie", "pt", "pl",
+	"jp", "cn", "kr", "tw", "hk", "sg", "in", "au", "nz", "br", "mx", "ar", "za", "eu", "ru",
+}
+
+var re_url = regexp.MustCompile(`(?i)(?:https?://[^\s/$.?#][^\s]*|\b(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+(?:` + strings.Join(url_tlds, "|") + `)\b(?::\d+)?(?:/[^\s]*)?)`)
+
+// StatusErrorReason is the reason a status failed validation.
+type StatusErrorReason string
+
+const (
+	// StatusTooLong indicates that a status exceeds `MAX_STATUS_LENGTH` weighted characters.
+	StatusTooLong StatusErrorReason = "too long"
+	// StatusEmpty indicates that a status is empty.
+	StatusEmpty StatusErrorReason = "empty"
+	// StatusInvalidCharacters indicates that a status contains characters which Twitter does not allow.
+	StatusInvalidCharacters StatusErrorReason = "invalid characters"
+)
+
+// StatusError is the error returned by `ValidateStatus` for a status that Twitter would reject.
+type StatusError struct {
+	// Reason is the reason the status failed validation.
+	Reason StatusErrorReason
+	// WeightedLength is the weighted length of the status.
+	WeightedLength int
+	// MaxLength is the maximum weighted length of a status.
+	MaxLength int
+}
+
+// Error returns a string representation of 'e'.
+func (e *StatusError) Error() string {
+
+	switch e.Reason {
+	case StatusTooLong:
+		return fmt.Sprintf("Status is too long, %d characters exceeds the maximum of %d", e.WeightedLength, e.MaxLength)
+	default:
+		return fmt.Sprintf("Status is invalid, %s", e.Reason)
+	}
+}
+
+// Is returns a boolean value indicating whether 'target' is `ErrStatusTooLong` and 'e' is a `StatusTooLong` error.
+func (e *StatusError) Is(target error) bool {
+	return target == ErrStatusTooLong && e.Reason == StatusTooLong
+}
+
+// StatusInfo describes the length of a status as counted by Twitter.
+type StatusInfo struct {
+	// WeightedLength is the weighted length of the status.
+	WeightedLength int
+	// MaxLength is the maximum weighted length of a status.
+	MaxLength int
+	// Remaining is the number of weighted characters remaining before a status reaches `MaxLength`. It may be negative.
+	Remaining int
+	// Permillage is the weighted length of the status expressed in thousandths of `MaxLength`.
+	Permillage int
+	// Valid is a boolean flag indicating whether the status is valid.
+	Valid bool
+}
+
+// ParseStatus returns a `StatusInfo` instance describing 'text' using Twitter's character counting rules: text
+// is NFC-normalized, every URL counts as `TRANSFORMED_URL_LENGTH` characters, emoji (including multi-codepoint
+// sequences) count as two characters, characters in the Latin, general punctuation and similar ranges count as
+// one character and everything else (for example CJK characters) counts as two characters.
+func ParseStatus(text string) *StatusInfo {
+
+	l := WeightedLength(text)
+
+	info := &StatusInfo{
+		WeightedLength: l,
+		MaxLength:      MAX_STATUS_LENGTH,
+		Remaining:      MAX_STATUS_LENGTH - l,
+		Permillage:     (l * 1000) / MAX_STATUS_LENGTH,
+		Valid:          ValidateStatus(text) == nil,
+	}
+
+	return info
+}
+
+// ValidateStatus returns a `StatusError` if 'text' is empty, contains invalid characters or is longer than
+// `MAX_STATUS_LENGTH` weighted characters (as measured by `WeightedLength`).
+func ValidateStatus(text string) error {
+
+	if strings.TrimSpace(text) == "" {
+		return &StatusError{Reason: StatusEmpty, MaxLength: MAX_STATUS_LENGTH}
+	}
+
+	return validateStatusContent(text)
+}
+
+// validateStatusContent validates the characters and length of 'text' but does not check whether it is empty
+// since a tweet with media may have an empty status.
+func validateStatusContent(text string) error {
+
+	l := WeightedLength(text)
+
+	for _, r := range invalid_characters {
+
+		if strings.ContainsRune(text, r) {
+			return &StatusError{Reason: StatusInvalidCharacters, WeightedLength: l, MaxLength: MAX_STATUS_LENGTH}
+		}
+	}
+
+	if l > MAX_STATUS_LENGTH {
+		return &StatusError{Reason: StatusTooLong, WeightedLength: l, MaxLength: MAX_STATUS_LENGTH}
+	}
+
+	return nil
+}
+
+// WeightedLength returns the length of 'text' as counted by Twitter. See `ParseStatus` for details.
+func WeightedLength(text string) int {
+
+	text = norm.NFC.String(text)
+
+	weight := 0
+	offset := 0
+
+	for _, loc := range re_url.FindAllStringIndex(text, -1) {
+
+		start, end := loc[0], trimURL(text, loc[0], loc[1])
+
+		weight += textWeight(text[offset:start])
+		weight += TRANSFORMED_URL_LENGTH * weight_scale
+
+		// Any trailing punctuation trimmed from the URL is counted as text
+
+		weight += textWeight(text[end:loc[1]])
+		offset = loc[1]
+	}
+
+	weight += textWeight(text[offset:])
+
+	return weight / weight_scale
+}
+
+// trimURL returns the end offset of the URL 'text[start:end]' excluding any trailing punctuation.
+func trimURL(text string, start int, end int) int {
+
+	for end > start && strings.ContainsRune(`.,;:!?'")]}`, rune(text[end-1])) {
+		end -= 1
+	}
+
+	return end
+}
+
+// textWeight returns the (scaled) weight of 'text' which is assumed not to contain any URLs.
+func textWeight(text string) int {
+
+	runes := []rune(text)
+	weight := 0
+
+	for i := 0; i < len(runes); {
+
+		n := emojiSequenceLength(runes, i)
+
+		if n > 0 {
+			weight += emoji_weight
+			i += n
+			continue
+		}
+
+		weight += runeWeight(runes[i])
+		i += 1
+	}
+
+	return weight
+}
+
+func runeWeight(r rune) int {
+
+	for _, wr := range weighted_ranges {
+
+		if r >= wr.start && r <= wr.end {
+			return wr.weight
+		}
+	}
+
+	return default_weight
+}
+
+// emojiSequenceLength returns the number of runes in the emoji sequence starting at 'runes[i]' or 0 if
+// 'runes[i]' is not the start of an emoji. Sequences include flags (pairs of regional indicators), keycaps,
+// skin tone modifiers, variation selectors, tag sequences and zero-width-joiner (ZWJ) sequences.
+func emojiSequenceLength(runes []rune, i int) int {
+
+	r := runes[i]
+	count := len(runes)
+
+	// Flags
+
+	if isRegionalIndicator(r) {
+
+		if i+1 < count && isRegionalIndicator(runes[i+1]) {
+			return 2
+		}
+
+		return 1
+	}
+
+	// Keycaps, for example "1" followed by U+FE0F and U+20E3
+
+	if (r >= '0' && r <= '9') || r == '#' || r == '*' {
+
+		if i+2 < count && runes[i+1] == '\uFE0F' && runes[i+2] == '\u20E3' {
+			return 3
+		}
+
+		if i+1 < count && runes[i+1] == '\u20E3' {
+			return 2
+		}
+
+		return 0
+	}
+
+	// Symbols, like "©", which are only emoji when followed by a variation selector
+
+	if isTextDefaultEmoji(r) {
+
+		if i+1 < count && runes[i+1] == '\uFE0F' {
+			return 2
+		}
+
+		return 0
+	}
+
+	if !isEmoji(r) {
+		return 0
+	}
+
+	j := i + 1
+
+	for j < count {
+
+		next := runes[j]
+
+		switch {
+		case next == '\uFE0F' || next == '\uFE0E':
+			j += 1
+		case next >= 0x1F3FB && next <= 0x1F3FF:
+			// skin tone modifiers
+			j += 1
+		case next >= 0xE0020 && next <= 0xE007F:
+			// tag sequences (subdivision flags)
+			j += 1
+		case next == '\u200D' && j+1 < count && isEmoji(runes[j+1]):
+			j += 2
+		default:
+			return j - i
+		}
+	}
+
+	return j - i
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isEmoji returns true if 'r' falls within one of the Unicode blocks that (primarily) contain emoji.
+func isEmoji(r rune) bool {
+
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2600 && r <= 0x27BF:
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTextDefaultEmoji returns true if 'r' is a symbol that is rendered as text unless it is followed by
+// an emoji variation selector.
+func isTextDefaultEmoji(r rune) bool {
+
+	switch {
+	case r == 0x00A9 || r == 0x00AE || r == 0x203C || r == 0x2049 || r == 0x2122 || r == 0x2139:
+		return true
+	case r >= 0x2190 && r <= 0x21FF:
+		return true
+	case r >= 0x2300 && r <= 0x23FF:
+		return true
+	default:
+		return false
+	}
+}
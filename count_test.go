@@ -0,0 +1,108 @@
+package twitter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWeightedLength(t *testing.T) {
+
+	tests := map[string]int{
+		"hello": 5,
+		"https://example.com/a/very/long/path/that/is/longer/than/twenty-three/characters": TRANSFORMED_URL_LENGTH,
+		"see example.com.": 4 + TRANSFORMED_URL_LENGTH + 1,
+		"日本語":              6,
+		"café":             4,
+		"cafe\u0301":       4,
+		"👍":                2,
+		"👍🏽":               2,
+		"\U0001F468\u200D\U0001F469\u200D\U0001F467": 2,
+		"🇺🇸":                 2,
+		"1\uFE0F\u20E3":      2,
+		"© 2023":             6,
+		"\u00A9\uFE0F":       2,
+		"—hello—":            7,
+		"hello #world @sfo":  17,
+		"a https://b.co/c d": 2 + TRANSFORMED_URL_LENGTH + 2,
+	}
+
+	for text, expected := range tests {
+
+		l := WeightedLength(text)
+
+		if l != expected {
+			t.Fatalf("Expected weighted length of %q to be %d, got %d", text, expected, l)
+		}
+	}
+}
+
+func TestValidateStatus(t *testing.T) {
+
+	var status_err *StatusError
+
+	err := ValidateStatus("  ")
+
+	if !errors.As(err, &status_err) || status_err.Reason != StatusEmpty {
+		t.Fatalf("Expected empty status error, got %v", err)
+	}
+
+	err = ValidateStatus("hello\uFFFEworld")
+
+	if !errors.As(err, &status_err) || status_err.Reason != StatusInvalidCharacters {
+		t.Fatalf("Expected invalid characters error, got %v", err)
+	}
+
+	err = ValidateStatus(strings.Repeat("a", MAX_STATUS_LENGTH+1))
+
+	if !errors.Is(err, ErrStatusTooLong) {
+		t.Fatalf("Expected ErrStatusTooLong, got %v", err)
+	}
+
+	if !errors.As(err, &status_err) || status_err.WeightedLength != MAX_STATUS_LENGTH+1 {
+		t.Fatalf("Expected weighted length to be reported, got %v", err)
+	}
+
+	// CJK characters count double
+
+	err = ValidateStatus(strings.Repeat("語", MAX_STATUS_LENGTH/2+1))
+
+	if !errors.Is(err, ErrStatusTooLong) {
+		t.Fatalf("Expected ErrStatusTooLong for CJK text, got %v", err)
+	}
+
+	err = ValidateStatus(strings.Repeat("a", MAX_STATUS_LENGTH))
+
+	if err != nil {
+		t.Fatalf("Expected status of maximum length to be valid, %v", err)
+	}
+
+	// A status with media may be empty
+
+	err = validateStatusContent("")
+
+	if err != nil {
+		t.Fatalf("Expected empty status content to be valid, %v", err)
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+
+	info := ParseStatus(strings.Repeat("a", MAX_STATUS_LENGTH))
+
+	if !info.Valid || info.Remaining != 0 || info.Permillage != 1000 {
+		t.Fatalf("Unexpected status info %+v", info)
+	}
+
+	info = ParseStatus("hello https://example.com")
+
+	if !info.Valid || info.WeightedLength != 6+TRANSFORMED_URL_LENGTH || info.Remaining != MAX_STATUS_LENGTH-(6+TRANSFORMED_URL_LENGTH) {
+		t.Fatalf("Unexpected status info %+v", info)
+	}
+
+	info = ParseStatus(strings.Repeat("a", MAX_STATUS_LENGTH+10))
+
+	if info.Valid || info.Remaining != -10 {
+		t.Fatalf("Unexpected status info %+v", info)
+	}
+}
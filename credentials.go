@@ -0,0 +1,170 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/sfomuseum/runtimevar"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ENV_CONSUMER_KEY is the environment variable used for the OAuth1 consumer key if neither `?credentials=` nor
+// `?consumer-key=` are present.
+const ENV_CONSUMER_KEY string = "TWITTER_CONSUMER_KEY"
+
+// ENV_CONSUMER_SECRET is the environment variable used for the OAuth1 consumer secret if neither `?credentials=` nor
+// `?consumer-secret=` are present.
+const ENV_CONSUMER_SECRET string = "TWITTER_CONSUMER_SECRET"
+
+// ENV_ACCESS_TOKEN is the environment variable used for the OAuth1 access token if neither `?credentials=` nor
+// `?access-token=` are present.
+const ENV_ACCESS_TOKEN string = "TWITTER_ACCESS_TOKEN"
+
+// ENV_ACCESS_SECRET is the environment variable used for the OAuth1 access token secret if neither `?credentials=` nor
+// `?access-secret=` are present.
+const ENV_ACCESS_SECRET string = "TWITTER_ACCESS_SECRET"
+
+// credentialsField maps a property of an `oauth.OAuth1Credentials` document to the URI parameter and environment
+// variable it can be read from.
+type credentialsField struct {
+	name  string
+	param string
+	env   string
+}
+
+// credentialsFields are the properties of an `oauth.OAuth1Credentials` document which can be read from individual
+// URI parameters or environment variables.
+var credentialsFields = []*credentialsField{
+	{name: "consumer_key", param: "consumer-key", env: ENV_CONSUMER_KEY},
+	{name: "consumer_secret", param: "consumer-secret", env: ENV_CONSUMER_SECRET},
+	{name: "access_token", param: "access-token", env: ENV_ACCESS_TOKEN},
+	{name: "access_token_secret", param: "access-secret", env: ENV_ACCESS_SECRET},
+}
+
+// credentialsLoader reads a JSON-encoded `oauth.OAuth1Credentials` or `oauth.OAuth2Credentials` document.
+type credentialsLoader func(context.Context) (string, error)
+
+// newCredentialsLoader returns a `credentialsLoader` for the credentials defined by 'query'. Credentials are read from
+// the `?credentials=` parameter, if present, or otherwise assembled from the `?consumer-key=`, `?consumer-secret=`,
+// `?access-token=` and `?access-secret=` parameters falling back to the `ENV_CONSUMER_KEY`, `ENV_CONSUMER_SECRET`,
+// `ENV_ACCESS_TOKEN` and `ENV_ACCESS_SECRET` environment variables for any which are absent. It is an error to combine
+// `?credentials=` with the individual parameters or for any of the individual values to have no source.
+func newCredentialsLoader(query url.Values) (credentialsLoader, error) {
+
+	creds_uri := query.Get("credentials")
+
+	field_params := make([]string, 0)
+
+	for _, f := range credentialsFields {
+
+		if query.Has(f.param) {
+			field_params = append(field_params, fmt.Sprintf("?%s=", f.param))
+		}
+	}
+
+	if creds_uri != "" {
+
+		if len(field_params) > 0 {
+			return nil, fmt.Errorf("?credentials= parameter conflicts with %s, use one or the other", strings.Join(field_params, ", "))
+		}
+
+		loader := func(ctx context.Context) (string, error) {
+			return readRuntimeVar(ctx, creds_uri)
+		}
+
+		return loader, nil
+	}
+
+	missing := make([]string, 0)
+	from_env := 0
+
+	for _, f := range credentialsFields {
+
+		switch {
+		case query.Get(f.param) != "":
+			// pass
+		case query.Has(f.param):
+			return nil, fmt.Errorf("Invalid ?%s= parameter, empty URI", f.param)
+		case os.Getenv(f.env) != "":
+			from_env += 1
+		default:
+			missing = append(missing, fmt.Sprintf("%s (set ?%s= or %s)", f.name, f.param, f.env))
+		}
+	}
+
+	if len(field_params) == 0 && from_env == 0 {
+		return nil, fmt.Errorf("Missing ?credentials= parameter (or the ?consumer-key=, ?consumer-secret=, ?access-token= and ?access-secret= parameters or their environment variables)")
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("Incomplete credentials, missing %s", strings.Join(missing, ", "))
+	}
+
+	loader := func(ctx context.Context) (string, error) {
+		return readCredentialsFields(ctx, query)
+	}
+
+	return loader, nil
+}
+
+// readCredentialsFields reads the individual credentials values defined by 'query', or the environment, and returns
+// them as a JSON-encoded `oauth.OAuth1Credentials` document. Leading and trailing whitespace, for example the
+// trailing newline in a file, is removed from each value.
+func readCredentialsFields(ctx context.Context, query url.Values) (string, error) {
+
+	values := make(map[string]string)
+
+	for _, f := range credentialsFields {
+
+		var v string
+
+		if query.Has(f.param) {
+
+			str_v, err := readRuntimeVar(ctx, query.Get(f.param))
+
+			if err != nil {
+				return "", fmt.Errorf("Failed to read ?%s= parameter, %w", f.param, err)
+			}
+
+			v = str_v
+
+		} else {
+
+			v = os.Getenv(f.env)
+
+			if v == "" {
+				return "", fmt.Errorf("Missing %s environment variable", f.env)
+			}
+		}
+
+		values[f.name] = strings.TrimSpace(v)
+	}
+
+	creds := &oauth.OAuth1Credentials{
+		ConsumerKey:    values["consumer_key"],
+		ConsumerSecret: values["consumer_secret"],
+		AccessToken:    values["access_token"],
+		AccessSecret:   values["access_token_secret"],
+	}
+
+	enc_creds, err := json.Marshal(creds)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to marshal credentials, %w", err)
+	}
+
+	return string(enc_creds), nil
+}
+
+// readRuntimeVar returns the value of the `gocloud.dev/runtimevar` URI 'uri'.
+func readRuntimeVar(ctx context.Context, uri string) (string, error) {
+
+	rt_ctx, rt_cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer rt_cancel()
+
+	return runtimevar.StringVar(rt_ctx, uri)
+}
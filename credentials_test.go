@@ -0,0 +1,122 @@
+package twitter
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+)
+
+func TestCredentialsFromParameters(t *testing.T) {
+
+	ctx := context.Background()
+
+	for _, f := range credentialsFields {
+		t.Setenv(f.env, "")
+	}
+
+	path := filepath.Join(t.TempDir(), "secret.txt")
+
+	err := os.WriteFile(path, []byte("access-secret\n"), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write secret, %v", err)
+	}
+
+	q := url.Values{}
+	q.Set("consumer-key", "constant://?val=consumer-key")
+	q.Set("consumer-secret", "constant://?val=consumer-secret")
+	q.Set("access-token", "constant://?val=access-token")
+	q.Set("access-secret", "file://"+path+"?decoder=string")
+
+	load, err := newCredentialsLoader(q)
+
+	if err != nil {
+		t.Fatalf("Failed to create credentials loader, %v", err)
+	}
+
+	str_creds, err := load(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to load credentials, %v", err)
+	}
+
+	creds, err := oauth.NewOAuth1CredentialsFromString(ctx, str_creds)
+
+	if err != nil {
+		t.Fatalf("Failed to parse credentials, %v", err)
+	}
+
+	if creds.ConsumerKey != "consumer-key" || creds.ConsumerSecret != "consumer-secret" || creds.AccessToken != "access-token" || creds.AccessSecret != "access-secret" {
+		t.Fatalf("Unexpected credentials %v", creds)
+	}
+}
+
+func TestCredentialsFromEnvironment(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Setenv(ENV_CONSUMER_KEY, "consumer-key")
+	t.Setenv(ENV_CONSUMER_SECRET, "consumer-secret")
+	t.Setenv(ENV_ACCESS_TOKEN, "access-token")
+	t.Setenv(ENV_ACCESS_SECRET, "")
+
+	// Parameters take precedence over environment variables
+
+	q := url.Values{}
+	q.Set("access-secret", "constant://?val=access-secret")
+
+	load, err := newCredentialsLoader(q)
+
+	if err != nil {
+		t.Fatalf("Failed to create credentials loader, %v", err)
+	}
+
+	str_creds, err := load(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to load credentials, %v", err)
+	}
+
+	creds, err := oauth.NewOAuth1CredentialsFromString(ctx, str_creds)
+
+	if err != nil {
+		t.Fatalf("Failed to parse credentials, %v", err)
+	}
+
+	if creds.ConsumerKey != "consumer-key" || creds.AccessSecret != "access-secret" {
+		t.Fatalf("Unexpected credentials %v", creds)
+	}
+
+	_, err = newCredentialsLoader(url.Values{})
+
+	if err == nil {
+		t.Fatalf("Expected incomplete credentials to fail")
+	}
+}
+
+func TestCredentialsLoaderInvalid(t *testing.T) {
+
+	for _, f := range credentialsFields {
+		t.Setenv(f.env, "")
+	}
+
+	tests := []url.Values{
+		{},
+		{"credentials": {"constant://?val={}"}, "consumer-key": {"constant://?val=a"}},
+		{"consumer-key": {""}, "consumer-secret": {"constant://?val=b"}, "access-token": {"constant://?val=c"}, "access-secret": {"constant://?val=d"}},
+		{"consumer-key": {"constant://?val=a"}},
+	}
+
+	for _, q := range tests {
+
+		_, err := newCredentialsLoader(q)
+
+		if err == nil {
+			t.Fatalf("Expected %s to be invalid", q.Encode())
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package twitter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/aaronland/go-broadcaster-twitter/dedupe"
+	"hash"
+	"image"
+	"time"
+)
+
+// DEFAULT_DEDUPE_TTL is the default amount of time a message recorded in the dedupe store is considered to have
+// been published. Once a record is older than this the same message can be published again.
+const DEFAULT_DEDUPE_TTL time.Duration = 12 * time.Hour
+
+// dedupeKey returns a key for use with a `dedupe.Store` derived from the SHA-256 hash of 'status' and 'images'.
+// `EncodedImage` instances are hashed using the bytes that are uploaded, so that every frame of an animated GIF is
+// included, and other images using their pixel data.
+func dedupeKey(status string, images []image.Image) string {
+
+	h := sha256.New()
+	writeHashString(h, status)
+
+	for _, im := range images {
+
+		im, _ = imageWithAltText(im)
+
+		if enc_im, ok := im.(*EncodedImage); ok {
+			writeHashBytes(h, enc_im.Body)
+			continue
+		}
+
+		writeHashImage(h, im)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeHashString writes the length of 's' followed by 's' to 'h' so that adjacent values can not be confused.
+func writeHashString(h hash.Hash, s string) {
+	writeHashBytes(h, []byte(s))
+}
+
+// writeHashBytes writes the length of 'b' followed by 'b' to 'h' so that adjacent values can not be confused.
+func writeHashBytes(h hash.Hash, b []byte) {
+	binary.Write(h, binary.BigEndian, int64(len(b)))
+	h.Write(b)
+}
+
+// writeHashImage writes the bounds and pixel data of 'im' to 'h'. The pixel buffers of common image types are
+// written as-is; other images are written pixel by pixel.
+func writeHashImage(h hash.Hash, im image.Image) {
+
+	bounds := im.Bounds()
+
+	binary.Write(h, binary.BigEndian, []int64{
+		int64(bounds.Min.X), int64(bounds.Min.Y), int64(bounds.Max.X), int64(bounds.Max.Y),
+	})
+
+	switch i := im.(type) {
+	case *image.RGBA:
+		h.Write(i.Pix)
+	case *image.NRGBA:
+		h.Write(i.Pix)
+	case *image.Gray:
+		h.Write(i.Pix)
+	case *image.YCbCr:
+		h.Write(i.Y)
+		h.Write(i.Cb)
+		h.Write(i.Cr)
+	case *image.Paletted:
+
+		h.Write(i.Pix)
+
+		for _, c := range i.Palette {
+			r, g, b, a := c.RGBA()
+			binary.Write(h, binary.BigEndian, []uint32{r, g, b, a})
+		}
+
+	default:
+
+		buf := make([]byte, 0, bounds.Dx()*8)
+
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+
+			buf = buf[:0]
+
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r, g, b, a := im.At(x, y).RGBA()
+				buf = append(buf, byte(r>>8), byte(r), byte(g>>8), byte(g), byte(b>>8), byte(b), byte(a>>8), byte(a))
+			}
+
+			h.Write(buf)
+		}
+	}
+}
+
+// publishedRecord returns the record in the dedupe store for 'dedupe_key' and a boolean value indicating whether it
+// exists. Records which were created more than `?dedupe-ttl=` ago are ignored so that recurring messages can be
+// published again.
+func (b *TwitterBroadcaster) publishedRecord(ctx context.Context, dedupe_key string) (*dedupe.Record, bool, error) {
+
+	r, exists, err := b.dedupe.Get(ctx, dedupe_key)
+
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	if b.dedupe_ttl > 0 && time.Since(r.Created) > b.dedupe_ttl {
+		return nil, false, nil
+	}
+
+	return r, true, nil
+}
@@ -0,0 +1,134 @@
+// Package dedupe provides stores for recording the tweets published for a message so that the same
+// message is not published more than once.
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-roster"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record describes a message that has been published.
+type Record struct {
+	// Key is the unique key (typically a hash) identifying the message.
+	Key string `json:"key"`
+	// TweetIds is the list of IDs of the tweets published for the message, in order.
+	TweetIds []string `json:"tweet_ids"`
+	// Created is the time the record was created.
+	Created time.Time `json:"created"`
+}
+
+// Store is an interface for recording, and looking up, the tweets published for a message.
+type Store interface {
+	// Get returns the `Record` associated with a key and a boolean value indicating whether it exists.
+	Get(context.Context, string) (*Record, bool, error)
+	// GetByTweetId returns the `Record` whose tweet IDs contain a tweet ID and a boolean value indicating whether it exists.
+	GetByTweetId(context.Context, string) (*Record, bool, error)
+	// Put stores a `Record` (replacing any existing record with the same key).
+	Put(context.Context, *Record) error
+	// Delete removes the `Record` associated with a key. It is not an error if the record does not exist.
+	Delete(context.Context, string) error
+	// Close releases any resources used by the store.
+	Close(context.Context) error
+}
+
+var store_roster roster.Roster
+
+// StoreInitializationFunc is a function defined by individual store implementations and used to create
+// an instance of that store.
+type StoreInitializationFunc func(ctx context.Context, uri string) (Store, error)
+
+// RegisterStore registers 'scheme' as a key pointing to 'init_func' in an internal lookup table
+// used to create new `Store` instances by the `NewStore` method.
+func RegisterStore(ctx context.Context, scheme string, init_func StoreInitializationFunc) error {
+
+	err := ensureStoreRoster()
+
+	if err != nil {
+		return err
+	}
+
+	return store_roster.Register(ctx, scheme, init_func)
+}
+
+func ensureStoreRoster() error {
+
+	if store_roster == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return err
+		}
+
+		store_roster = r
+	}
+
+	return nil
+}
+
+// NewStore returns a new `Store` instance configured by 'uri'. The value of 'uri' is parsed
+// as a `url.URL` and its scheme is used as the key for a corresponding `StoreInitializationFunc`
+// function used to instantiate the new `Store`. It is assumed that the scheme (and initialization
+// function) have been registered by the `RegisterStore` method.
+func NewStore(ctx context.Context, uri string) (Store, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = ensureStoreRoster()
+
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := store_roster.Driver(ctx, u.Scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Unsupported dedupe store '%s', %w", u.Scheme, err)
+	}
+
+	init_func := i.(StoreInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// Schemes returns the list of schemes that have been registered.
+func Schemes() []string {
+
+	ctx := context.Background()
+	schemes := []string{}
+
+	err := ensureStoreRoster()
+
+	if err != nil {
+		return schemes
+	}
+
+	for _, dr := range store_roster.Drivers(ctx) {
+		scheme := fmt.Sprintf("%s://", strings.ToLower(dr))
+		schemes = append(schemes, scheme)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+// HasTweetId returns a boolean value indicating whether 'tweet_id' is one of the tweets published for 'r'.
+func (r *Record) HasTweetId(tweet_id string) bool {
+
+	for _, id := range r.TweetIds {
+
+		if id == tweet_id {
+			return true
+		}
+	}
+
+	return false
+}
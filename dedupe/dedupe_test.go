@@ -0,0 +1,181 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, s Store) {
+
+	ctx := context.Background()
+
+	_, exists, err := s.Get(ctx, "a")
+
+	if err != nil {
+		t.Fatalf("Failed to get record, %v", err)
+	}
+
+	if exists {
+		t.Fatalf("Expected record not to exist")
+	}
+
+	r := &Record{
+		Key:      "a",
+		TweetIds: []string{"1", "2"},
+		Created:  time.Now(),
+	}
+
+	err = s.Put(ctx, r)
+
+	if err != nil {
+		t.Fatalf("Failed to put record, %v", err)
+	}
+
+	r2, exists, err := s.Get(ctx, "a")
+
+	if err != nil {
+		t.Fatalf("Failed to get record, %v", err)
+	}
+
+	if !exists || len(r2.TweetIds) != 2 || r2.TweetIds[1] != "2" {
+		t.Fatalf("Unexpected record %v", r2)
+	}
+
+	r3, exists, err := s.GetByTweetId(ctx, "2")
+
+	if err != nil {
+		t.Fatalf("Failed to get record by tweet ID, %v", err)
+	}
+
+	if !exists || r3.Key != "a" {
+		t.Fatalf("Expected to find record 'a' by tweet ID")
+	}
+
+	_, exists, err = s.GetByTweetId(ctx, "3")
+
+	if err != nil {
+		t.Fatalf("Failed to get record by tweet ID, %v", err)
+	}
+
+	if exists {
+		t.Fatalf("Expected no record for tweet ID 3")
+	}
+
+	err = s.Delete(ctx, "a")
+
+	if err != nil {
+		t.Fatalf("Failed to delete record, %v", err)
+	}
+
+	_, exists, err = s.Get(ctx, "a")
+
+	if err != nil {
+		t.Fatalf("Failed to get record, %v", err)
+	}
+
+	if exists {
+		t.Fatalf("Expected record to be deleted")
+	}
+
+	err = s.Delete(ctx, "a")
+
+	if err != nil {
+		t.Fatalf("Expected deleting a missing record to succeed, %v", err)
+	}
+
+	err = s.Close(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to close store, %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := NewStore(ctx, "mem://")
+
+	if err != nil {
+		t.Fatalf("Failed to create store, %v", err)
+	}
+
+	testStore(t, s)
+}
+
+func TestFileStore(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "dedupe.json")
+	uri := fmt.Sprintf("file://%s", path)
+
+	s, err := NewStore(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create store, %v", err)
+	}
+
+	testStore(t, s)
+
+	// Records are persisted between instances
+
+	s, _ = NewStore(ctx, uri)
+
+	r := &Record{
+		Key:      "b",
+		TweetIds: []string{"4"},
+		Created:  time.Now(),
+	}
+
+	err = s.Put(ctx, r)
+
+	if err != nil {
+		t.Fatalf("Failed to put record, %v", err)
+	}
+
+	s2, err := NewStore(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create store, %v", err)
+	}
+
+	_, exists, err := s2.Get(ctx, "b")
+
+	if err != nil || !exists {
+		t.Fatalf("Expected record to be persisted, %v", err)
+	}
+}
+
+func TestSQLiteStoreMissingDriver(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "dedupe.db")
+
+	_, err := NewStore(context.Background(), fmt.Sprintf("sqlite://%s?driver=missing", path))
+
+	if err == nil {
+		t.Fatalf("Expected unregistered database/sql driver to fail")
+	}
+
+	_, err = NewStore(context.Background(), "sqlite://")
+
+	if err == nil {
+		t.Fatalf("Expected missing path to fail")
+	}
+}
+
+func TestSchemes(t *testing.T) {
+
+	schemes := strings.Join(Schemes(), " ")
+
+	for _, scheme := range []string{"file://", "mem://", "sqlite://"} {
+
+		if !strings.Contains(schemes, scheme) {
+			t.Fatalf("Expected %s to be registered, got %s", scheme, schemes)
+		}
+	}
+}
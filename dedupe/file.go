@@ -0,0 +1,164 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter/internal/jsonfile"
+	"net/url"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore implements the `Store` interface for records stored in a JSON-encoded file on the local filesystem.
+// The file is re-read before every operation so it may be shared by more than one process, but writes are not
+// coordinated between processes.
+type FileStore struct {
+	Store
+	path string
+	mu   *sync.Mutex
+}
+
+func init() {
+	ctx := context.Background()
+	RegisterStore(ctx, "file", NewFileStore)
+}
+
+// NewFileStore returns a new `FileStore` instance configured by 'uri' which is expected to take the form of:
+//
+//	file:///path/to/dedupe.json
+//
+// The file is created, the first time a record is stored, if it does not already exist.
+func NewFileStore(ctx context.Context, uri string) (Store, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	path := u.Path
+
+	if path == "" {
+		return nil, fmt.Errorf("Missing path")
+	}
+
+	abs_path, err := filepath.Abs(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive absolute path for %s, %w", path, err)
+	}
+
+	s := &FileStore{
+		path: abs_path,
+		mu:   new(sync.Mutex),
+	}
+
+	_, err = s.read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Get returns the `Record` associated with 'key' and a boolean value indicating whether it exists.
+func (s *FileStore) Get(ctx context.Context, key string) (*Record, bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	r, ok := records[key]
+	return r, ok, nil
+}
+
+// GetByTweetId returns the `Record` whose tweet IDs contain 'tweet_id' and a boolean value indicating whether it exists.
+func (s *FileStore) GetByTweetId(ctx context.Context, tweet_id string) (*Record, bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, r := range records {
+
+		if r.HasTweetId(tweet_id) {
+			return r, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// Put stores 'r' replacing any existing record with the same key.
+func (s *FileStore) Put(ctx context.Context, r *Record) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+
+	if err != nil {
+		return err
+	}
+
+	records[r.Key] = r
+
+	return s.write(records)
+}
+
+// Delete removes the `Record` associated with 'key'.
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+
+	if err != nil {
+		return err
+	}
+
+	_, exists := records[key]
+
+	if !exists {
+		return nil
+	}
+
+	delete(records, key)
+
+	return s.write(records)
+}
+
+// Close is a no-op.
+func (s *FileStore) Close(ctx context.Context) error {
+	return nil
+}
+
+func (s *FileStore) read() (map[string]*Record, error) {
+
+	records := make(map[string]*Record)
+
+	err := jsonfile.Read(s.path, &records)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// write atomically replaces the contents of 's.path' with 'records'.
+func (s *FileStore) write(records map[string]*Record) error {
+	return jsonfile.Write(s.path, records)
+}
@@ -0,0 +1,82 @@
+package dedupe
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore implements the `Store` interface for records held in memory.
+type MemoryStore struct {
+	Store
+	records map[string]*Record
+	mu      *sync.RWMutex
+}
+
+func init() {
+	ctx := context.Background()
+	RegisterStore(ctx, "mem", NewMemoryStore)
+}
+
+// NewMemoryStore returns a new `MemoryStore` instance configured by 'uri' which is expected to take the form of:
+//
+//	mem://
+func NewMemoryStore(ctx context.Context, uri string) (Store, error) {
+
+	s := &MemoryStore{
+		records: make(map[string]*Record),
+		mu:      new(sync.RWMutex),
+	}
+
+	return s, nil
+}
+
+// Get returns the `Record` associated with 'key' and a boolean value indicating whether it exists.
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Record, bool, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.records[key]
+	return r, ok, nil
+}
+
+// GetByTweetId returns the `Record` whose tweet IDs contain 'tweet_id' and a boolean value indicating whether it exists.
+func (s *MemoryStore) GetByTweetId(ctx context.Context, tweet_id string) (*Record, bool, error) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.records {
+
+		if r.HasTweetId(tweet_id) {
+			return r, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// Put stores 'r' replacing any existing record with the same key.
+func (s *MemoryStore) Put(ctx context.Context, r *Record) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[r.Key] = r
+	return nil
+}
+
+// Delete removes the `Record` associated with 'key'.
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// Close is a no-op.
+func (s *MemoryStore) Close(ctx context.Context) error {
+	return nil
+}
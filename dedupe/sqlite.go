@@ -0,0 +1,221 @@
+package dedupe
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// DEFAULT_SQLITE_DRIVER is the default name of the `database/sql` driver used by `SQLiteStore`.
+const DEFAULT_SQLITE_DRIVER string = "sqlite3"
+
+// SQLiteStore implements the `Store` interface for records stored in a SQLite database using the `database/sql`
+// package. This package does not bundle a SQLite driver so applications must import one themselves, for example
+// `github.com/mattn/go-sqlite3` (which registers a driver named "sqlite3") or `modernc.org/sqlite` (which registers
+// a driver named "sqlite").
+type SQLiteStore struct {
+	Store
+	db *sql.DB
+}
+
+func init() {
+	ctx := context.Background()
+	RegisterStore(ctx, "sqlite", NewSQLiteStore)
+}
+
+// NewSQLiteStore returns a new `SQLiteStore` instance configured by 'uri' which is expected to take the form of:
+//
+//	sqlite:///path/to/dedupe.db?driver={DRIVER}
+//
+// Where {DRIVER} is the (optional) name of a registered `database/sql` driver. Default is "sqlite3". The
+// database, and its "dedupe" table, are created if they do not already exist.
+func NewSQLiteStore(ctx context.Context, uri string) (Store, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	path := u.Path
+
+	if path == "" {
+		return nil, fmt.Errorf("Missing path")
+	}
+
+	driver := DEFAULT_SQLITE_DRIVER
+
+	if u.Query().Has("driver") {
+		driver = u.Query().Get("driver")
+	}
+
+	if !isRegisteredDriver(driver) {
+		return nil, fmt.Errorf("The '%s' database/sql driver is not registered, applications must import a SQLite driver (for example github.com/mattn/go-sqlite3) to use sqlite:// stores", driver)
+	}
+
+	db, err := sql.Open(driver, path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open %s, %w", path, err)
+	}
+
+	_, err = db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS dedupe (key TEXT PRIMARY KEY, tweet_ids TEXT NOT NULL, created INTEGER NOT NULL)")
+
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Failed to create dedupe table, %w", err)
+	}
+
+	s := &SQLiteStore{
+		db: db,
+	}
+
+	return s, nil
+}
+
+// Get returns the `Record` associated with 'key' and a boolean value indicating whether it exists.
+func (s *SQLiteStore) Get(ctx context.Context, key string) (*Record, bool, error) {
+
+	row := s.db.QueryRowContext(ctx, "SELECT key, tweet_ids, created FROM dedupe WHERE key = ?", key)
+	r, err := scanRecord(row)
+
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return r, true, nil
+}
+
+// GetByTweetId returns the `Record` whose tweet IDs contain 'tweet_id' and a boolean value indicating whether it exists.
+func (s *SQLiteStore) GetByTweetId(ctx context.Context, tweet_id string) (*Record, bool, error) {
+
+	enc_id, err := json.Marshal(tweet_id)
+
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to marshal tweet ID, %w", err)
+	}
+
+	// Tweet IDs are stored as a JSON-encoded list so narrow the search to rows containing the encoded ID and then confirm each match
+
+	rows, err := s.db.QueryContext(ctx, "SELECT key, tweet_ids, created FROM dedupe WHERE instr(tweet_ids, ?) > 0", string(enc_id))
+
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to query records, %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		r, err := scanRecord(rows)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		if r.HasTweetId(tweet_id) {
+			return r, true, nil
+		}
+	}
+
+	err = rows.Err()
+
+	if err != nil {
+		return nil, false, fmt.Errorf("Failed to iterate records, %w", err)
+	}
+
+	return nil, false, nil
+}
+
+// Put stores 'r' replacing any existing record with the same key.
+func (s *SQLiteStore) Put(ctx context.Context, r *Record) error {
+
+	enc_ids, err := json.Marshal(r.TweetIds)
+
+	if err != nil {
+		return fmt.Errorf("Failed to marshal tweet IDs, %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, "INSERT OR REPLACE INTO dedupe (key, tweet_ids, created) VALUES (?, ?, ?)", r.Key, string(enc_ids), r.Created.Unix())
+
+	if err != nil {
+		return fmt.Errorf("Failed to store record, %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the `Record` associated with 'key'.
+func (s *SQLiteStore) Delete(ctx context.Context, key string) error {
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM dedupe WHERE key = ?", key)
+
+	if err != nil {
+		return fmt.Errorf("Failed to delete record, %w", err)
+	}
+
+	return nil
+}
+
+// Close closes the underlying database connection.
+func (s *SQLiteStore) Close(ctx context.Context) error {
+	return s.db.Close()
+}
+
+// rowScanner is implemented by both `sql.Row` and `sql.Rows`.
+type rowScanner interface {
+	Scan(...interface{}) error
+}
+
+// scanRecord returns a new `Record` derived from the key, tweet IDs and created columns of 'row'.
+func scanRecord(row rowScanner) (*Record, error) {
+
+	var key string
+	var str_ids string
+	var created int64
+
+	err := row.Scan(&key, &str_ids, &created)
+
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to query record, %w", err)
+	}
+
+	var tweet_ids []string
+
+	err = json.Unmarshal([]byte(str_ids), &tweet_ids)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal tweet IDs, %w", err)
+	}
+
+	r := &Record{
+		Key:      key,
+		TweetIds: tweet_ids,
+		Created:  time.Unix(created, 0),
+	}
+
+	return r, nil
+}
+
+func isRegisteredDriver(name string) bool {
+
+	for _, d := range sql.Drivers() {
+
+		if d == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,161 @@
+package twitter
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster-twitter/dedupe"
+)
+
+func TestDedupeKey(t *testing.T) {
+
+	red := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	blue := image.NewRGBA(image.Rect(0, 0, 8, 8))
+
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			red.Set(x, y, color.RGBA{255, 0, 0, 255})
+			blue.Set(x, y, color.RGBA{0, 0, 255, 255})
+		}
+	}
+
+	key := dedupeKey("hello world", []image.Image{red})
+
+	if dedupeKey("hello world", []image.Image{red}) != key {
+		t.Fatalf("Expected the same status and images to have the same key")
+	}
+
+	// Descriptions do not change the key
+
+	if dedupeKey("hello world", []image.Image{NewDescribedImage(red, "A red square")}) != key {
+		t.Fatalf("Expected described image to have the same key")
+	}
+
+	others := map[string]string{
+		"status": dedupeKey("hello again", []image.Image{red}),
+		"image":  dedupeKey("hello world", []image.Image{blue}),
+		"count":  dedupeKey("hello world", []image.Image{red, red}),
+		"none":   dedupeKey("hello world", nil),
+	}
+
+	for label, other := range others {
+
+		if other == key {
+			t.Fatalf("Expected a different %s to change the key", label)
+		}
+	}
+
+	// Animated GIFs which share the same first frame have different keys
+
+	ctx := context.Background()
+
+	gif_keys := make([]string, 0)
+
+	for _, frames := range []int{2, 3} {
+
+		enc_im, err := NewEncodedImage(ctx, newTestGIF(t, 8, 8, frames), "")
+
+		if err != nil {
+			t.Fatalf("Failed to create encoded image, %v", err)
+		}
+
+		gif_keys = append(gif_keys, dedupeKey("hello world", []image.Image{enc_im}))
+	}
+
+	if gif_keys[0] == gif_keys[1] {
+		t.Fatalf("Expected animated GIFs with different frames to have different keys")
+	}
+
+	// Adjacent values can not be confused
+
+	if dedupeKey("ab", nil) == dedupeKey("a", nil) {
+		t.Fatalf("Expected different statuses to have different keys")
+	}
+}
+
+func TestPublishedRecord(t *testing.T) {
+
+	ctx := context.Background()
+
+	s, err := dedupe.NewStore(ctx, "mem://")
+
+	if err != nil {
+		t.Fatalf("Failed to create dedupe store, %v", err)
+	}
+
+	records := []*dedupe.Record{
+		{Key: "recent", TweetIds: []string{"1"}, Created: time.Now().Add(-1 * time.Minute)},
+		{Key: "expired", TweetIds: []string{"2"}, Created: time.Now().Add(-2 * time.Hour)},
+	}
+
+	for _, r := range records {
+
+		err := s.Put(ctx, r)
+
+		if err != nil {
+			t.Fatalf("Failed to put record, %v", err)
+		}
+	}
+
+	b := &TwitterBroadcaster{
+		dedupe:     s,
+		dedupe_ttl: time.Hour,
+	}
+
+	tests := map[string]bool{
+		"recent":  true,
+		"expired": false,
+		"missing": false,
+	}
+
+	for key, expected := range tests {
+
+		_, exists, err := b.publishedRecord(ctx, key)
+
+		if err != nil {
+			t.Fatalf("Failed to get record for %s, %v", key, err)
+		}
+
+		if exists != expected {
+			t.Fatalf("Expected record for %s to exist (%t), got %t", key, expected, exists)
+		}
+	}
+
+	// Records never expire if the TTL is 0
+
+	b.dedupe_ttl = 0
+
+	_, exists, err := b.publishedRecord(ctx, "expired")
+
+	if err != nil || !exists {
+		t.Fatalf("Expected record without a TTL to exist, %v", err)
+	}
+}
+
+func TestNewTwitterBroadcasterInvalidDedupeTTL(t *testing.T) {
+
+	ctx := context.Background()
+
+	invalid := []string{
+		"dedupe-ttl=1h",
+		"dedupe=mem://&dedupe-ttl=soon",
+		"dedupe=mem://&dedupe-ttl=-1h",
+	}
+
+	for _, q := range invalid {
+
+		params, _ := url.ParseQuery(q)
+		params.Set("credentials", `constant://?val={"consumer_key":"a","consumer_secret":"b","access_token":"c","access_secret":"d"}`)
+		params.Set("verify", VERIFY_NEVER)
+
+		_, err := NewTwitterBroadcaster(ctx, "twitter://?"+params.Encode())
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", q)
+		}
+	}
+}
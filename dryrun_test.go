@@ -0,0 +1,115 @@
+package twitter_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-broadcaster-twitter/twittertest"
+)
+
+func TestBroadcastDryRun(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string]string{
+		"v2":   "POST %s/2/tweets",
+		"v1.1": "POST %s/1.1/statuses/update.json",
+	}
+
+	for api, expected := range tests {
+
+		s := newTestServer(t, nil)
+
+		params := url.Values{}
+		params.Set("api", api)
+		params.Set("mode", "dry-run")
+		params.Set("min-interval", "1h")
+		params.Set("throttle", "fail")
+
+		br := newTestBroadcaster(t, s, params, nil)
+
+		var buf bytes.Buffer
+		br.SetLogger(ctx, log.New(&buf, "", 0))
+
+		// Dry-run messages do not count against the posting budget so the second message is not throttled
+
+		for _, body := range []string{"hello world", "hello again"} {
+
+			u, err := broadcast(t, br, body)
+
+			if err != nil {
+				t.Fatalf("Failed to broadcast %s message in dry-run mode, %v", api, err)
+			}
+
+			if !strings.HasPrefix(u, "dryrun") {
+				t.Fatalf("Expected synthetic dry-run UID, got %s", u)
+			}
+		}
+
+		if len(s.Tweets()) != 0 {
+			t.Fatalf("Expected nothing to be published in dry-run mode")
+		}
+
+		// Credentials are verified, using the real endpoint for the API, even though nothing is published
+
+		verify_endpoint := twittertest.ENDPOINT_VERIFY_CREDENTIALS
+
+		if api == "v1.1" {
+			verify_endpoint = twittertest.ENDPOINT_VERIFY_CREDENTIALS_V1
+		}
+
+		if len(s.RequestsFor(http.MethodGet, verify_endpoint)) != 1 {
+			t.Fatalf("Expected credentials to be verified once for %s in dry-run mode", api)
+		}
+
+		if len(s.RequestsFor(http.MethodPost, "")) != 0 {
+			t.Fatalf("Expected no write requests for %s in dry-run mode", api)
+		}
+
+		endpoint := strings.Replace(expected, "%s", s.URL, 1)
+
+		if strings.Count(buf.String(), endpoint) != 2 {
+			t.Fatalf("Expected %s to be logged twice for %s, got %s", endpoint, api, buf.String())
+		}
+	}
+}
+
+func TestNewTwitterBroadcasterDryRunInvalidCredentials(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	s.InjectFailure(&twittertest.Failure{
+		Path:       twittertest.ENDPOINT_VERIFY_CREDENTIALS,
+		StatusCode: http.StatusUnauthorized,
+		Code:       32,
+		Message:    "Could not authenticate you.",
+	})
+
+	params := url.Values{}
+	params.Set("mode", "dry-run")
+
+	uri, err := s.BroadcasterURI(params)
+
+	if err != nil {
+		t.Fatalf("Failed to derive broadcaster URI, %v", err)
+	}
+
+	opts := &twitter.TwitterBroadcasterOptions{
+		HTTPClient: s.Client(),
+	}
+
+	_, err = twitter.NewTwitterBroadcasterWithOptions(ctx, uri, opts)
+
+	if !errors.Is(err, twitter.ErrAuthRevoked) {
+		t.Fatalf("Expected invalid credentials to fail in dry-run mode, got %v", err)
+	}
+}
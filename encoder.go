@@ -0,0 +1,86 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-image-encode"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+// DEFAULT_JPEG_QUALITY is the default quality used to encode JPEG images.
+const DEFAULT_JPEG_QUALITY int = 90
+
+// jpegEncoder implements the `encode.Encoder` interface for JPEG images with a configurable quality. It
+// exists because the `aaronland/go-image-encode` JPEG encoder always uses a quality of 100.
+type jpegEncoder struct {
+	encode.Encoder
+	quality int
+}
+
+// MimeType returns the MIME type of images produced by 'e'.
+func (e *jpegEncoder) MimeType() string {
+	return "image/jpeg"
+}
+
+// Extension returns the file extension of images produced by 'e'.
+func (e *jpegEncoder) Extension() string {
+	return ".jpg"
+}
+
+// Encode encodes 'im' as a JPEG image and writes it to 'wr'.
+func (e *jpegEncoder) Encode(ctx context.Context, im image.Image, wr io.Writer) error {
+
+	opts := &jpeg.Options{
+		Quality: e.quality,
+	}
+
+	return jpeg.Encode(wr, im, opts)
+}
+
+// newImageEncoder returns a new `encode.Encoder` instance for 'uri'. JPEG URIs (for example
+// "jpeg://?quality=85") are handled by `jpegEncoder`; everything else is handed off to the
+// `aaronland/go-image-encode` package.
+func newImageEncoder(ctx context.Context, uri string) (encode.Encoder, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse encoder URI, %w", err)
+	}
+
+	switch u.Scheme {
+	case "jpeg", "jpg":
+
+		quality := DEFAULT_JPEG_QUALITY
+
+		q := u.Query()
+
+		if q.Has("quality") {
+
+			v, err := strconv.Atoi(q.Get("quality"))
+
+			if err != nil {
+				return nil, fmt.Errorf("Invalid ?quality= parameter, %w", err)
+			}
+
+			if v < 1 || v > 100 {
+				return nil, fmt.Errorf("Invalid ?quality= parameter, must be between 1 and 100")
+			}
+
+			quality = v
+		}
+
+		e := &jpegEncoder{
+			quality: quality,
+		}
+
+		return e, nil
+
+	default:
+		return encode.NewEncoder(ctx, uri)
+	}
+}
@@ -0,0 +1,322 @@
+package twitter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ChimeraCoder/anaconda"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRateLimited is the error (or `RateLimitError`) returned when a request exceeds a Twitter API rate limit.
+var ErrRateLimited = errors.New("Rate limit exceeded")
+
+// ErrDuplicateStatus is the error returned when Twitter rejects a tweet because it duplicates a previous tweet.
+var ErrDuplicateStatus = errors.New("Status is a duplicate")
+
+// ErrStatusTooLong is the error returned when a tweet is longer than `MAX_STATUS_LENGTH` characters.
+var ErrStatusTooLong = errors.New("Status is too long")
+
+// ErrMediaRejected is the error returned when media can not be fitted to Twitter's limits or is rejected by Twitter.
+var ErrMediaRejected = errors.New("Media rejected")
+
+// ErrAuthRevoked is the error returned when Twitter rejects the credentials used to sign a request, for example
+// because the access token has been revoked.
+var ErrAuthRevoked = errors.New("Authorization revoked or invalid")
+
+// ErrNotFound is the error returned when a tweet (or other resource) does not exist, for example because it has
+// already been deleted.
+var ErrNotFound = errors.New("Not found")
+
+// ErrSuspended is the error returned when the account associated with a set of credentials is suspended or locked.
+var ErrSuspended = errors.New("Account suspended")
+
+// Error is an error wrapping an underlying error (typically an `APIError` or an `anaconda.ApiError`) with one
+// of the sentinel errors defined by this package, such that `errors.Is(err, ErrDuplicateStatus)` and
+// `errors.As(err, &api_err)` both work.
+type Error struct {
+	// Kind is the sentinel error, for example `ErrDuplicateStatus`, describing the error.
+	Kind error
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns a string representation of 'e'.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v, %v", e.Kind, e.Err)
+}
+
+// Is returns a boolean value indicating whether 'target' is the sentinel error describing 'e'.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the underlying error wrapped by 'e'.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// RateLimitError is the error returned when a request exceeds a Twitter API rate limit. It satisfies
+// `errors.Is(err, ErrRateLimited)`.
+type RateLimitError struct {
+	// Reset is the time at which the rate limit resets. It is the zero value if the reset time is not known.
+	Reset time.Time
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns a string representation of 'e'.
+func (e *RateLimitError) Error() string {
+
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("%v, %v", ErrRateLimited, e.Err)
+	}
+
+	return fmt.Sprintf("%v until %s, %v", ErrRateLimited, e.Reset.Format(time.RFC3339), e.Err)
+}
+
+// Is returns a boolean value indicating whether 'target' is `ErrRateLimited`.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Unwrap returns the underlying error wrapped by 'e'.
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// APIError is the error returned by (natively issued) Twitter API requests that fail.
+type APIError struct {
+	// StatusCode is the HTTP status code of the failed request.
+	StatusCode int `json:"-"`
+	// Header is the HTTP response header of the failed request.
+	Header http.Header `json:"-"`
+	// Method is the HTTP method of the failed request.
+	Method string `json:"-"`
+	// URL is the URL of the failed request.
+	URL string `json:"-"`
+	// Body is the raw body of the failed request.
+	Body string `json:"-"`
+	// Title is the (v2 problem) title of the error.
+	Title string `json:"title"`
+	// Detail is the (v2 problem) detail of the error.
+	Detail string `json:"detail"`
+	// Type is the (v2 problem) type URI of the error.
+	Type string `json:"type"`
+	// Errors is the list of individual error messages reported by the API.
+	Errors []APIErrorMessage `json:"errors"`
+}
+
+// APIErrorMessage is an individual error message reported by the Twitter API.
+type APIErrorMessage struct {
+	// Message is the human-readable error message.
+	Message string `json:"message"`
+	// Code is the numeric Twitter error code, if present.
+	Code int `json:"code,omitempty"`
+	// Title is the (v2 problem) title of the error, if present.
+	Title string `json:"title,omitempty"`
+	// Detail is the (v2 problem) detail of the error, if present.
+	Detail string `json:"detail,omitempty"`
+	// Parameters are the (v2) request parameters, and their values, that caused the error, if present.
+	Parameters map[string][]string `json:"parameters,omitempty"`
+}
+
+// Error returns a string representation of 'e'.
+func (e *APIError) Error() string {
+
+	msg := e.Detail
+
+	if msg == "" && len(e.Errors) > 0 {
+
+		msg = e.Errors[0].Message
+
+		if msg == "" {
+			msg = e.Errors[0].Detail
+		}
+	}
+
+	if msg == "" {
+		msg = e.Body
+	}
+
+	return fmt.Sprintf("%s %s returned status %d, %s", e.Method, e.URL, e.StatusCode, msg)
+}
+
+// Codes returns the list of numeric Twitter error codes associated with 'e'.
+func (e *APIError) Codes() []int {
+
+	codes := make([]int, 0)
+
+	for _, m := range e.Errors {
+
+		if m.Code != 0 {
+			codes = append(codes, m.Code)
+		}
+	}
+
+	return codes
+}
+
+func newAPIError(req *http.Request, rsp *http.Response, body []byte) *APIError {
+
+	api_err := &APIError{
+		StatusCode: rsp.StatusCode,
+		Header:     rsp.Header,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Body:       string(body),
+	}
+
+	// Errors are ignored because not every error response (for example
+	// errors returned by a proxy) will be valid JSON.
+
+	json.Unmarshal(body, api_err)
+
+	return api_err
+}
+
+// classifyError wraps 'err' in an `Error` or `RateLimitError` instance if it is an `APIError` or an `anaconda.ApiError`
+// which corresponds to one of the sentinel errors defined by this package. Otherwise 'err' is returned unchanged.
+func classifyError(err error) error {
+
+	if err == nil {
+		return nil
+	}
+
+	var twitter_err *Error
+	var ratelimit_err *RateLimitError
+
+	if errors.As(err, &twitter_err) || errors.As(err, &ratelimit_err) {
+		return err
+	}
+
+	var status_code int
+	var header http.Header
+	var codes []int
+	var params []string
+	var messages []string
+
+	var api_err *APIError
+	var anaconda_err *anaconda.ApiError
+
+	switch {
+	case errors.As(err, &api_err):
+
+		status_code = api_err.StatusCode
+		header = api_err.Header
+		codes = api_err.Codes()
+		messages = []string{api_err.Title, api_err.Detail, api_err.Type}
+
+		for _, m := range api_err.Errors {
+
+			messages = append(messages, m.Message, m.Title, m.Detail)
+
+			for p := range m.Parameters {
+				params = append(params, p)
+			}
+		}
+
+	case errors.As(err, &anaconda_err):
+
+		status_code = anaconda_err.StatusCode
+		header = anaconda_err.Header
+
+		for _, e := range anaconda_err.Decoded.Errors {
+			codes = append(codes, e.Code)
+			messages = append(messages, e.Message)
+		}
+
+	default:
+		return err
+	}
+
+	kind := errorKind(status_code, codes, params, strings.ToLower(strings.Join(messages, " ")))
+
+	switch kind {
+	case nil:
+		return err
+	case ErrRateLimited:
+
+		ratelimit_err := &RateLimitError{
+			Err: err,
+		}
+
+		reset, parse_err := strconv.ParseInt(header.Get("x-rate-limit-reset"), 10, 64)
+
+		if parse_err == nil {
+			ratelimit_err.Reset = time.Unix(reset, 0)
+		}
+
+		return ratelimit_err
+
+	default:
+		return &Error{Kind: kind, Err: err}
+	}
+}
+
+// errorKind returns the sentinel error corresponding to an API response with 'status_code', (v1.1) error 'codes',
+// (v2) invalid request 'params' and (lower-cased) error 'message' or nil if there is no corresponding sentinel error.
+// The v2 API does not return numeric error codes so errors are matched on their status code, the parameters they
+// refer to and, for errors which have no other distinguishing features, their message instead.
+//
+// https://developer.twitter.com/en/support/twitter-api/error-troubleshooting
+func errorKind(status_code int, codes []int, params []string, message string) error {
+
+	for _, code := range codes {
+
+		switch code {
+		case 88:
+			return ErrRateLimited
+		case 187:
+			return ErrDuplicateStatus
+		case 186:
+			return ErrStatusTooLong
+		case 323, 324, 325:
+			return ErrMediaRejected
+		case 32, 89, 215, 220:
+			return ErrAuthRevoked
+		case 64, 326:
+			return ErrSuspended
+		case 144:
+			return ErrNotFound
+		}
+	}
+
+	switch {
+	case status_code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status_code == http.StatusUnauthorized:
+		return ErrAuthRevoked
+	case status_code == http.StatusNotFound:
+		return ErrNotFound
+	case status_code >= 500:
+		return nil
+	case status_code == http.StatusBadRequest && hasMediaParameter(params):
+		return ErrMediaRejected
+	case strings.Contains(message, "suspended") || strings.Contains(message, "locked"):
+		return ErrSuspended
+	case strings.Contains(message, "duplicate"):
+		return ErrDuplicateStatus
+	case strings.Contains(message, "too long") || strings.Contains(message, "shorter"):
+		return ErrStatusTooLong
+	}
+
+	return nil
+}
+
+// hasMediaParameter returns a boolean value indicating whether any of 'params' is a (v2) media parameter, for
+// example "media.media_ids".
+func hasMediaParameter(params []string) bool {
+
+	for _, p := range params {
+
+		if p == "media" || strings.HasPrefix(p, "media.") {
+			return true
+		}
+	}
+
+	return false
+}
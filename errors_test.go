@@ -0,0 +1,127 @@
+package twitter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ChimeraCoder/anaconda"
+)
+
+func TestErrorKind(t *testing.T) {
+
+	tests := []struct {
+		status_code int
+		codes       []int
+		params      []string
+		message     string
+		expected    error
+	}{
+		{http.StatusForbidden, []int{187}, nil, "", ErrDuplicateStatus},
+		{http.StatusForbidden, []int{186}, nil, "", ErrStatusTooLong},
+		{http.StatusBadRequest, []int{324}, nil, "", ErrMediaRejected},
+		{http.StatusForbidden, []int{326}, nil, "", ErrSuspended},
+		{http.StatusNotFound, []int{144}, nil, "", ErrNotFound},
+		{http.StatusTooManyRequests, nil, nil, "", ErrRateLimited},
+		{http.StatusUnauthorized, nil, nil, "", ErrAuthRevoked},
+		{http.StatusForbidden, nil, nil, "you are not allowed to create a tweet with duplicate content.", ErrDuplicateStatus},
+		{http.StatusForbidden, nil, nil, "your account is suspended and is not permitted to access this feature.", ErrSuspended},
+		{http.StatusBadRequest, nil, nil, "tweet text is too long", ErrStatusTooLong},
+		{http.StatusBadRequest, nil, []string{"media.media_ids"}, "your media ids are invalid.", ErrMediaRejected},
+		{http.StatusBadRequest, nil, []string{"reply.in_reply_to_tweet_id"}, "the tweet you are replying to does not exist.", nil},
+		{http.StatusServiceUnavailable, nil, []string{"media.media_ids"}, "media service unavailable", nil},
+		{http.StatusBadRequest, nil, nil, "your media ids are invalid.", nil},
+		{http.StatusForbidden, nil, nil, "you are not permitted to post media", nil},
+		{http.StatusBadRequest, nil, nil, "something else", nil},
+	}
+
+	for _, test := range tests {
+
+		kind := errorKind(test.status_code, test.codes, test.params, test.message)
+
+		if kind != test.expected {
+			t.Fatalf("Expected %d %v %v '%s' to be %v, got %v", test.status_code, test.codes, test.params, test.message, test.expected, kind)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+
+	if classifyError(nil) != nil {
+		t.Fatalf("Expected nil error to be classified as nil")
+	}
+
+	other := fmt.Errorf("Failed to open file")
+
+	if classifyError(other) != other {
+		t.Fatalf("Expected non-API errors to be returned unchanged")
+	}
+
+	api_err := &APIError{
+		StatusCode: http.StatusForbidden,
+		Method:     http.MethodPost,
+		URL:        "https://api.twitter.com/2/tweets",
+		Detail:     "You are not allowed to create a Tweet with duplicate content.",
+	}
+
+	err := classifyError(fmt.Errorf("Failed to post tweet, %w", api_err))
+
+	if !errors.Is(err, ErrDuplicateStatus) {
+		t.Fatalf("Expected ErrDuplicateStatus, got %v", err)
+	}
+
+	var target *APIError
+
+	if !errors.As(err, &target) || target != api_err {
+		t.Fatalf("Expected classified error to wrap the APIError")
+	}
+
+	// Errors which have already been classified are not wrapped again
+
+	if classifyError(err) != err {
+		t.Fatalf("Expected classified error to be returned unchanged")
+	}
+
+	reset := time.Now().Add(time.Minute).Truncate(time.Second)
+
+	h := http.Header{}
+	h.Set("x-rate-limit-reset", strconv.FormatInt(reset.Unix(), 10))
+
+	err = classifyError(&APIError{StatusCode: http.StatusTooManyRequests, Header: h})
+
+	var ratelimit_err *RateLimitError
+
+	if !errors.As(err, &ratelimit_err) || !ratelimit_err.Reset.Equal(reset) {
+		t.Fatalf("Expected RateLimitError resetting at %s, got %v", reset, err)
+	}
+
+	anaconda_err := &anaconda.ApiError{
+		StatusCode: http.StatusForbidden,
+		Header:     http.Header{},
+	}
+
+	anaconda_err.Decoded.Errors = []anaconda.TwitterError{
+		{Code: 187, Message: "Status is a duplicate."},
+	}
+
+	err = classifyError(anaconda_err)
+
+	if !errors.Is(err, ErrDuplicateStatus) {
+		t.Fatalf("Expected anaconda error to be ErrDuplicateStatus, got %v", err)
+	}
+	// v2 media errors are identified by the parameters they refer to
+
+	req, _ := http.NewRequest(http.MethodPost, "https://api.twitter.com/2/tweets", nil)
+	rsp := &http.Response{StatusCode: http.StatusBadRequest, Header: http.Header{}}
+
+	body := `{"errors":[{"parameters":{"media.media_ids":["1234"]},"message":"Your media IDs are invalid."}],"title":"Invalid Request","detail":"One or more parameters to your request was invalid.","type":"https://api.twitter.com/2/problems/invalid-request"}`
+
+	err = classifyError(newAPIError(req, rsp, []byte(body)))
+
+	if !errors.Is(err, ErrMediaRejected) {
+		t.Fatalf("Expected v2 media error to be ErrMediaRejected, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clientFactory creates the `client` instances used by a `TwitterBroadcaster` from a set of credentials. It is used
+// both when the broadcaster is created and when its credentials are reloaded (see `?watch=`).
+type clientFactory struct {
+	api         string
+	mode        string
+	options     *clientOptions
+	token_sink  oauth.TokenSink
+	max_retries int
+	max_wait    time.Duration
+	logger      *log.Logger
+}
+
+// newClient returns a new `client` instance, for the API and mode defined by 'f', using 'creds'.
+func (f *clientFactory) newClient(ctx context.Context, creds oauth.Credentials) (client, error) {
+
+	client_opts := *f.options
+
+	switch c := creds.(type) {
+	case *oauth.OAuth1Credentials:
+
+		client_opts.Credentials = c
+
+	case *oauth.OAuth2Credentials:
+
+		if f.api == API_V1 {
+			return nil, fmt.Errorf("OAuth2 credentials can not be used with the v1.1 API, use ?api=%s", API_V2)
+		}
+
+		source_opts := &oauth.OAuth2TokenSourceOptions{
+			HTTPClient: client_opts.httpClient(),
+			TokenURL:   client_opts.apiBase() + "/2/oauth2/token",
+			Sink:       f.token_sink,
+			Logger:     f.logger,
+		}
+
+		source, err := oauth.NewOAuth2TokenSource(ctx, c, source_opts)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create OAuth2 token source, %w", err)
+		}
+
+		client_opts.OAuth2 = source
+
+	default:
+		return nil, fmt.Errorf("Unsupported credentials type %T", creds)
+	}
+
+	var tw_client client
+	var err error
+
+	switch {
+	case f.api == API_V1:
+		tw_client, err = newV1Client(ctx, &client_opts)
+	default:
+		tw_client, err = newV2Client(ctx, &client_opts)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create Twitter client, %w", err)
+	}
+
+	// Credentials are still verified in "dry-run" mode but nothing is published
+
+	if f.mode == MODE_DRYRUN {
+
+		tw_client, err = newDryRunClient(ctx, tw_client, f.api, &client_opts, f.logger)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create dry-run client, %w", err)
+		}
+
+		return tw_client, nil
+	}
+
+	tw_client, err = newRetryClient(ctx, tw_client, f.max_retries, f.max_wait, f.logger)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid retry options, %w", err)
+	}
+
+	return tw_client, nil
+}
+
+// accountKey returns the key used to share a posting budget between broadcasters for the same account. The key is
+// derived from identities which do not change when credentials are refreshed: the ID of the verified 'account', if
+// present, or the user ID at the start of OAuth1 access tokens. Otherwise OAuth2 credentials, whose access tokens
+// rotate, are keyed on their client ID and the account 'name' (which is empty unless the credentials contain
+// multiple accounts).
+func accountKey(creds oauth.Credentials, account *Account, name string) string {
+
+	if account != nil {
+		return fmt.Sprintf("user:%s", account.Id)
+	}
+
+	switch c := creds.(type) {
+	case *oauth.OAuth1Credentials:
+
+		// OAuth1 user access tokens take the form "{USER_ID}-{TOKEN}"
+
+		user_id, _, ok := strings.Cut(c.AccessToken, "-")
+
+		if ok {
+
+			_, err := strconv.ParseUint(user_id, 10, 64)
+
+			if err == nil {
+				return fmt.Sprintf("user:%s", user_id)
+			}
+		}
+
+		return fmt.Sprintf("oauth1:%s", c.AccessToken)
+
+	case *oauth.OAuth2Credentials:
+		return fmt.Sprintf("oauth2:%s:%s", c.ClientId, name)
+	default:
+		return ""
+	}
+}
+
+// clientState is a `client` instance and the calls which are currently using it.
+type clientState struct {
+	client   client
+	inflight *sync.WaitGroup
+}
+
+// newClientState returns a new `clientState` instance for 'c'.
+func newClientState(c client) *clientState {
+
+	s := &clientState{
+		client:   c,
+		inflight: new(sync.WaitGroup),
+	}
+
+	return s
+}
+
+// acquireClient returns the current `client` instance for 'b' and a function which must be called once the caller has
+// finished using it. Callers should use the same client for the duration of an operation (for example publishing a
+// thread) so that it is not affected by credentials being reloaded part way through.
+func (b *TwitterBroadcaster) acquireClient() (client, func()) {
+
+	b.client_mu.RLock()
+	defer b.client_mu.RUnlock()
+
+	s := b.client_state
+	s.inflight.Add(1)
+
+	return s.client, s.inflight.Done
+}
+
+// replaceClient replaces the current `client` instance for 'b' with 'c' and resets the cached account to 'account'
+// (which may be nil). Calls which are using the previous client continue to do so; it is closed once they have finished.
+func (b *TwitterBroadcaster) replaceClient(c client, account *Account) {
+
+	b.client_mu.Lock()
+	previous := b.client_state
+	b.client_state = newClientState(c)
+	b.client_mu.Unlock()
+
+	b.account_mu.Lock()
+	b.account = account
+	b.account_mu.Unlock()
+
+	go func() {
+		previous.inflight.Wait()
+		closeClient(previous.client)
+	}()
+}
+
+// closeClient releases any resources, for example background goroutines, associated with 'c'.
+func closeClient(c client) {
+
+	switch v := c.(type) {
+	case *retryClient:
+		closeClient(v.client)
+	case *v1Client:
+		v.twitter_client.Close()
+	}
+}
@@ -0,0 +1,299 @@
+package twitter
+
+// https://developer.twitter.com/en/docs/twitter-api/v1/media/upload-media/uploading-media/media-best-practices
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/aaronland/go-image-encode"
+	"image"
+	"image/color"
+)
+
+// MIN_JPEG_QUALITY is the lowest quality JPEG images will be encoded with when fitting them to Twitter's limits.
+const MIN_JPEG_QUALITY int = 50
+
+// JPEG_QUALITY_STEP is the amount JPEG quality is reduced by for each attempt to fit an image to Twitter's limits.
+const JPEG_QUALITY_STEP int = 10
+
+// SCALE_STEP is the factor image dimensions are reduced by for each attempt to fit an image to Twitter's limits.
+const SCALE_STEP float64 = 0.75
+
+// MIN_IMAGE_DIMENSION is the smallest width or height that Twitter will accept for an image.
+const MIN_IMAGE_DIMENSION int = 4
+
+// MAX_FIT_ATTEMPTS is the maximum number of times an image will be re-encoded when fitting it to Twitter's limits.
+const MAX_FIT_ATTEMPTS int = 20
+
+// MediaLimits defines the maximum size, in bytes and pixels, of a media file that Twitter will accept.
+type MediaLimits struct {
+	// MaxBytes is the maximum size of a media file in bytes.
+	MaxBytes int64
+	// MaxWidth is the maximum width of a media file in pixels.
+	MaxWidth int
+	// MaxHeight is the maximum height of a media file in pixels.
+	MaxHeight int
+}
+
+// media_limits is the lookup table of `MediaLimits` keyed by Twitter media category.
+var media_limits = map[string]MediaLimits{
+	MEDIA_CATEGORY_IMAGE: {
+		MaxBytes:  5 * 1024 * 1024,
+		MaxWidth:  8192,
+		MaxHeight: 8192,
+	},
+	MEDIA_CATEGORY_GIF: {
+		MaxBytes:  15 * 1024 * 1024,
+		MaxWidth:  1280,
+		MaxHeight: 1080,
+	},
+}
+
+// LimitsForContentType returns the `MediaLimits` for media whose MIME type is 'content_type'.
+func LimitsForContentType(content_type string) (MediaLimits, bool) {
+	l, ok := media_limits[MediaCategory(content_type)]
+	return l, ok
+}
+
+// fitResult describes the outcome of fitting an image to Twitter's limits.
+type fitResult struct {
+	Body           []byte
+	ContentType    string
+	OriginalBytes  int
+	OriginalWidth  int
+	OriginalHeight int
+	FinalWidth     int
+	FinalHeight    int
+	Quality        int
+	Attempts       int
+}
+
+// String returns a human-readable summary of 'r'.
+func (r *fitResult) String() string {
+
+	str := fmt.Sprintf("%dx%d (%d bytes) -> %dx%d (%d bytes, %s", r.OriginalWidth, r.OriginalHeight, r.OriginalBytes, r.FinalWidth, r.FinalHeight, len(r.Body), r.ContentType)
+
+	if r.Quality > 0 {
+		str = fmt.Sprintf("%s, quality %d", str, r.Quality)
+	}
+
+	return fmt.Sprintf("%s) after %d attempt(s)", str, r.Attempts)
+}
+
+// Resized returns a boolean value indicating whether the image was resized or re-encoded with a lower quality.
+func (r *fitResult) Resized() bool {
+	return r.Attempts > 1 || r.OriginalWidth != r.FinalWidth || r.OriginalHeight != r.FinalHeight
+}
+
+// fitsLimits returns a boolean value indicating whether 'enc_im' already fits within Twitter's limits.
+func fitsLimits(enc_im *EncodedImage) bool {
+
+	limits, ok := LimitsForContentType(enc_im.ContentType)
+
+	if !ok {
+		return true
+	}
+
+	if int64(len(enc_im.Body)) > limits.MaxBytes {
+		return false
+	}
+
+	bounds := enc_im.Bounds()
+
+	if bounds.Dx() > limits.MaxWidth || bounds.Dy() > limits.MaxHeight {
+		return false
+	}
+
+	return true
+}
+
+// fitImage encodes 'im' using 'enc' ensuring that the result fits within the Twitter limits for
+// the encoder's MIME type. Images are first scaled down to fit the maximum allowed dimensions. If the
+// encoded image is still too large then JPEG images are re-encoded with progressively lower qualities
+// (down to `MIN_JPEG_QUALITY`) after which, for all image types, the dimensions are reduced by `SCALE_STEP`
+// until the image fits or `MAX_FIT_ATTEMPTS` is reached.
+func fitImage(ctx context.Context, im image.Image, enc encode.Encoder) (*fitResult, error) {
+
+	content_type := enc.MimeType()
+
+	limits, ok := LimitsForContentType(content_type)
+
+	if !ok {
+		return nil, fmt.Errorf("No limits defined for content type '%s'", content_type)
+	}
+
+	bounds := im.Bounds()
+
+	r := &fitResult{
+		ContentType:    content_type,
+		OriginalWidth:  bounds.Dx(),
+		OriginalHeight: bounds.Dy(),
+	}
+
+	w, h := scaleToFit(r.OriginalWidth, r.OriginalHeight, limits.MaxWidth, limits.MaxHeight)
+
+	current := im
+
+	if w != r.OriginalWidth || h != r.OriginalHeight {
+		current = resizeImage(im, w, h)
+	}
+
+	quality := 0
+
+	jpeg_enc, is_jpeg := enc.(*jpegEncoder)
+
+	if is_jpeg {
+		quality = jpeg_enc.quality
+	}
+
+	for r.Attempts < MAX_FIT_ATTEMPTS {
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+			// pass
+		}
+
+		r.Attempts += 1
+
+		attempt_enc := enc
+
+		if is_jpeg {
+			attempt_enc = &jpegEncoder{
+				quality: quality,
+			}
+		}
+
+		buf := new(bytes.Buffer)
+
+		err := attempt_enc.Encode(ctx, current, buf)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to encode image, %w", err)
+		}
+
+		if r.Attempts == 1 {
+			r.OriginalBytes = buf.Len()
+		}
+
+		if int64(buf.Len()) <= limits.MaxBytes {
+
+			final_bounds := current.Bounds()
+
+			r.Body = buf.Bytes()
+			r.FinalWidth = final_bounds.Dx()
+			r.FinalHeight = final_bounds.Dy()
+			r.Quality = quality
+
+			return r, nil
+		}
+
+		if is_jpeg && quality > MIN_JPEG_QUALITY {
+
+			quality -= JPEG_QUALITY_STEP
+
+			if quality < MIN_JPEG_QUALITY {
+				quality = MIN_JPEG_QUALITY
+			}
+
+			continue
+		}
+
+		w = int(float64(w) * SCALE_STEP)
+		h = int(float64(h) * SCALE_STEP)
+
+		if w < MIN_IMAGE_DIMENSION || h < MIN_IMAGE_DIMENSION {
+			break
+		}
+
+		current = resizeImage(im, w, h)
+	}
+
+	return nil, fmt.Errorf("Unable to fit %dx%d image within %d bytes (%s)", r.OriginalWidth, r.OriginalHeight, limits.MaxBytes, content_type)
+}
+
+// scaleToFit returns the largest dimensions, preserving the aspect ratio of 'w' and 'h', that fit within
+// 'max_w' and 'max_h'.
+func scaleToFit(w int, h int, max_w int, max_h int) (int, int) {
+
+	if w <= max_w && h <= max_h {
+		return w, h
+	}
+
+	ratio_w := float64(max_w) / float64(w)
+	ratio_h := float64(max_h) / float64(h)
+
+	ratio := ratio_w
+
+	if ratio_h < ratio {
+		ratio = ratio_h
+	}
+
+	new_w := int(float64(w) * ratio)
+	new_h := int(float64(h) * ratio)
+
+	if new_w < 1 {
+		new_w = 1
+	}
+
+	if new_h < 1 {
+		new_h = 1
+	}
+
+	return new_w, new_h
+}
+
+// resizeImage returns a copy of 'im' resized to 'w' x 'h' pixels. Each destination pixel is the average of
+// the source pixels it covers (box filtering) which is suitable for the downscaling done by `fitImage`.
+func resizeImage(im image.Image, w int, h int) *image.RGBA {
+
+	src := im.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	src_w := src.Dx()
+	src_h := src.Dy()
+
+	for y := 0; y < h; y++ {
+
+		y0 := src.Min.Y + (y * src_h / h)
+		y1 := src.Min.Y + ((y + 1) * src_h / h)
+
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < w; x++ {
+
+			x0 := src.Min.X + (x * src_w / w)
+			x1 := src.Min.X + ((x + 1) * src_w / w)
+
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, count uint64
+
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sr, sg, sb, sa := im.At(sx, sy).RGBA()
+					r += uint64(sr)
+					g += uint64(sg)
+					b += uint64(sb)
+					a += uint64(sa)
+					count += 1
+				}
+			}
+
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,110 @@
+package twitter
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestScaleToFit(t *testing.T) {
+
+	tests := [][6]int{
+		{100, 50, 200, 200, 100, 50},
+		{400, 200, 200, 200, 200, 100},
+		{200, 400, 200, 200, 100, 200},
+		{9000, 100, 8192, 8192, 8192, 91},
+		{10000, 1, 100, 100, 100, 1},
+	}
+
+	for _, test := range tests {
+
+		w, h := scaleToFit(test[0], test[1], test[2], test[3])
+
+		if w != test[4] || h != test[5] {
+			t.Fatalf("Expected %dx%d to scale to %dx%d, got %dx%d", test[0], test[1], test[4], test[5], w, h)
+		}
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+
+	im := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(im, im.Bounds(), &image.Uniform{color.RGBA{0, 0, 255, 255}}, image.Point{}, draw.Src)
+
+	resized := resizeImage(im, 10, 20)
+
+	if resized.Bounds().Dx() != 10 || resized.Bounds().Dy() != 20 {
+		t.Fatalf("Unexpected dimensions %v", resized.Bounds())
+	}
+
+	c := resized.RGBAAt(5, 5)
+
+	if c.B != 255 || c.R != 0 || c.A != 255 {
+		t.Fatalf("Unexpected colour %v", c)
+	}
+}
+
+func TestFitImage(t *testing.T) {
+
+	ctx := context.Background()
+
+	enc, err := newImageEncoder(ctx, "jpeg://?quality=85")
+
+	if err != nil {
+		t.Fatalf("Failed to create encoder, %v", err)
+	}
+
+	r, err := fitImage(ctx, image.NewRGBA(image.Rect(0, 0, 64, 64)), enc)
+
+	if err != nil {
+		t.Fatalf("Failed to fit image, %v", err)
+	}
+
+	if r.Resized() || r.Quality != 85 || r.ContentType != "image/jpeg" {
+		t.Fatalf("Expected image to be encoded as-is, got %s", r)
+	}
+
+	r, err = fitImage(ctx, image.NewRGBA(image.Rect(0, 0, 9000, 100)), enc)
+
+	if err != nil {
+		t.Fatalf("Failed to fit image, %v", err)
+	}
+
+	if !r.Resized() || r.FinalWidth != 8192 || r.FinalHeight != 91 {
+		t.Fatalf("Expected image to be scaled to fit, got %s", r)
+	}
+
+	limits, _ := LimitsForContentType(r.ContentType)
+
+	if int64(len(r.Body)) > limits.MaxBytes {
+		t.Fatalf("Expected image to fit within %d bytes, got %d", limits.MaxBytes, len(r.Body))
+	}
+}
+
+func TestNewImageEncoder(t *testing.T) {
+
+	ctx := context.Background()
+
+	enc, err := newImageEncoder(ctx, "jpeg://?quality=85")
+
+	if err != nil {
+		t.Fatalf("Failed to create encoder, %v", err)
+	}
+
+	jpeg_enc, ok := enc.(*jpegEncoder)
+
+	if !ok || jpeg_enc.quality != 85 {
+		t.Fatalf("Expected JPEG encoder with quality 85")
+	}
+
+	for _, uri := range []string{"jpeg://?quality=0", "jpeg://?quality=101", "jpeg://?quality=high"} {
+
+		_, err := newImageEncoder(ctx, uri)
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to be invalid", uri)
+		}
+	}
+}
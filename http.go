@@ -0,0 +1,173 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	oauth1 "github.com/garyburd/go-oauth/oauth"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// requestSigner is an interface for adding authorization details to HTTP requests.
+type requestSigner interface {
+	// sign adds authorization details to a request. 'form' is the list of form-encoded parameters (if any)
+	// included in the request body.
+	sign(*http.Request, url.Values) error
+}
+
+// oauth1Signer implements the `requestSigner` interface by adding an OAuth1 signature to requests.
+type oauth1Signer struct {
+	oauth_client *oauth1.Client
+	credentials  *oauth1.Credentials
+}
+
+func newOAuth1Signer(consumer_key string, consumer_secret string, access_token string, access_secret string) *oauth1Signer {
+
+	oauth_client := &oauth1.Client{
+		Credentials: oauth1.Credentials{
+			Token:  consumer_key,
+			Secret: consumer_secret,
+		},
+	}
+
+	creds := &oauth1.Credentials{
+		Token:  access_token,
+		Secret: access_secret,
+	}
+
+	s := &oauth1Signer{
+		oauth_client: oauth_client,
+		credentials:  creds,
+	}
+
+	return s
+}
+
+func (s *oauth1Signer) sign(req *http.Request, form url.Values) error {
+	return s.oauth_client.SetAuthorizationHeader(req.Header, s.credentials, req.Method, req.URL, form)
+}
+
+// oauth2Signer implements the `requestSigner` interface by adding an OAuth2 bearer token, refreshed as
+// necessary, to requests.
+type oauth2Signer struct {
+	source *oauth.OAuth2TokenSource
+}
+
+func (s *oauth2Signer) sign(req *http.Request, form url.Values) error {
+
+	token, err := s.source.Token(req.Context())
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// newRequestSigner returns a `requestSigner` for the credentials defined by 'opts'.
+func newRequestSigner(opts *clientOptions) (requestSigner, error) {
+
+	switch {
+	case opts.OAuth2 != nil:
+		return &oauth2Signer{source: opts.OAuth2}, nil
+	case opts.Credentials != nil:
+		creds := opts.Credentials
+		return newOAuth1Signer(creds.ConsumerKey, creds.ConsumerSecret, creds.AccessToken, creds.AccessSecret), nil
+	default:
+		return nil, fmt.Errorf("Missing credentials")
+	}
+}
+
+// signedClient issues signed HTTP requests to the Twitter API.
+type signedClient struct {
+	signer      requestSigner
+	http_client *http.Client
+}
+
+func newSignedClient(http_client *http.Client, signer requestSigner) *signedClient {
+
+	c := &signedClient{
+		signer:      signer,
+		http_client: http_client,
+	}
+
+	return c
+}
+
+// get issues a signed GET request to 'uri' with 'query' appended as a query string and decodes
+// the response in to 'target'.
+func (c *signedClient) get(ctx context.Context, uri string, query url.Values, target interface{}) error {
+
+	if len(query) > 0 {
+		uri = fmt.Sprintf("%s?%s", uri, query.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	return c.do(req, nil, target)
+}
+
+// postForm issues a signed, form-encoded POST request to 'uri' and decodes the response in to 'target'.
+func (c *signedClient) postForm(ctx context.Context, uri string, form url.Values, target interface{}) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.do(req, form, target)
+}
+
+// do signs and executes 'req' and decodes the response in to 'target'. 'form' is the list of
+// form-encoded parameters (if any) included in the request body which are used to derive the
+// request's OAuth1 signature. Any non-2XX response is returned as an `APIError`.
+func (c *signedClient) do(req *http.Request, form url.Values, target interface{}) error {
+
+	err := c.signer.sign(req, form)
+
+	if err != nil {
+		return fmt.Errorf("Failed to sign request, %w", err)
+	}
+
+	rsp, err := c.http_client.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+
+	if err != nil {
+		return fmt.Errorf("Failed to read response, %w", err)
+	}
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return classifyError(newAPIError(req, rsp, body))
+	}
+
+	if target == nil || len(body) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(body, target)
+
+	if err != nil {
+		return fmt.Errorf("Failed to unmarshal response, %w", err)
+	}
+
+	return nil
+}
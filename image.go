@@ -0,0 +1,147 @@
+package twitter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/aaronland/go-image-encode"
+	"image"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// EncodedImage is an `image.Image` instance which also carries the original encoded bytes, and content
+// type, of an image. When an `EncodedImage` is included in a `broadcaster.Message` instance's `Images`
+// property the `TwitterBroadcaster` will upload its original bytes untouched rather than re-encoding it.
+// Other `broadcaster.Broadcaster` implementations will treat it as a plain `image.Image`.
+type EncodedImage struct {
+	image.Image
+	// Body is the original encoded image data.
+	Body []byte
+	// ContentType is the MIME type of the encoded image data.
+	ContentType string
+	// Frames is the number of frames in the encoded image data. Values greater than 1 indicate an animated GIF.
+	Frames int
+}
+
+// NewEncodedImage returns a new `EncodedImage` instance for 'body' whose MIME type is 'content_type'. If
+// 'content_type' is empty it will be derived from the contents of 'body'.
+func NewEncodedImage(ctx context.Context, body []byte, content_type string) (*EncodedImage, error) {
+
+	if content_type == "" {
+		content_type = http.DetectContentType(body)
+	}
+
+	content_type = strings.ToLower(content_type)
+
+	if !strings.HasPrefix(content_type, "image/") {
+		return nil, fmt.Errorf("Invalid content type for image, '%s'", content_type)
+	}
+
+	if content_type == "image/gif" {
+		return newEncodedGIF(ctx, body)
+	}
+
+	im, _, err := image.Decode(bytes.NewReader(body))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode image, %w", err)
+	}
+
+	enc_im := &EncodedImage{
+		Image:       im,
+		Body:        body,
+		ContentType: content_type,
+		Frames:      1,
+	}
+
+	return enc_im, nil
+}
+
+// newEncodedGIF returns a new `EncodedImage` instance for the GIF image data in 'body' decoding every
+// frame so that animated GIFs can be distinguished from still images. The `Image` property is the first frame.
+func newEncodedGIF(ctx context.Context, body []byte) (*EncodedImage, error) {
+
+	g, err := gif.DecodeAll(bytes.NewReader(body))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode image, %w", err)
+	}
+
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("Failed to decode image, GIF contains no frames")
+	}
+
+	enc_im := &EncodedImage{
+		Image:       g.Image[0],
+		Body:        body,
+		ContentType: "image/gif",
+		Frames:      len(g.Image),
+	}
+
+	return enc_im, nil
+}
+
+// Animated returns a boolean value indicating whether 'im' is an animated (multi-frame) image.
+func (im *EncodedImage) Animated() bool {
+	return im.Frames > 1
+}
+
+// NewEncodedImageFromReader returns a new `EncodedImage` instance derived from the contents of 'r'.
+func NewEncodedImageFromReader(ctx context.Context, r io.Reader) (*EncodedImage, error) {
+
+	body, err := io.ReadAll(r)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read image, %w", err)
+	}
+
+	return NewEncodedImage(ctx, body, "")
+}
+
+// opaqueImage is implemented by the majority of the `image.Image` types in the standard library.
+type opaqueImage interface {
+	Opaque() bool
+}
+
+// encoderSchemeForImage returns the `aaronland/go-image-encode` scheme most suitable for encoding 'im':
+// "gif" for paletted images, "png" for images with transparency and "jpeg" for everything else.
+func encoderSchemeForImage(im image.Image) string {
+
+	switch im.(type) {
+	case *image.Paletted:
+		return "gif"
+	default:
+		// pass
+	}
+
+	if o, ok := im.(opaqueImage); ok && !o.Opaque() {
+		return "png"
+	}
+
+	return "jpeg"
+}
+
+// newDefaultEncoders returns a lookup table of `encode.Encoder` instances, keyed by scheme, used to encode
+// images that are not `EncodedImage` instances.
+func newDefaultEncoders(ctx context.Context) (map[string]encode.Encoder, error) {
+
+	encoders := make(map[string]encode.Encoder)
+
+	for _, scheme := range []string{"jpeg", "png", "gif"} {
+
+		enc, err := newImageEncoder(ctx, fmt.Sprintf("%s://", scheme))
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create %s encoder, %w", scheme, err)
+		}
+
+		encoders[scheme] = enc
+	}
+
+	return encoders, nil
+}
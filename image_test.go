@@ -0,0 +1,148 @@
+package twitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"log"
+	"testing"
+)
+
+func newTestGIF(t *testing.T, width int, height int, frames int) []byte {
+
+	t.Helper()
+
+	palette := color.Palette{color.White, color.Black}
+	g := &gif.GIF{}
+
+	for i := 0; i < frames; i++ {
+
+		im := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+		im.SetColorIndex(i%width, 0, 1)
+
+		g.Image = append(g.Image, im)
+		g.Delay = append(g.Delay, 10)
+	}
+
+	buf := new(bytes.Buffer)
+
+	err := gif.EncodeAll(buf, g)
+
+	if err != nil {
+		t.Fatalf("Failed to encode GIF, %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestNewEncodedImage(t *testing.T) {
+
+	ctx := context.Background()
+
+	buf := new(bytes.Buffer)
+
+	err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 10, 20)))
+
+	if err != nil {
+		t.Fatalf("Failed to encode PNG, %v", err)
+	}
+
+	enc_im, err := NewEncodedImage(ctx, buf.Bytes(), "")
+
+	if err != nil {
+		t.Fatalf("Failed to create encoded image, %v", err)
+	}
+
+	if enc_im.ContentType != "image/png" {
+		t.Fatalf("Unexpected content type '%s'", enc_im.ContentType)
+	}
+
+	if enc_im.Bounds().Dx() != 10 || enc_im.Bounds().Dy() != 20 {
+		t.Fatalf("Unexpected bounds %v", enc_im.Bounds())
+	}
+
+	if enc_im.Animated() {
+		t.Fatalf("Expected PNG image not to be animated")
+	}
+
+	_, err = NewEncodedImage(ctx, []byte("hello world"), "text/plain")
+
+	if err == nil {
+		t.Fatalf("Expected non-image content type to fail")
+	}
+}
+
+func TestNewEncodedImageAnimatedGIF(t *testing.T) {
+
+	ctx := context.Background()
+
+	body := newTestGIF(t, 10, 10, 3)
+
+	enc_im, err := NewEncodedImage(ctx, body, "")
+
+	if err != nil {
+		t.Fatalf("Failed to create encoded image, %v", err)
+	}
+
+	if enc_im.ContentType != "image/gif" {
+		t.Fatalf("Unexpected content type '%s'", enc_im.ContentType)
+	}
+
+	if enc_im.Frames != 3 || !enc_im.Animated() {
+		t.Fatalf("Expected animated GIF with 3 frames, got %d", enc_im.Frames)
+	}
+
+	if !bytes.Equal(enc_im.Body, body) {
+		t.Fatalf("Expected GIF to be left untouched")
+	}
+}
+
+func TestEncoderSchemeForImage(t *testing.T) {
+
+	palette := color.Palette{color.White, color.Black}
+
+	tests := map[string]image.Image{
+		"gif":  image.NewPaletted(image.Rect(0, 0, 10, 10), palette),
+		"png":  image.NewRGBA(image.Rect(0, 0, 10, 10)),
+		"jpeg": image.NewGray(image.Rect(0, 0, 10, 10)),
+	}
+
+	for expected, im := range tests {
+
+		scheme := encoderSchemeForImage(im)
+
+		if scheme != expected {
+			t.Fatalf("Expected %T to be encoded as %s, got %s", im, expected, scheme)
+		}
+	}
+}
+
+func TestUploadImageOversizedAnimatedGIF(t *testing.T) {
+
+	ctx := context.Background()
+
+	limits, _ := LimitsForContentType("image/gif")
+
+	enc_im, err := NewEncodedImage(ctx, newTestGIF(t, limits.MaxWidth+10, 10, 2), "")
+
+	if err != nil {
+		t.Fatalf("Failed to create encoded image, %v", err)
+	}
+
+	b := &TwitterBroadcaster{
+		fit_images: true,
+		logger:     log.New(io.Discard, "", 0),
+	}
+
+	// A nil client ensures that the test fails loudly if an upload is attempted
+	_, err = b.uploadImage(ctx, nil, enc_im)
+
+	if !errors.Is(err, ErrMediaRejected) {
+		t.Fatalf("Expected ErrMediaRejected, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+// Package jsonfile provides methods for reading and atomically writing JSON-encoded files on the local filesystem.
+package jsonfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Read decodes the JSON-encoded contents of 'path' in to 'target'. If 'path' does not exist, or is empty,
+// 'target' is left unchanged.
+func Read(path string, target interface{}) error {
+
+	body, err := os.ReadFile(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed to read %s, %w", path, err)
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(body, target)
+
+	if err != nil {
+		return fmt.Errorf("Failed to unmarshal %s, %w", path, err)
+	}
+
+	return nil
+}
+
+// Write atomically replaces the contents of 'path' with the JSON encoding of 'v'. The encoded data is written to
+// a temporary file, in the same directory as 'path', which is then renamed.
+func Write(path string, v interface{}) error {
+
+	body, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("Failed to marshal %s, %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary file, %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(body)
+
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write temporary file, %w", err)
+	}
+
+	err = tmp.Close()
+
+	if err != nil {
+		return fmt.Errorf("Failed to close temporary file, %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+
+	if err != nil {
+		return fmt.Errorf("Failed to replace %s, %w", path, err)
+	}
+
+	return nil
+}
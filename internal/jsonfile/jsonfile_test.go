@@ -0,0 +1,70 @@
+package jsonfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "test.json")
+
+	values := map[string]int{"a": 1}
+
+	// Missing files leave the target unchanged
+
+	err := Read(path, &values)
+
+	if err != nil {
+		t.Fatalf("Failed to read missing file, %v", err)
+	}
+
+	if len(values) != 1 || values["a"] != 1 {
+		t.Fatalf("Expected values to be unchanged, got %v", values)
+	}
+
+	values["b"] = 2
+
+	err = Write(path, values)
+
+	if err != nil {
+		t.Fatalf("Failed to write %s, %v", path, err)
+	}
+
+	read_values := make(map[string]int)
+
+	err = Read(path, &read_values)
+
+	if err != nil {
+		t.Fatalf("Failed to read %s, %v", path, err)
+	}
+
+	if len(read_values) != 2 || read_values["b"] != 2 {
+		t.Fatalf("Unexpected values %v", read_values)
+	}
+
+	// Temporary files are removed
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+
+	if err != nil {
+		t.Fatalf("Failed to read directory, %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 file, got %d", len(entries))
+	}
+
+	err = os.WriteFile(path, []byte("{"), 0644)
+
+	if err != nil {
+		t.Fatalf("Failed to write %s, %v", path, err)
+	}
+
+	err = Read(path, &read_values)
+
+	if err == nil {
+		t.Fatalf("Expected invalid JSON to fail")
+	}
+}
@@ -0,0 +1,51 @@
+package twitter
+
+import (
+	"log"
+	"strings"
+	"sync"
+)
+
+// loggerWriter is an `io.Writer` which forwards every line written to it to a `log.Logger` instance that
+// can be replaced while other goroutines are logging. A `TwitterBroadcaster` (and its clients, credential
+// watcher and account broadcasters) log using a single `log.Logger` which writes to a `loggerWriter` so
+// that `SetLogger` never needs to modify a logger that is in use.
+type loggerWriter struct {
+	logger *log.Logger
+	mu     *sync.RWMutex
+}
+
+func newLoggerWriter(logger *log.Logger) *loggerWriter {
+
+	wr := &loggerWriter{
+		logger: logger,
+		mu:     new(sync.RWMutex),
+	}
+
+	return wr
+}
+
+// Write logs 'p' using the current `log.Logger` instance for 'wr'.
+func (wr *loggerWriter) Write(p []byte) (int, error) {
+
+	wr.mu.RLock()
+	logger := wr.logger
+	wr.mu.RUnlock()
+
+	err := logger.Output(2, strings.TrimSuffix(string(p), "\n"))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// setLogger replaces the `log.Logger` instance that 'wr' forwards lines to with 'logger'.
+func (wr *loggerWriter) setLogger(logger *log.Logger) {
+
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	wr.logger = logger
+}
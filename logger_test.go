@@ -0,0 +1,83 @@
+package twitter_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a `bytes.Buffer` which is safe to write to from multiple goroutines.
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSetLoggerConcurrent(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("mode", "dry-run")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < 4; i++ {
+
+		wg.Add(1)
+
+		go func(i int) {
+
+			defer wg.Done()
+
+			for j := 0; j < 10; j++ {
+
+				_, err := broadcast(t, br, fmt.Sprintf("hello world %d %d", i, j))
+
+				if err != nil {
+					t.Errorf("Failed to broadcast message, %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	for i := 0; i < 10; i++ {
+		br.SetLogger(ctx, log.New(new(syncBuffer), "", 0))
+	}
+
+	wg.Wait()
+
+	buf := new(syncBuffer)
+	br.SetLogger(ctx, log.New(buf, "[test] ", 0))
+
+	_, err := broadcast(t, br, "hello logger")
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "[test] [dry-run]") {
+		t.Fatalf("Expected messages to be logged with the current logger, got '%s'", buf.String())
+	}
+}
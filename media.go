@@ -0,0 +1,399 @@
+package twitter
+
+// https://developer.twitter.com/en/docs/twitter-api/v1/media/upload-media/overview
+// https://developer.twitter.com/en/docs/twitter-api/v1/media/upload-media/uploading-media/chunked-media-upload
+// https://developer.twitter.com/en/docs/twitter-api/v1/media/upload-media/api-reference/get-media-upload-status
+// https://developer.twitter.com/en/docs/twitter-api/v1/media/upload-media/api-reference/post-media-metadata-create
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MEDIA_CATEGORY_IMAGE is the Twitter media category for (static) images.
+const MEDIA_CATEGORY_IMAGE string = "tweet_image"
+
+// MEDIA_CATEGORY_GIF is the Twitter media category for (animated) GIFs.
+const MEDIA_CATEGORY_GIF string = "tweet_gif"
+
+// MEDIA_CATEGORY_VIDEO is the Twitter media category for videos.
+const MEDIA_CATEGORY_VIDEO string = "tweet_video"
+
+// DEFAULT_CHUNK_SIZE is the default number of bytes sent with each chunked upload APPEND request.
+const DEFAULT_CHUNK_SIZE int64 = 1024 * 1024
+
+// MAX_CHUNK_SIZE is the maximum number of bytes Twitter accepts in a single chunked upload APPEND request.
+const MAX_CHUNK_SIZE int64 = 5 * 1024 * 1024
+
+// SIMPLE_UPLOAD_MAX_BYTES is the maximum size of a file that can be sent using a simple (non-chunked) upload.
+const SIMPLE_UPLOAD_MAX_BYTES int64 = 5 * 1024 * 1024
+
+const (
+	processingStatePending    = "pending"
+	processingStateInProgress = "in_progress"
+	processingStateFailed     = "failed"
+	processingStateSucceeded  = "succeeded"
+)
+
+// MediaCategory returns the Twitter media category for 'content_type'.
+func MediaCategory(content_type string) string {
+
+	content_type = strings.ToLower(content_type)
+
+	switch {
+	case content_type == "image/gif":
+		return MEDIA_CATEGORY_GIF
+	case strings.HasPrefix(content_type, "video/"):
+		return MEDIA_CATEGORY_VIDEO
+	default:
+		return MEDIA_CATEGORY_IMAGE
+	}
+}
+
+type mediaUploadResponse struct {
+	MediaId          int64                `json:"media_id"`
+	MediaIdString    string               `json:"media_id_string"`
+	Size             int64                `json:"size"`
+	ExpiresAfterSecs int                  `json:"expires_after_secs"`
+	ProcessingInfo   *mediaProcessingInfo `json:"processing_info,omitempty"`
+}
+
+type mediaProcessingInfo struct {
+	State           string                `json:"state"`
+	CheckAfterSecs  int                   `json:"check_after_secs"`
+	ProgressPercent int                   `json:"progress_percent"`
+	Error           *mediaProcessingError `json:"error,omitempty"`
+}
+
+type mediaMetadataRequest struct {
+	MediaId string            `json:"media_id"`
+	AltText *mediaMetadataAlt `json:"alt_text"`
+}
+
+type mediaMetadataAlt struct {
+	Text string `json:"text"`
+}
+
+type mediaProcessingError struct {
+	Code    int    `json:"code"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// mediaUploader uploads media files using the Twitter media upload API. Files are sent using a simple
+// (single request) upload or a chunked (INIT/APPEND/FINALIZE/STATUS) upload depending on their size
+// and media category.
+type mediaUploader struct {
+	http_client *signedClient
+	upload_base string
+	chunk_size  int64
+}
+
+func newMediaUploader(ctx context.Context, http_client *signedClient, opts *clientOptions) (*mediaUploader, error) {
+
+	chunk_size := opts.ChunkSize
+
+	if chunk_size == 0 {
+		chunk_size = DEFAULT_CHUNK_SIZE
+	}
+
+	if chunk_size < 0 || chunk_size > MAX_CHUNK_SIZE {
+		return nil, fmt.Errorf("Invalid chunk size (%d), must be between 1 and %d bytes", chunk_size, MAX_CHUNK_SIZE)
+	}
+
+	u := &mediaUploader{
+		http_client: http_client,
+		upload_base: opts.uploadBase(),
+		chunk_size:  chunk_size,
+	}
+
+	return u, nil
+}
+
+// Upload uploads 'body' whose content type is 'content_type' and returns its media ID. Images
+// smaller than `SIMPLE_UPLOAD_MAX_BYTES` are sent using a simple upload; everything else (large images,
+// GIFs and videos) is sent using a chunked upload.
+func (u *mediaUploader) Upload(ctx context.Context, body []byte, content_type string) (string, error) {
+
+	category := MediaCategory(content_type)
+	size := int64(len(body))
+
+	if category == MEDIA_CATEGORY_IMAGE && size <= SIMPLE_UPLOAD_MAX_BYTES {
+		return u.uploadSimple(ctx, body, category)
+	}
+
+	return u.uploadChunked(ctx, bytes.NewReader(body), size, content_type, category)
+}
+
+// CreateMetadata associates 'alt_text' with the previously uploaded media 'media_id'.
+func (u *mediaUploader) CreateMetadata(ctx context.Context, media_id string, alt_text string) error {
+
+	md := &mediaMetadataRequest{
+		MediaId: media_id,
+		AltText: &mediaMetadataAlt{
+			Text: alt_text,
+		},
+	}
+
+	enc_md, err := json.Marshal(md)
+
+	if err != nil {
+		return fmt.Errorf("Failed to marshal metadata, %w", err)
+	}
+
+	uri := u.upload_base + "/1.1/media/metadata/create.json"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(enc_md))
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return u.http_client.do(req, nil, nil)
+}
+
+func (u *mediaUploader) endpoint() string {
+	return u.upload_base + "/1.1/media/upload.json"
+}
+
+func (u *mediaUploader) uploadSimple(ctx context.Context, body []byte, category string) (string, error) {
+
+	form := url.Values{}
+	form.Set("media_data", base64.StdEncoding.EncodeToString(body))
+	form.Set("media_category", category)
+
+	var rsp mediaUploadResponse
+
+	err := u.http_client.postForm(ctx, u.endpoint(), form, &rsp)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to upload media, %w", err)
+	}
+
+	if rsp.MediaIdString == "" {
+		return "", fmt.Errorf("Failed to upload media, response is missing media ID")
+	}
+
+	return rsp.MediaIdString, nil
+}
+
+// uploadChunked reads 'size' bytes from 'r' and uploads them in chunks of (up to) `u.chunk_size` bytes
+// after which it waits for Twitter to finish processing the upload.
+func (u *mediaUploader) uploadChunked(ctx context.Context, r io.Reader, size int64, content_type string, category string) (string, error) {
+
+	media_id, err := u.init(ctx, size, content_type, category)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to initialize chunked upload, %w", err)
+	}
+
+	buf := make([]byte, u.chunk_size)
+	segment := 0
+
+	for {
+
+		n, err := io.ReadFull(r, buf)
+
+		if n > 0 {
+
+			append_err := u.append(ctx, media_id, segment, buf[:n])
+
+			if append_err != nil {
+				return "", fmt.Errorf("Failed to append segment %d for media %s, %w", segment, media_id, append_err)
+			}
+
+			segment += 1
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("Failed to read media, %w", err)
+		}
+	}
+
+	info, err := u.finalize(ctx, media_id)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to finalize chunked upload for media %s, %w", media_id, err)
+	}
+
+	err = u.waitForProcessing(ctx, media_id, info)
+
+	if err != nil {
+		return "", err
+	}
+
+	return media_id, nil
+}
+
+func (u *mediaUploader) init(ctx context.Context, size int64, content_type string, category string) (string, error) {
+
+	form := url.Values{}
+	form.Set("command", "INIT")
+	form.Set("total_bytes", strconv.FormatInt(size, 10))
+	form.Set("media_type", content_type)
+	form.Set("media_category", category)
+
+	var rsp mediaUploadResponse
+
+	err := u.http_client.postForm(ctx, u.endpoint(), form, &rsp)
+
+	if err != nil {
+		return "", err
+	}
+
+	if rsp.MediaIdString == "" {
+		return "", fmt.Errorf("Response is missing media ID")
+	}
+
+	return rsp.MediaIdString, nil
+}
+
+// append sends 'chunk' as a multipart/form-data request. Per the Twitter documentation the parameters
+// in a multipart request body are not included when calculating the request's OAuth1 signature.
+func (u *mediaUploader) append(ctx context.Context, media_id string, segment int, chunk []byte) error {
+
+	body := new(bytes.Buffer)
+	wr := multipart.NewWriter(body)
+
+	fields := map[string]string{
+		"command":       "APPEND",
+		"media_id":      media_id,
+		"segment_index": strconv.Itoa(segment),
+	}
+
+	for k, v := range fields {
+
+		err := wr.WriteField(k, v)
+
+		if err != nil {
+			return fmt.Errorf("Failed to write %s field, %w", k, err)
+		}
+	}
+
+	part_wr, err := wr.CreateFormFile("media", "blob")
+
+	if err != nil {
+		return fmt.Errorf("Failed to create media part, %w", err)
+	}
+
+	_, err = part_wr.Write(chunk)
+
+	if err != nil {
+		return fmt.Errorf("Failed to write media part, %w", err)
+	}
+
+	err = wr.Close()
+
+	if err != nil {
+		return fmt.Errorf("Failed to close multipart writer, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.endpoint(), body)
+
+	if err != nil {
+		return fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", wr.FormDataContentType())
+
+	return u.http_client.do(req, nil, nil)
+}
+
+func (u *mediaUploader) finalize(ctx context.Context, media_id string) (*mediaProcessingInfo, error) {
+
+	form := url.Values{}
+	form.Set("command", "FINALIZE")
+	form.Set("media_id", media_id)
+
+	var rsp mediaUploadResponse
+
+	err := u.http_client.postForm(ctx, u.endpoint(), form, &rsp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp.ProcessingInfo, nil
+}
+
+func (u *mediaUploader) status(ctx context.Context, media_id string) (*mediaProcessingInfo, error) {
+
+	query := url.Values{}
+	query.Set("command", "STATUS")
+	query.Set("media_id", media_id)
+
+	var rsp mediaUploadResponse
+
+	err := u.http_client.get(ctx, u.endpoint(), query, &rsp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp.ProcessingInfo, nil
+}
+
+// waitForProcessing polls the STATUS command for 'media_id' until its processing state is "succeeded"
+// or "failed", waiting for the number of seconds specified by Twitter's `check_after_secs` property
+// between each request. A nil 'info' value means that the media does not require any processing.
+func (u *mediaUploader) waitForProcessing(ctx context.Context, media_id string, info *mediaProcessingInfo) error {
+
+	for info != nil {
+
+		switch info.State {
+		case processingStateSucceeded:
+			return nil
+		case processingStateFailed:
+
+			if info.Error != nil {
+				return &Error{Kind: ErrMediaRejected, Err: fmt.Errorf("Failed to process media %s, %s (%d): %s", media_id, info.Error.Name, info.Error.Code, info.Error.Message)}
+			}
+
+			return &Error{Kind: ErrMediaRejected, Err: fmt.Errorf("Failed to process media %s", media_id)}
+
+		case processingStatePending, processingStateInProgress:
+			// pass
+		default:
+			return fmt.Errorf("Unknown processing state for media %s, '%s'", media_id, info.State)
+		}
+
+		wait := time.Duration(info.CheckAfterSecs) * time.Second
+
+		if wait <= 0 {
+			wait = time.Second
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+			// pass
+		}
+
+		next, err := u.status(ctx, media_id)
+
+		if err != nil {
+			return fmt.Errorf("Failed to retrieve status for media %s, %w", media_id, err)
+		}
+
+		info = next
+	}
+
+	return nil
+}
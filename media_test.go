@@ -0,0 +1,77 @@
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestMediaUploadEmptyResponse(t *testing.T) {
+
+	ctx := context.Background()
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+		rsp.WriteHeader(http.StatusOK)
+	})
+
+	_, err := c.UploadMedia(ctx, []byte("png"), "image/png")
+
+	if err == nil {
+		t.Fatalf("Expected simple upload to fail with an empty response")
+	}
+
+	_, err = c.UploadMedia(ctx, []byte("mp4"), "video/mp4")
+
+	if err == nil {
+		t.Fatalf("Expected chunked upload to fail with an empty response")
+	}
+}
+
+func TestMediaUploadChunked(t *testing.T) {
+
+	ctx := context.Background()
+
+	commands := make([]string, 0)
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+
+		command := req.FormValue("command")
+		commands = append(commands, command)
+
+		switch command {
+		case "INIT":
+			rsp.Write([]byte(`{"media_id_string":"5678"}`))
+		case "APPEND":
+			rsp.WriteHeader(http.StatusNoContent)
+		case "FINALIZE":
+			// An empty response means there is no processing_info so there is nothing to wait for
+			rsp.WriteHeader(http.StatusOK)
+		default:
+			http.Error(rsp, "Bad request", http.StatusBadRequest)
+		}
+	})
+
+	c.uploader.chunk_size = 2
+
+	media_id, err := c.UploadMedia(ctx, []byte("abcde"), "video/mp4")
+
+	if err != nil {
+		t.Fatalf("Failed to upload media, %v", err)
+	}
+
+	if media_id != "5678" {
+		t.Fatalf("Unexpected media ID '%s'", media_id)
+	}
+
+	expected := []string{"INIT", "APPEND", "APPEND", "APPEND", "FINALIZE"}
+
+	if len(commands) != len(expected) {
+		t.Fatalf("Unexpected commands %v", commands)
+	}
+
+	for i, cmd := range expected {
+		if commands[i] != cmd {
+			t.Fatalf("Unexpected command at %d, expected %s but got %s", i, cmd, commands[i])
+		}
+	}
+}
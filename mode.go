@@ -0,0 +1,25 @@
+package twitter
+
+// MODE_LIVE is the `?mode=` option which publishes messages as-is (the default).
+const MODE_LIVE string = "live"
+
+// MODE_TEST is the `?mode=` option which publishes messages prefixed with the value of `?test-prefix=`.
+const MODE_TEST string = "test"
+
+// MODE_DRYRUN is the `?mode=` option which verifies credentials and prepares and validates messages, including their
+// images, but logs the requests that would have published (or deleted) something rather than sending them.
+const MODE_DRYRUN string = "dry-run"
+
+// DEFAULT_TEST_PREFIX is the default string prepended to messages published using the "test" mode.
+const DEFAULT_TEST_PREFIX string = "this is a test and there may be more / please disregard and apologies for the distraction / meanwhile:"
+
+// isValidMode returns a boolean value indicating whether 'mode' is a known broadcast mode.
+func isValidMode(mode string) bool {
+
+	switch mode {
+	case MODE_LIVE, MODE_TEST, MODE_DRYRUN:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,151 @@
+package twitter
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aaronland/go-broadcaster"
+)
+
+// newDryRunBroadcaster returns a new "dry-run" `TwitterBroadcaster` instance configured by 'params' which logs
+// to 'buf'.
+func newDryRunBroadcaster(t *testing.T, params url.Values, buf *bytes.Buffer) *TwitterBroadcaster {
+
+	t.Helper()
+
+	ctx := context.Background()
+
+	q := url.Values{}
+	q.Set("credentials", `constant://?val={"consumer_key":"a","consumer_secret":"b","access_token":"c","access_secret":"d"}`)
+	q.Set("mode", MODE_DRYRUN)
+	q.Set("verify", VERIFY_NEVER)
+
+	for k, v := range params {
+		q[k] = v
+	}
+
+	br, err := NewTwitterBroadcaster(ctx, "twitter://?"+q.Encode())
+
+	if err != nil {
+		t.Fatalf("Failed to create broadcaster, %v", err)
+	}
+
+	tw_br := br.(*TwitterBroadcaster)
+	tw_br.SetLogger(ctx, log.New(buf, "", 0))
+
+	return tw_br
+}
+
+func TestIsValidMode(t *testing.T) {
+
+	for _, mode := range []string{MODE_LIVE, MODE_TEST, MODE_DRYRUN} {
+
+		if !isValidMode(mode) {
+			t.Fatalf("Expected '%s' to be a valid mode", mode)
+		}
+	}
+
+	if isValidMode("debug") {
+		t.Fatalf("Expected 'debug' to be an invalid mode")
+	}
+}
+
+func TestNewTwitterBroadcasterInvalidMode(t *testing.T) {
+
+	ctx := context.Background()
+
+	invalid := []string{
+		"mode=debug",
+		"mode=live&test-prefix=test",
+		"test-prefix=test",
+	}
+
+	for _, q := range invalid {
+
+		params, _ := url.ParseQuery(q)
+		params.Set("credentials", `constant://?val={"consumer_key":"a","consumer_secret":"b","access_token":"c","access_secret":"d"}`)
+
+		_, err := NewTwitterBroadcaster(ctx, "twitter://?"+params.Encode())
+
+		if err == nil {
+			t.Fatalf("Expected '%s' to fail", q)
+		}
+	}
+}
+
+func TestBroadcastDryRun(t *testing.T) {
+
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+
+	br := newDryRunBroadcaster(t, nil, &buf)
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+	}
+
+	u, err := br.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message in dry-run mode, %v", err)
+	}
+
+	if !strings.HasPrefix(u.String(), DRYRUN_ID_PREFIX) {
+		t.Fatalf("Expected synthetic dry-run UID, got %s", u)
+	}
+
+	if !strings.Contains(buf.String(), "POST "+DEFAULT_API_BASE+"/2/tweets") {
+		t.Fatalf("Expected tweet to be logged, got %s", buf.String())
+	}
+
+	// Messages are still validated in dry-run mode
+
+	msg.Body = strings.Repeat("a", MAX_STATUS_LENGTH+1)
+
+	_, err = br.BroadcastMessage(ctx, msg)
+
+	if err == nil {
+		t.Fatalf("Expected over-length message to fail in dry-run mode")
+	}
+}
+
+func TestBroadcastTestMode(t *testing.T) {
+
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+
+	br := newDryRunBroadcaster(t, nil, &buf)
+
+	// The "test" mode publishes live messages so use the dry-run client to record them instead
+
+	br.mode = MODE_TEST
+	br.test_prefix = "[test]"
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+	}
+
+	_, err := br.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message in test mode, %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `"text":"[test] hello world"`) {
+		t.Fatalf("Expected tweet with the test prefix, got %s", buf.String())
+	}
+
+	msg.Body = strings.Repeat("a", MAX_STATUS_LENGTH-5)
+
+	_, err = br.BroadcastMessage(ctx, msg)
+
+	if err == nil {
+		t.Fatalf("Expected the test prefix to count towards the maximum status length")
+	}
+}
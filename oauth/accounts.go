@@ -0,0 +1,89 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// re_account_name is the pattern that account names in an `AccountsCredentials` document must match. Names are
+// used in the string representation of UIDs so they may not contain whitespace, "#" or "," characters.
+var re_account_name = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.\-]*$`)
+
+// AccountsCredentials is a struct containing the credentials for several named accounts. It is encoded as a JSON
+// document whose "accounts" property maps each account name to an `OAuth1Credentials` or `OAuth2Credentials` document,
+// for example:
+//
+//	{"accounts": {"sfo": {"consumer_key": ...}, "sfomuseum": {"client_id": ...}}}
+type AccountsCredentials struct {
+	// Accounts maps account names to their credentials.
+	Accounts map[string]Credentials `json:"accounts"`
+}
+
+// NewAccountsCredentialsFromString derives a `AccountsCredentials` struct from a JSON-encoded string. The credentials
+// for each account are parsed, and validated, using `NewCredentialsFromString`.
+func NewAccountsCredentialsFromString(ctx context.Context, str_creds string) (*AccountsCredentials, error) {
+
+	var doc *struct {
+		Accounts map[string]json.RawMessage `json:"accounts"`
+	}
+
+	err := json.Unmarshal([]byte(str_creds), &doc)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal credentials, %w", err)
+	}
+
+	if doc == nil {
+		return nil, fmt.Errorf("%w, expected a JSON object", ErrInvalidCredentials)
+	}
+
+	if len(doc.Accounts) == 0 {
+		return nil, fmt.Errorf("%w, document does not contain any accounts", ErrInvalidCredentials)
+	}
+
+	creds := &AccountsCredentials{
+		Accounts: make(map[string]Credentials),
+	}
+
+	for name, raw := range doc.Accounts {
+
+		if !re_account_name.MatchString(name) {
+			return nil, fmt.Errorf("%w, invalid account name '%s'", ErrInvalidCredentials, name)
+		}
+
+		account_creds, err := NewCredentialsFromString(ctx, string(raw))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid credentials for account '%s', %w", name, err)
+		}
+
+		if account_creds.CredentialsType() == CREDENTIALS_ACCOUNTS {
+			return nil, fmt.Errorf("%w, account '%s' can not contain other accounts", ErrInvalidCredentials, name)
+		}
+
+		creds.Accounts[name] = account_creds
+	}
+
+	return creds, nil
+}
+
+// CredentialsType returns `CREDENTIALS_ACCOUNTS`.
+func (c *AccountsCredentials) CredentialsType() string {
+	return CREDENTIALS_ACCOUNTS
+}
+
+// Names returns the (sorted) list of account names in 'c'.
+func (c *AccountsCredentials) Names() []string {
+
+	names := make([]string, 0, len(c.Accounts))
+
+	for name := range c.Accounts {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
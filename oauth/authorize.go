@@ -0,0 +1,152 @@
+package oauth
+
+// https://developer.twitter.com/en/docs/authentication/oauth-1-0a/obtaining-user-access-tokens
+// https://developer.twitter.com/en/docs/authentication/oauth-1-0a/pin-based-oauth
+// https://www.rfc-editor.org/rfc/rfc5849#section-2
+
+import (
+	"context"
+	"fmt"
+	oauth1 "github.com/garyburd/go-oauth/oauth"
+	"net/http"
+	"strings"
+)
+
+// DEFAULT_AUTHORIZE_BASE is the default base URL for the OAuth1 request token, authorize and access token endpoints.
+const DEFAULT_AUTHORIZE_BASE string = "https://api.twitter.com"
+
+// OOB_CALLBACK is the callback used to request a PIN (out-of-band) verifier rather than redirecting to a callback URL.
+const OOB_CALLBACK string = "oob"
+
+// OAuth1RequestToken is a temporary (request) token issued during the OAuth1 3-legged authorization flow.
+type OAuth1RequestToken struct {
+	// Token is the request token.
+	Token string
+	// Secret is the request token secret.
+	Secret string
+}
+
+// OAuth1AuthorizerOptions defines configuration options for creating a new `OAuth1Authorizer` instance.
+type OAuth1AuthorizerOptions struct {
+	// HTTPClient is the `http.Client` used to request tokens. If nil `http.DefaultClient` is used.
+	HTTPClient *http.Client
+	// Base is the base URL for the request token ("/oauth/request_token"), authorize ("/oauth/authorize") and
+	// access token ("/oauth/access_token") endpoints. If empty `DEFAULT_AUTHORIZE_BASE` is used.
+	Base string
+}
+
+// OAuth1Authorizer implements the OAuth1 3-legged authorization flow used to obtain an access token and secret
+// for a user (account) on behalf of an application.
+type OAuth1Authorizer struct {
+	oauth_client    *oauth1.Client
+	http_client     *http.Client
+	consumer_key    string
+	consumer_secret string
+}
+
+// NewOAuth1Authorizer returns a new `OAuth1Authorizer` instance for the application whose consumer key and secret
+// are 'consumer_key' and 'consumer_secret', configured by 'opts' (which may be nil).
+func NewOAuth1Authorizer(ctx context.Context, consumer_key string, consumer_secret string, opts *OAuth1AuthorizerOptions) (*OAuth1Authorizer, error) {
+
+	if consumer_key == "" || consumer_secret == "" {
+		return nil, fmt.Errorf("Missing consumer key or secret")
+	}
+
+	if opts == nil {
+		opts = &OAuth1AuthorizerOptions{}
+	}
+
+	http_client := opts.HTTPClient
+
+	if http_client == nil {
+		http_client = http.DefaultClient
+	}
+
+	base := strings.TrimSuffix(opts.Base, "/")
+
+	if base == "" {
+		base = DEFAULT_AUTHORIZE_BASE
+	}
+
+	oauth_client := &oauth1.Client{
+		TemporaryCredentialRequestURI: base + "/oauth/request_token",
+		ResourceOwnerAuthorizationURI: base + "/oauth/authorize",
+		TokenRequestURI:               base + "/oauth/access_token",
+		Credentials: oauth1.Credentials{
+			Token:  consumer_key,
+			Secret: consumer_secret,
+		},
+	}
+
+	a := &OAuth1Authorizer{
+		oauth_client:    oauth_client,
+		http_client:     http_client,
+		consumer_key:    consumer_key,
+		consumer_secret: consumer_secret,
+	}
+
+	return a, nil
+}
+
+// RequestToken requests a new (temporary) request token. 'callback' is the URL the user will be redirected to,
+// with "oauth_token" and "oauth_verifier" query parameters, once they have authorized the application or `OOB_CALLBACK`
+// if the user should be shown a PIN instead.
+func (a *OAuth1Authorizer) RequestToken(ctx context.Context, callback string) (*OAuth1RequestToken, error) {
+
+	ctx = context.WithValue(ctx, oauth1.HTTPClient, a.http_client)
+
+	creds, err := a.oauth_client.RequestTemporaryCredentialsContext(ctx, callback, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to request token, %w", err)
+	}
+
+	t := &OAuth1RequestToken{
+		Token:  creds.Token,
+		Secret: creds.Secret,
+	}
+
+	return t, nil
+}
+
+// AuthorizationURL returns the URL the user needs to visit to authorize the application for 't'.
+func (a *OAuth1Authorizer) AuthorizationURL(t *OAuth1RequestToken) string {
+
+	creds := &oauth1.Credentials{
+		Token:  t.Token,
+		Secret: t.Secret,
+	}
+
+	return a.oauth_client.AuthorizationURL(creds, nil)
+}
+
+// AccessToken exchanges the request token 't' and 'verifier' (the PIN, or the "oauth_verifier" parameter passed
+// to the callback URL) for an access token and secret and returns the complete `OAuth1Credentials` for the user.
+func (a *OAuth1Authorizer) AccessToken(ctx context.Context, t *OAuth1RequestToken, verifier string) (*OAuth1Credentials, error) {
+
+	if verifier == "" {
+		return nil, fmt.Errorf("Missing verifier")
+	}
+
+	ctx = context.WithValue(ctx, oauth1.HTTPClient, a.http_client)
+
+	req_creds := &oauth1.Credentials{
+		Token:  t.Token,
+		Secret: t.Secret,
+	}
+
+	access_creds, _, err := a.oauth_client.RequestTokenContext(ctx, req_creds, strings.TrimSpace(verifier))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to request access token, %w", err)
+	}
+
+	creds := &OAuth1Credentials{
+		ConsumerKey:    a.consumer_key,
+		ConsumerSecret: a.consumer_secret,
+		AccessToken:    access_creds.Token,
+		AccessSecret:   access_creds.Secret,
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,126 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTestAuthorizeServer(t *testing.T) *httptest.Server {
+
+	t.Helper()
+
+	s := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+
+		auth := req.Header.Get("Authorization")
+
+		if !strings.Contains(auth, `oauth_consumer_key="consumer-key"`) {
+			http.Error(rsp, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch req.URL.Path {
+		case "/oauth/request_token":
+
+			if !strings.Contains(auth, `oauth_callback="oob"`) {
+				http.Error(rsp, "Missing callback", http.StatusBadRequest)
+				return
+			}
+
+			rsp.Write([]byte("oauth_token=request-token&oauth_token_secret=request-secret&oauth_callback_confirmed=true"))
+
+		case "/oauth/access_token":
+
+			if !strings.Contains(auth, `oauth_token="request-token"`) || !strings.Contains(auth, `oauth_verifier="1234567"`) {
+				http.Error(rsp, "Invalid verifier", http.StatusUnauthorized)
+				return
+			}
+
+			rsp.Write([]byte("oauth_token=access-token&oauth_token_secret=access-secret&user_id=1234&screen_name=example"))
+
+		default:
+			http.NotFound(rsp, req)
+		}
+	}))
+
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestOAuth1Authorizer(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestAuthorizeServer(t)
+
+	opts := &OAuth1AuthorizerOptions{
+		HTTPClient: s.Client(),
+		Base:       s.URL + "/",
+	}
+
+	a, err := NewOAuth1Authorizer(ctx, "consumer-key", "consumer-secret", opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create authorizer, %v", err)
+	}
+
+	req_token, err := a.RequestToken(ctx, OOB_CALLBACK)
+
+	if err != nil {
+		t.Fatalf("Failed to request token, %v", err)
+	}
+
+	if req_token.Token != "request-token" || req_token.Secret != "request-secret" {
+		t.Fatalf("Unexpected request token %v", req_token)
+	}
+
+	auth_url, err := url.Parse(a.AuthorizationURL(req_token))
+
+	if err != nil {
+		t.Fatalf("Failed to parse authorization URL, %v", err)
+	}
+
+	if auth_url.Path != "/oauth/authorize" || auth_url.Query().Get("oauth_token") != "request-token" {
+		t.Fatalf("Unexpected authorization URL %s", auth_url)
+	}
+
+	_, err = a.AccessToken(ctx, req_token, "")
+
+	if err == nil {
+		t.Fatalf("Expected missing verifier to fail")
+	}
+
+	_, err = a.AccessToken(ctx, req_token, "7654321")
+
+	if err == nil {
+		t.Fatalf("Expected invalid verifier to fail")
+	}
+
+	creds, err := a.AccessToken(ctx, req_token, " 1234567\n")
+
+	if err != nil {
+		t.Fatalf("Failed to request access token, %v", err)
+	}
+
+	if creds.ConsumerKey != "consumer-key" || creds.ConsumerSecret != "consumer-secret" || creds.AccessToken != "access-token" || creds.AccessSecret != "access-secret" {
+		t.Fatalf("Unexpected credentials %v", creds)
+	}
+
+	err = creds.Validate()
+
+	if err != nil {
+		t.Fatalf("Expected credentials to be valid, %v", err)
+	}
+}
+
+func TestNewOAuth1AuthorizerInvalid(t *testing.T) {
+
+	_, err := NewOAuth1Authorizer(context.Background(), "consumer-key", "", nil)
+
+	if err == nil {
+		t.Fatalf("Expected missing consumer secret to fail")
+	}
+}
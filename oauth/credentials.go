@@ -0,0 +1,127 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// CREDENTIALS_OAUTH1 is the type of `OAuth1Credentials` instances.
+const CREDENTIALS_OAUTH1 string = "oauth1"
+
+// CREDENTIALS_OAUTH2 is the type of `OAuth2Credentials` instances.
+const CREDENTIALS_OAUTH2 string = "oauth2"
+
+// CREDENTIALS_ACCOUNTS is the type of `AccountsCredentials` instances.
+const CREDENTIALS_ACCOUNTS string = "accounts"
+
+// Credentials is an interface implemented by `OAuth1Credentials`, `OAuth2Credentials` and `AccountsCredentials`.
+type Credentials interface {
+	// CredentialsType returns the type of the credentials, one of `CREDENTIALS_OAUTH1`, `CREDENTIALS_OAUTH2` or `CREDENTIALS_ACCOUNTS`.
+	CredentialsType() string
+}
+
+// CredentialsType returns `CREDENTIALS_OAUTH1`.
+func (c *OAuth1Credentials) CredentialsType() string {
+	return CREDENTIALS_OAUTH1
+}
+
+// NewCredentialsFromString derives a `OAuth1Credentials`, `OAuth2Credentials` or `AccountsCredentials` struct from a
+// JSON-encoded string. Documents with an "accounts" property are `AccountsCredentials`. Otherwise the
+// flavour of the credentials is determined by an explicit "type" property ("oauth1" or "oauth2"), if present, or otherwise
+// by the properties in the document: OAuth1 credentials have "consumer_key", "consumer_secret" or "access_token_secret"
+// properties (or one of their alternate names, see `NewOAuth1CredentialsFromString`) while OAuth2 credentials have
+// "client_id", "refresh_token" or "expires_at" properties. The credentials are validated before they are returned.
+func NewCredentialsFromString(ctx context.Context, str_creds string) (Credentials, error) {
+
+	var doc map[string]interface{}
+
+	err := json.Unmarshal([]byte(str_creds), &doc)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal credentials, %w", err)
+	}
+
+	if doc == nil {
+		return nil, fmt.Errorf("%w, expected a JSON object", ErrInvalidCredentials)
+	}
+
+	creds_type, err := detectCredentialsType(doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch creds_type {
+	case CREDENTIALS_ACCOUNTS:
+		return NewAccountsCredentialsFromString(ctx, str_creds)
+	case CREDENTIALS_OAUTH1:
+		return NewOAuth1CredentialsFromString(ctx, str_creds)
+	default:
+		return NewOAuth2CredentialsFromString(ctx, str_creds)
+	}
+}
+
+// detectCredentialsType returns the type of the credentials in 'doc'.
+func detectCredentialsType(doc map[string]interface{}) (string, error) {
+
+	if _, ok := doc["accounts"]; ok {
+
+		if len(doc) > 1 {
+			return "", fmt.Errorf("Ambiguous credentials, document contains \"accounts\" and other properties")
+		}
+
+		return CREDENTIALS_ACCOUNTS, nil
+	}
+
+	if v, ok := doc["type"]; ok {
+
+		creds_type, _ := v.(string)
+
+		switch creds_type {
+		case CREDENTIALS_OAUTH1, CREDENTIALS_OAUTH2:
+			return creds_type, nil
+		default:
+			return "", fmt.Errorf("Invalid credentials type '%v'", v)
+		}
+	}
+
+	normalized := make(map[string]bool)
+
+	for k := range doc {
+		normalized[normalizeFieldName(k)] = true
+	}
+
+	has := func(keys ...string) bool {
+
+		for _, k := range keys {
+
+			if normalized[normalizeFieldName(k)] {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	oauth1_keys := make([]string, 0)
+
+	for _, name := range []string{"consumer_key", "consumer_secret", "access_token_secret"} {
+		oauth1_keys = append(oauth1_keys, name)
+		oauth1_keys = append(oauth1_keys, oauth1Aliases[name]...)
+	}
+
+	is_oauth1 := has(oauth1_keys...)
+	is_oauth2 := has("client_id", "refresh_token", "expires_at")
+
+	switch {
+	case is_oauth1 && is_oauth2:
+		return "", fmt.Errorf("Ambiguous credentials, document contains both OAuth1 and OAuth2 properties")
+	case is_oauth1:
+		return CREDENTIALS_OAUTH1, nil
+	case is_oauth2:
+		return CREDENTIALS_OAUTH2, nil
+	default:
+		return "", fmt.Errorf("Unable to determine credentials type, document contains neither OAuth1 nor OAuth2 properties")
+	}
+}
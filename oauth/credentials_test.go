@@ -0,0 +1,153 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestTokenServer(t *testing.T) *httptest.Server {
+
+	t.Helper()
+
+	refreshes := 0
+
+	s := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+
+		if req.FormValue("grant_type") != "refresh_token" {
+			http.Error(rsp, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+
+		refreshes += 1
+
+		rsp.Header().Set("Content-Type", "application/json")
+		rsp.Write([]byte(fmt.Sprintf(`{"token_type":"bearer","expires_in":7200,"access_token":"access-%d","refresh_token":"refresh-%d"}`, refreshes, refreshes)))
+	}))
+
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestOAuth2Credentials() *OAuth2Credentials {
+
+	creds := &OAuth2Credentials{
+		ClientId:     "client-id",
+		AccessToken:  "access-0",
+		RefreshToken: "refresh-0",
+		ExpiresAt:    time.Now().Add(-1 * time.Minute),
+	}
+
+	return creds
+}
+
+func TestNewCredentialsFromString(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string]string{
+		`{"consumer_key":"a","consumer_secret":"b","access_token":"c","access_token_secret":"d"}`:                 CREDENTIALS_OAUTH1,
+		`{"client_id":"a","access_token":"b","refresh_token":"c"}`:                                                CREDENTIALS_OAUTH2,
+		`{"accounts":{"one":{"client_id":"a","access_token":"b"}}}`:                                               CREDENTIALS_ACCOUNTS,
+		`{"type":"oauth2","access_token":"b","client_id":"a"}`:                                                    CREDENTIALS_OAUTH2,
+		`{"type":"oauth1","consumer_key":"a","consumer_secret":"b","access_token":"c","access_token_secret":"d"}`: CREDENTIALS_OAUTH1,
+	}
+
+	for str_creds, expected := range tests {
+
+		creds, err := NewCredentialsFromString(ctx, str_creds)
+
+		if err != nil {
+			t.Fatalf("Failed to parse %s, %v", str_creds, err)
+		}
+
+		if creds.CredentialsType() != expected {
+			t.Fatalf("Expected %s to be %s credentials, got %s", str_creds, expected, creds.CredentialsType())
+		}
+	}
+
+	invalid := []string{
+		`null`,
+		`[]`,
+		`{"type":"oauth3","client_id":"a","access_token":"b"}`,
+		`{"accounts":{},"client_id":"a"}`,
+		`{"client_id":"a"}`,
+	}
+
+	for _, str_creds := range invalid {
+
+		_, err := NewCredentialsFromString(ctx, str_creds)
+
+		if err == nil {
+			t.Fatalf("Expected %s to be invalid", str_creds)
+		}
+	}
+
+	_, err := NewOAuth2CredentialsFromString(ctx, `{"client_id":"a"}`)
+
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestOAuth2CredentialsExpired(t *testing.T) {
+
+	creds := &OAuth2Credentials{
+		ClientId:    "client-id",
+		AccessToken: "access-token",
+	}
+
+	if creds.Expired(time.Hour) {
+		t.Fatalf("Expected credentials without an expiry time never to expire")
+	}
+
+	creds.ExpiresAt = time.Now().Add(30 * time.Minute)
+
+	if creds.Expired(time.Minute) {
+		t.Fatalf("Expected credentials not to have expired")
+	}
+
+	if !creds.Expired(time.Hour) {
+		t.Fatalf("Expected credentials to expire within the margin")
+	}
+
+	creds.ExpiresAt = time.Now().Add(-1 * time.Minute)
+
+	if !creds.Expired(0) {
+		t.Fatalf("Expected credentials to have expired")
+	}
+}
+
+func TestOAuth2CredentialsRefresh(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestTokenServer(t)
+	creds := newTestOAuth2Credentials()
+
+	refreshed, err := creds.Refresh(ctx, s.Client(), s.URL)
+
+	if err != nil {
+		t.Fatalf("Failed to refresh credentials, %v", err)
+	}
+
+	if refreshed.AccessToken != "access-1" || refreshed.RefreshToken != "refresh-1" || refreshed.ClientId != creds.ClientId {
+		t.Fatalf("Unexpected credentials %v", refreshed)
+	}
+
+	if refreshed.Expired(time.Hour) || !refreshed.Expired(3*time.Hour) {
+		t.Fatalf("Expected refreshed credentials to expire in two hours")
+	}
+
+	creds.RefreshToken = ""
+
+	_, err = creds.Refresh(ctx, s.Client(), s.URL)
+
+	if err == nil {
+		t.Fatalf("Expected credentials without a refresh token to fail")
+	}
+}
@@ -8,26 +8,81 @@ import (
 
 // type OAuth1Credentials is a struct containing OAuth1 keys and secrets.
 type OAuth1Credentials struct {
-	// ConsumerKey is an OAuth1 consumer (application) key.	
-	ConsumerKey    string `json:"consumer_key"`
-	// ConsumerSecret is an OAuth1 consumer (application) secret.	
+	// ConsumerKey is an OAuth1 consumer (application) key.
+	ConsumerKey string `json:"consumer_key"`
+	// ConsumerSecret is an OAuth1 consumer (application) secret.
 	ConsumerSecret string `json:"consumer_secret"`
-	// AccessToken is an OAuth1 access token.		
-	AccessToken    string `json:"access_token"`
-	// AccessSecret is an OAuth1 access secret.			
-	AccessSecret   string `json:"access_token_secret"`
+	// AccessToken is an OAuth1 access token.
+	AccessToken string `json:"access_token"`
+	// AccessSecret is an OAuth1 access secret.
+	AccessSecret string `json:"access_token_secret"`
 }
 
-// NewOAuth1CredentialsFromString derives a `OAuth1Credentials` struct from a JSON-encoded string.
+// oauth1Aliases maps the properties of an `OAuth1Credentials` document to the alternate names used by other tools
+// (for example twurl, tweepy and python-twitter) for the same values.
+var oauth1Aliases = map[string][]string{
+	"consumer_key":        {"api_key", "app_key", "oauth_consumer_key"},
+	"consumer_secret":     {"api_secret", "api_key_secret", "app_secret", "oauth_consumer_secret"},
+	"access_token":        {"token", "access_token_key", "oauth_token"},
+	"access_token_secret": {"access_secret", "secret", "token_secret", "oauth_token_secret"},
+}
+
+// NewOAuth1CredentialsFromString derives a `OAuth1Credentials` struct from a JSON-encoded string and validates it
+// (see `Validate`). In addition to the canonical property names the alternate names used by other tools are supported,
+// for example "api_key" and "api_key_secret" (the names used by the Twitter developer portal) or "token" and "secret"
+// (twurl). Property names are matched ignoring case, "-" and "_" characters so "ConsumerKey" and "consumer-key" are
+// also accepted.
 func NewOAuth1CredentialsFromString(ctx context.Context, str_creds string) (*OAuth1Credentials, error) {
 
-	var creds *OAuth1Credentials
+	var doc map[string]interface{}
 
-	err := json.Unmarshal([]byte(str_creds), &creds)
+	err := json.Unmarshal([]byte(str_creds), &doc)
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to unmarshal credentials, %w", err)
 	}
 
+	if doc == nil {
+		return nil, fmt.Errorf("%w, expected a JSON object", ErrInvalidCredentials)
+	}
+
+	values, field_errors := fieldsFromDocument(doc, oauth1Aliases)
+
+	creds := &OAuth1Credentials{
+		ConsumerKey:    values["consumer_key"],
+		ConsumerSecret: values["consumer_secret"],
+		AccessToken:    values["access_token"],
+		AccessSecret:   values["access_token_secret"],
+	}
+
+	err = creds.validate(field_errors)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return creds, nil
 }
+
+// Validate returns a `CredentialsError` naming each of the "consumer_key", "consumer_secret", "access_token" and
+// "access_token_secret" properties which are missing or malformed.
+func (c *OAuth1Credentials) Validate() error {
+	return c.validate(nil)
+}
+
+// validate returns a `CredentialsError` combining 'field_errors' with any missing or malformed properties in 'c'.
+func (c *OAuth1Credentials) validate(field_errors []*FieldError) error {
+
+	if c == nil {
+		return fmt.Errorf("%w, credentials are nil", ErrInvalidCredentials)
+	}
+
+	fields := [][2]string{
+		{"consumer_key", c.ConsumerKey},
+		{"consumer_secret", c.ConsumerSecret},
+		{"access_token", c.AccessToken},
+		{"access_token_secret", c.AccessSecret},
+	}
+
+	return validateFields(fields, field_errors)
+}
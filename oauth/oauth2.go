@@ -0,0 +1,204 @@
+package oauth
+
+// https://developer.twitter.com/en/docs/authentication/oauth-2-0/user-access-token
+// https://www.rfc-editor.org/rfc/rfc6749#section-6
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DEFAULT_OAUTH2_TOKEN_URL is the default URL used to refresh OAuth2 access tokens.
+const DEFAULT_OAUTH2_TOKEN_URL string = "https://api.twitter.com/2/oauth2/token"
+
+// OAuth2Credentials is a struct containing an OAuth2 user-context (PKCE) access token and the
+// details needed to refresh it.
+type OAuth2Credentials struct {
+	// ClientId is the OAuth2 client (application) ID.
+	ClientId string `json:"client_id"`
+	// ClientSecret is the (optional) OAuth2 client secret. It is only issued to confidential clients.
+	ClientSecret string `json:"client_secret,omitempty"`
+	// AccessToken is the OAuth2 access (bearer) token.
+	AccessToken string `json:"access_token"`
+	// RefreshToken is the (optional) OAuth2 refresh token used to issue new access tokens. It is only
+	// issued if the "offline.access" scope was requested.
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// TokenType is the type of the access token. It is always "bearer".
+	TokenType string `json:"token_type,omitempty"`
+	// Scope is the space-separated list of scopes the access token was issued for.
+	Scope string `json:"scope,omitempty"`
+	// ExpiresAt is the time the access token expires. If zero the expiry time is unknown.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// oauth2TokenResponse is the response returned by the OAuth2 token endpoint.
+type oauth2TokenResponse struct {
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// oauth2ErrorResponse is the error response returned by the OAuth2 token endpoint.
+type oauth2ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// NewOAuth2CredentialsFromString derives a `OAuth2Credentials` struct from a JSON-encoded string and validates it
+// (see `Validate`).
+func NewOAuth2CredentialsFromString(ctx context.Context, str_creds string) (*OAuth2Credentials, error) {
+
+	var creds *OAuth2Credentials
+
+	err := json.Unmarshal([]byte(str_creds), &creds)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal credentials, %w", err)
+	}
+
+	if creds == nil {
+		return nil, fmt.Errorf("%w, expected a JSON object", ErrInvalidCredentials)
+	}
+
+	err = creds.Validate()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return creds, nil
+}
+
+// Validate returns a `CredentialsError` naming each of the "client_id" and "access_token" properties which are missing
+// or malformed, and the optional "client_secret" and "refresh_token" properties if they are present but malformed.
+func (c *OAuth2Credentials) Validate() error {
+
+	if c == nil {
+		return fmt.Errorf("%w, credentials are nil", ErrInvalidCredentials)
+	}
+
+	fields := [][2]string{
+		{"client_id", c.ClientId},
+		{"access_token", c.AccessToken},
+	}
+
+	if c.ClientSecret != "" {
+		fields = append(fields, [2]string{"client_secret", c.ClientSecret})
+	}
+
+	if c.RefreshToken != "" {
+		fields = append(fields, [2]string{"refresh_token", c.RefreshToken})
+	}
+
+	return validateFields(fields, nil)
+}
+
+// CredentialsType returns `CREDENTIALS_OAUTH2`.
+func (c *OAuth2Credentials) CredentialsType() string {
+	return CREDENTIALS_OAUTH2
+}
+
+// Expired returns a boolean value indicating whether the access token in 'c' has expired, or will
+// expire within 'margin'. Access tokens without an expiry time are never considered expired.
+func (c *OAuth2Credentials) Expired(margin time.Duration) bool {
+
+	if c.ExpiresAt.IsZero() {
+		return false
+	}
+
+	return !time.Now().Add(margin).Before(c.ExpiresAt)
+}
+
+// Refresh exchanges the refresh token in 'c' for a new access token using the OAuth2 token endpoint
+// 'token_url' and returns a new `OAuth2Credentials` instance. Refresh tokens can only be used once so
+// the new credentials (which contain a new refresh token) replace 'c'.
+func (c *OAuth2Credentials) Refresh(ctx context.Context, http_client *http.Client, token_url string) (*OAuth2Credentials, error) {
+
+	if c.RefreshToken == "" {
+		return nil, fmt.Errorf("Credentials do not have a refresh token")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", c.RefreshToken)
+	form.Set("client_id", c.ClientId)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, token_url, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create request, %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if c.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientId), url.QueryEscape(c.ClientSecret))
+	}
+
+	rsp, err := http_client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to execute request, %w", err)
+	}
+
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read response, %w", err)
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+
+		var e *oauth2ErrorResponse
+
+		err := json.Unmarshal(body, &e)
+
+		if err == nil && e != nil && e.Error != "" {
+			return nil, fmt.Errorf("Failed to refresh access token, %s (%s)", e.Error, e.ErrorDescription)
+		}
+
+		return nil, fmt.Errorf("Failed to refresh access token, %s", rsp.Status)
+	}
+
+	var token_rsp *oauth2TokenResponse
+
+	err = json.Unmarshal(body, &token_rsp)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal response, %w", err)
+	}
+
+	if token_rsp == nil || token_rsp.AccessToken == "" {
+		return nil, fmt.Errorf("Token response is missing an access token")
+	}
+
+	new_creds := &OAuth2Credentials{
+		ClientId:     c.ClientId,
+		ClientSecret: c.ClientSecret,
+		AccessToken:  token_rsp.AccessToken,
+		RefreshToken: token_rsp.RefreshToken,
+		TokenType:    token_rsp.TokenType,
+		Scope:        token_rsp.Scope,
+	}
+
+	// Not all servers rotate refresh tokens
+	if new_creds.RefreshToken == "" {
+		new_creds.RefreshToken = c.RefreshToken
+	}
+
+	if token_rsp.ExpiresIn > 0 {
+		new_creds.ExpiresAt = time.Now().Add(time.Duration(token_rsp.ExpiresIn) * time.Second)
+	}
+
+	return new_creds, nil
+}
@@ -0,0 +1,88 @@
+package oauth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// REDACTED is the string used in place of secret values when credentials are formatted as strings.
+const REDACTED string = "[REDACTED]"
+
+// String returns a representation of 'c' suitable for logging. Secrets are replaced by `REDACTED` and only the
+// first few characters of the consumer key and access token are included. The method has a value receiver so that
+// both `OAuth1Credentials` values and pointers are redacted.
+func (c OAuth1Credentials) String() string {
+
+	fields := [][2]string{
+		{"consumer_key", redactIdentifier(c.ConsumerKey)},
+		{"consumer_secret", redactSecret(c.ConsumerSecret)},
+		{"access_token", redactIdentifier(c.AccessToken)},
+		{"access_token_secret", redactSecret(c.AccessSecret)},
+	}
+
+	return formatRedacted("OAuth1Credentials", fields)
+}
+
+// GoString returns the same value as `String` so that secrets are not included when 'c' is formatted using "%#v".
+func (c OAuth1Credentials) GoString() string {
+	return c.String()
+}
+
+// String returns a representation of 'c' suitable for logging. The access token and secrets are replaced by
+// `REDACTED` and only the first few characters of the client ID are included. The method has a value receiver so
+// that both `OAuth2Credentials` values and pointers are redacted.
+func (c OAuth2Credentials) String() string {
+
+	fields := [][2]string{
+		{"client_id", redactIdentifier(c.ClientId)},
+		{"client_secret", redactSecret(c.ClientSecret)},
+		{"access_token", redactSecret(c.AccessToken)},
+		{"refresh_token", redactSecret(c.RefreshToken)},
+		{"scope", c.Scope},
+		{"expires_at", c.ExpiresAt.Format(time.RFC3339)},
+	}
+
+	return formatRedacted("OAuth2Credentials", fields)
+}
+
+// GoString returns the same value as `String` so that secrets are not included when 'c' is formatted using "%#v".
+func (c OAuth2Credentials) GoString() string {
+	return c.String()
+}
+
+// redactIdentifier returns the first four characters of 'v', followed by "...", if 'v' is long enough to not be
+// revealed by them; otherwise it returns `REDACTED`.
+func redactIdentifier(v string) string {
+
+	switch {
+	case v == "":
+		return ""
+	case len(v) < 12:
+		return REDACTED
+	default:
+		return v[:4] + "..."
+	}
+}
+
+// redactSecret returns `REDACTED` if 'v' is not empty.
+func redactSecret(v string) string {
+
+	if v == "" {
+		return ""
+	}
+
+	return REDACTED
+}
+
+// formatRedacted returns the string representation of the (name, value) pairs in 'fields' for 'label'.
+func formatRedacted(label string, fields [][2]string) string {
+
+	parts := make([]string, len(fields))
+
+	for idx, f := range fields {
+		parts[idx] = fmt.Sprintf("%s=%q", f[0], f[1])
+	}
+
+	return fmt.Sprintf("%s{%s}", label, strings.Join(parts, " "))
+}
@@ -0,0 +1,61 @@
+package oauth
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOAuth1CredentialsString(t *testing.T) {
+
+	creds := &OAuth1Credentials{
+		ConsumerKey:    "consumer-key-1234",
+		ConsumerSecret: "consumer-secret-5678",
+		AccessToken:    "short",
+		AccessSecret:   "access-secret-9012",
+	}
+
+	for _, format := range []string{"%s", "%v", "%+v", "%#v"} {
+
+		for _, v := range []interface{}{creds, *creds} {
+
+			str := fmt.Sprintf(format, v)
+
+			for _, secret := range []string{"consumer-secret-5678", "access-secret-9012", "short", "consumer-key-1234"} {
+
+				if strings.Contains(str, secret) {
+					t.Fatalf("Expected '%s' formatted with %s to be redacted, got %s", secret, format, str)
+				}
+			}
+
+			if !strings.Contains(str, `consumer_key="cons..."`) || !strings.Contains(str, `access_token="`+REDACTED+`"`) {
+				t.Fatalf("Unexpected string %s", str)
+			}
+		}
+	}
+}
+
+func TestOAuth2CredentialsString(t *testing.T) {
+
+	creds := &OAuth2Credentials{
+		ClientId:     "client-id-1234",
+		AccessToken:  "access-token-5678",
+		RefreshToken: "refresh-token-9012",
+		Scope:        "tweet.write",
+		ExpiresAt:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	str := fmt.Sprintf("%#v", creds)
+
+	for _, secret := range []string{"access-token-5678", "refresh-token-9012"} {
+
+		if strings.Contains(str, secret) {
+			t.Fatalf("Expected '%s' to be redacted, got %s", secret, str)
+		}
+	}
+
+	if !strings.Contains(str, `client_id="clie..."`) || !strings.Contains(str, `client_secret=""`) || !strings.Contains(str, "2023-01-01T00:00:00Z") {
+		t.Fatalf("Unexpected string %s", str)
+	}
+}
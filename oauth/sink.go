@@ -0,0 +1,214 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aaronland/go-roster"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TokenSink is an interface for persisting OAuth2 credentials after their access token has been refreshed.
+// Twitter refresh tokens can only be used once so refreshed credentials need to be stored somewhere they
+// will be read from the next time an application starts.
+type TokenSink interface {
+	// WriteToken persists refreshed `OAuth2Credentials`.
+	WriteToken(context.Context, *OAuth2Credentials) error
+}
+
+// TokenSinkFunc is a function that implements the `TokenSink` interface.
+type TokenSinkFunc func(context.Context, *OAuth2Credentials) error
+
+// WriteToken calls 'f' with 'creds'.
+func (f TokenSinkFunc) WriteToken(ctx context.Context, creds *OAuth2Credentials) error {
+	return f(ctx, creds)
+}
+
+var sink_roster roster.Roster
+
+// TokenSinkInitializationFunc is a function defined by individual token sink implementations and used to create
+// an instance of that token sink.
+type TokenSinkInitializationFunc func(ctx context.Context, uri string) (TokenSink, error)
+
+// RegisterTokenSink registers 'scheme' as a key pointing to 'init_func' in an internal lookup table
+// used to create new `TokenSink` instances by the `NewTokenSink` method.
+func RegisterTokenSink(ctx context.Context, scheme string, init_func TokenSinkInitializationFunc) error {
+
+	err := ensureSinkRoster()
+
+	if err != nil {
+		return err
+	}
+
+	return sink_roster.Register(ctx, scheme, init_func)
+}
+
+func ensureSinkRoster() error {
+
+	if sink_roster == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return err
+		}
+
+		sink_roster = r
+	}
+
+	return nil
+}
+
+// NewTokenSink returns a new `TokenSink` instance configured by 'uri'. The value of 'uri' is parsed
+// as a `url.URL` and its scheme is used as the key for a corresponding `TokenSinkInitializationFunc`
+// function used to instantiate the new `TokenSink`. It is assumed that the scheme (and initialization
+// function) have been registered by the `RegisterTokenSink` method.
+func NewTokenSink(ctx context.Context, uri string) (TokenSink, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = ensureSinkRoster()
+
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := sink_roster.Driver(ctx, u.Scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Unsupported token sink '%s', %w", u.Scheme, err)
+	}
+
+	init_func := i.(TokenSinkInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// TokenSinkSchemes returns the list of token sink schemes that have been registered.
+func TokenSinkSchemes() []string {
+
+	ctx := context.Background()
+	schemes := []string{}
+
+	err := ensureSinkRoster()
+
+	if err != nil {
+		return schemes
+	}
+
+	for _, dr := range sink_roster.Drivers(ctx) {
+		scheme := fmt.Sprintf("%s://", strings.ToLower(dr))
+		schemes = append(schemes, scheme)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+func init() {
+	ctx := context.Background()
+	RegisterTokenSink(ctx, "null", NewNullTokenSink)
+	RegisterTokenSink(ctx, "file", NewFileTokenSink)
+}
+
+// NullTokenSink implements the `TokenSink` interface but does not persist anything.
+type NullTokenSink struct {
+	TokenSink
+}
+
+// NewNullTokenSink returns a new `NullTokenSink` instance configured by 'uri' which is expected to take the form of:
+//
+//	null://
+func NewNullTokenSink(ctx context.Context, uri string) (TokenSink, error) {
+	s := &NullTokenSink{}
+	return s, nil
+}
+
+// WriteToken is a no-op.
+func (s *NullTokenSink) WriteToken(ctx context.Context, creds *OAuth2Credentials) error {
+	return nil
+}
+
+// FileTokenSink implements the `TokenSink` interface by writing JSON-encoded credentials to a file on the local
+// filesystem. The file can be read back using a "file://" `gocloud.dev/runtimevar` URI.
+type FileTokenSink struct {
+	TokenSink
+	path string
+}
+
+// NewFileTokenSink returns a new `FileTokenSink` instance configured by 'uri' which is expected to take the form of:
+//
+//	file:///path/to/credentials.json
+func NewFileTokenSink(ctx context.Context, uri string) (TokenSink, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	path := u.Path
+
+	if path == "" {
+		return nil, fmt.Errorf("Missing path")
+	}
+
+	abs_path, err := filepath.Abs(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive absolute path for %s, %w", path, err)
+	}
+
+	s := &FileTokenSink{
+		path: abs_path,
+	}
+
+	return s, nil
+}
+
+// WriteToken atomically replaces the contents of the file associated with 's' with 'creds'. The file is only
+// readable by the current user.
+func (s *FileTokenSink) WriteToken(ctx context.Context, creds *OAuth2Credentials) error {
+
+	body, err := json.MarshalIndent(creds, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("Failed to marshal credentials, %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary file, %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(body)
+
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("Failed to write temporary file, %w", err)
+	}
+
+	err = tmp.Close()
+
+	if err != nil {
+		return fmt.Errorf("Failed to close temporary file, %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), s.path)
+
+	if err != nil {
+		return fmt.Errorf("Failed to replace %s, %w", s.path, err)
+	}
+
+	return nil
+}
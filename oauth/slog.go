@@ -0,0 +1,32 @@
+//go:build go1.21
+
+package oauth
+
+import (
+	"log/slog"
+)
+
+// LogValue implements the `slog.LogValuer` interface, returning a group value in which secrets are replaced by `REDACTED`.
+func (c OAuth1Credentials) LogValue() slog.Value {
+
+	return slog.GroupValue(
+		slog.String("consumer_key", redactIdentifier(c.ConsumerKey)),
+		slog.String("consumer_secret", redactSecret(c.ConsumerSecret)),
+		slog.String("access_token", redactIdentifier(c.AccessToken)),
+		slog.String("access_token_secret", redactSecret(c.AccessSecret)),
+	)
+}
+
+// LogValue implements the `slog.LogValuer` interface, returning a group value in which the access token and secrets
+// are replaced by `REDACTED`.
+func (c OAuth2Credentials) LogValue() slog.Value {
+
+	return slog.GroupValue(
+		slog.String("client_id", redactIdentifier(c.ClientId)),
+		slog.String("client_secret", redactSecret(c.ClientSecret)),
+		slog.String("access_token", redactSecret(c.AccessToken)),
+		slog.String("refresh_token", redactSecret(c.RefreshToken)),
+		slog.String("scope", c.Scope),
+		slog.Time("expires_at", c.ExpiresAt),
+	)
+}
@@ -0,0 +1,44 @@
+//go:build go1.21
+
+package oauth
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCredentialsLogValue(t *testing.T) {
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	oauth1_creds := &OAuth1Credentials{
+		ConsumerKey:    "consumer-key-1234",
+		ConsumerSecret: "consumer-secret-5678",
+		AccessToken:    "access-token-1234",
+		AccessSecret:   "access-secret-9012",
+	}
+
+	oauth2_creds := &OAuth2Credentials{
+		ClientId:     "client-id-1234",
+		AccessToken:  "oauth2-access-token-5678",
+		RefreshToken: "refresh-token-9012",
+	}
+
+	logger.Info("credentials", "oauth1", oauth1_creds, "oauth2", oauth2_creds)
+
+	str := buf.String()
+
+	for _, secret := range []string{"consumer-secret-5678", "access-secret-9012", "oauth2-access-token-5678", "refresh-token-9012"} {
+
+		if strings.Contains(str, secret) {
+			t.Fatalf("Expected '%s' to be redacted, got %s", secret, str)
+		}
+	}
+
+	if !strings.Contains(str, REDACTED) {
+		t.Fatalf("Expected redacted values to be logged, got %s", str)
+	}
+}
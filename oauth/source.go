@@ -0,0 +1,172 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DEFAULT_REFRESH_MARGIN is the default amount of time before an OAuth2 access token expires that it is refreshed.
+const DEFAULT_REFRESH_MARGIN time.Duration = 5 * time.Minute
+
+// OAuth2TokenSourceOptions defines configuration options for creating a new `OAuth2TokenSource` instance.
+type OAuth2TokenSourceOptions struct {
+	// HTTPClient is the `http.Client` used to refresh access tokens. If nil `http.DefaultClient` is used.
+	HTTPClient *http.Client
+	// TokenURL is the URL of the OAuth2 token endpoint. If empty `DEFAULT_OAUTH2_TOKEN_URL` is used.
+	TokenURL string
+	// Sink is the (optional) `TokenSink` that refreshed credentials are written to.
+	Sink TokenSink
+	// RefreshMargin is the amount of time before an access token expires that it is refreshed. If 0
+	// `DEFAULT_REFRESH_MARGIN` is used.
+	RefreshMargin time.Duration
+	// Logger is the (optional) `log.Logger` instance used to report failures to write refreshed credentials to 'Sink', or
+	// refresh tokens which were rotated when there is no 'Sink'.
+	Logger *log.Logger
+}
+
+// OAuth2TokenSource provides OAuth2 access tokens, refreshing them before they expire. It is safe for concurrent use.
+type OAuth2TokenSource struct {
+	credentials *OAuth2Credentials
+	http_client *http.Client
+	token_url   string
+	sink        TokenSink
+	margin      time.Duration
+	logger      *log.Logger
+	mu          *sync.Mutex
+}
+
+// NewOAuth2TokenSource returns a new `OAuth2TokenSource` instance for 'creds' configured by 'opts' (which may be nil).
+func NewOAuth2TokenSource(ctx context.Context, creds *OAuth2Credentials, opts *OAuth2TokenSourceOptions) (*OAuth2TokenSource, error) {
+
+	if creds == nil {
+		return nil, fmt.Errorf("Missing credentials")
+	}
+
+	if opts == nil {
+		opts = &OAuth2TokenSourceOptions{}
+	}
+
+	http_client := opts.HTTPClient
+
+	if http_client == nil {
+		http_client = http.DefaultClient
+	}
+
+	token_url := opts.TokenURL
+
+	if token_url == "" {
+		token_url = DEFAULT_OAUTH2_TOKEN_URL
+	}
+
+	margin := opts.RefreshMargin
+
+	if margin == 0 {
+		margin = DEFAULT_REFRESH_MARGIN
+	}
+
+	if margin < 0 {
+		return nil, fmt.Errorf("Invalid refresh margin, must not be negative")
+	}
+
+	logger := opts.Logger
+
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	c := *creds
+
+	s := &OAuth2TokenSource{
+		credentials: &c,
+		http_client: http_client,
+		token_url:   token_url,
+		sink:        opts.Sink,
+		margin:      margin,
+		logger:      logger,
+		mu:          new(sync.Mutex),
+	}
+
+	return s, nil
+}
+
+// Token returns a valid access token, refreshing it first if it has expired or is about to expire.
+func (s *OAuth2TokenSource) Token(ctx context.Context) (string, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.credentials.Expired(s.margin) && s.credentials.RefreshToken != "" {
+
+		err := s.refresh(ctx)
+
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if s.credentials.Expired(0) {
+		return "", fmt.Errorf("Access token expired at %s and can not be refreshed", s.credentials.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return s.credentials.AccessToken, nil
+}
+
+// Refresh refreshes the access token regardless of when it expires.
+func (s *OAuth2TokenSource) Refresh(ctx context.Context) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.refresh(ctx)
+}
+
+// Credentials returns a copy of the current credentials.
+func (s *OAuth2TokenSource) Credentials() *OAuth2Credentials {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := *s.credentials
+	return &c
+}
+
+// refresh refreshes the access token and writes the new credentials to the sink, if present. If there is no sink and
+// the refresh token has been rotated a warning is logged. Callers must hold 's.mu'.
+func (s *OAuth2TokenSource) refresh(ctx context.Context) error {
+
+	new_creds, err := s.credentials.Refresh(ctx, s.http_client, s.token_url)
+
+	if err != nil {
+		return err
+	}
+
+	rotated := new_creds.RefreshToken != s.credentials.RefreshToken
+	s.credentials = new_creds
+
+	if s.sink == nil {
+
+		// Twitter refresh tokens can only be used once so unless the new one is saved somewhere the
+		// credentials will stop working once the current access token expires after a restart
+
+		if rotated {
+			s.logger.Printf("Warning: OAuth2 refresh token was rotated but there is no token sink to write it to, the new refresh token will be lost when this process exits")
+		}
+
+		return nil
+	}
+
+	// The previous refresh token is no longer valid so failing to persist the new one is
+	// worth shouting about, but the new access token is still good for this process
+
+	err = s.sink.WriteToken(ctx, new_creds)
+
+	if err != nil {
+		s.logger.Printf("Warning: failed to write refreshed OAuth2 credentials to token sink, %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package oauth
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestOAuth2TokenSourceRefresh(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestTokenServer(t)
+
+	written := make([]*OAuth2Credentials, 0)
+
+	sink := TokenSinkFunc(func(ctx context.Context, creds *OAuth2Credentials) error {
+		written = append(written, creds)
+		return nil
+	})
+
+	var buf bytes.Buffer
+
+	opts := &OAuth2TokenSourceOptions{
+		HTTPClient: s.Client(),
+		TokenURL:   s.URL,
+		Sink:       sink,
+		Logger:     log.New(&buf, "", 0),
+	}
+
+	source, err := NewOAuth2TokenSource(ctx, newTestOAuth2Credentials(), opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create token source, %v", err)
+	}
+
+	token, err := source.Token(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to get token, %v", err)
+	}
+
+	if token != "access-1" {
+		t.Fatalf("Expected expired token to be refreshed, got '%s'", token)
+	}
+
+	// The token is not refreshed again until it is about to expire
+
+	token, err = source.Token(ctx)
+
+	if err != nil || token != "access-1" {
+		t.Fatalf("Unexpected token '%s', %v", token, err)
+	}
+
+	if len(written) != 1 || written[0].RefreshToken != "refresh-1" {
+		t.Fatalf("Expected refreshed credentials to be written to the sink once, got %d", len(written))
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("Unexpected log output, %s", buf.String())
+	}
+}
+
+func TestOAuth2TokenSourceRefreshWithoutSink(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestTokenServer(t)
+
+	var buf bytes.Buffer
+
+	opts := &OAuth2TokenSourceOptions{
+		HTTPClient: s.Client(),
+		TokenURL:   s.URL,
+		Logger:     log.New(&buf, "", 0),
+	}
+
+	source, err := NewOAuth2TokenSource(ctx, newTestOAuth2Credentials(), opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create token source, %v", err)
+	}
+
+	err = source.Refresh(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to refresh token, %v", err)
+	}
+
+	if source.Credentials().RefreshToken != "refresh-1" {
+		t.Fatalf("Expected refresh token to be rotated")
+	}
+
+	if !strings.Contains(buf.String(), "refresh token was rotated but there is no token sink") {
+		t.Fatalf("Expected a warning about the rotated refresh token, got '%s'", buf.String())
+	}
+}
@@ -0,0 +1,182 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidCredentials is the error returned when credentials are missing required properties or contain
+// malformed values.
+var ErrInvalidCredentials = errors.New("Invalid credentials")
+
+// FieldError describes a credentials property which is missing or malformed.
+type FieldError struct {
+	// Field is the (canonical) name of the property.
+	Field string
+	// Reason is a description of what is wrong with the property, for example "is missing".
+	Reason string
+}
+
+// Error returns the string representation of 'e'.
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Reason)
+}
+
+// CredentialsError is the error returned when one or more credentials properties are missing or malformed.
+type CredentialsError struct {
+	// Fields are the properties which are missing or malformed.
+	Fields []*FieldError
+}
+
+// Error returns the string representation of 'e'.
+func (e *CredentialsError) Error() string {
+
+	reasons := make([]string, len(e.Fields))
+
+	for idx, f := range e.Fields {
+		reasons[idx] = f.Error()
+	}
+
+	return fmt.Sprintf("%v, %s", ErrInvalidCredentials, strings.Join(reasons, ", "))
+}
+
+// Is returns a boolean value indicating whether 'target' is `ErrInvalidCredentials`.
+func (e *CredentialsError) Is(target error) bool {
+	return target == ErrInvalidCredentials
+}
+
+// validateFields checks each of the (name, value) pairs in 'fields', in order, and returns a `CredentialsError`
+// combining 'field_errors' with the properties which are missing or malformed, or nil if there are none. Properties
+// named in 'field_errors' are not checked again.
+func validateFields(fields [][2]string, field_errors []*FieldError) error {
+
+	reported := make(map[string]bool)
+	errs := make([]*FieldError, 0)
+
+	for _, e := range field_errors {
+		reported[e.Field] = true
+	}
+
+	for _, f := range fields {
+
+		name := f[0]
+
+		if reported[name] {
+
+			for _, e := range field_errors {
+
+				if e.Field == name {
+					errs = append(errs, e)
+				}
+			}
+
+			continue
+		}
+
+		reason := checkFieldValue(f[1])
+
+		if reason != "" {
+			errs = append(errs, &FieldError{Field: name, Reason: reason})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return &CredentialsError{
+		Fields: errs,
+	}
+}
+
+// checkFieldValue returns a description of what is wrong with 'value', or an empty string if it is valid.
+func checkFieldValue(value string) string {
+
+	if value == "" {
+		return "is missing"
+	}
+
+	if strings.TrimSpace(value) == "" {
+		return "is blank"
+	}
+
+	for _, r := range value {
+
+		switch {
+		case unicode.IsSpace(r):
+			return "contains whitespace"
+		case r > unicode.MaxASCII || !unicode.IsPrint(r):
+			return "contains non-printable or non-ASCII characters"
+		}
+	}
+
+	return ""
+}
+
+// fieldsFromDocument returns the string values of the properties in 'doc' named by the keys of 'aliases', or any of
+// their alternate names, along with the list of properties whose values are not strings or which are defined more than
+// once with different values. Property names are matched using `normalizeFieldName`.
+func fieldsFromDocument(doc map[string]interface{}, aliases map[string][]string) (map[string]string, []*FieldError) {
+
+	values := make(map[string]string)
+	errs := make([]*FieldError, 0)
+
+	// Sort the keys so that errors are reported consistently
+	keys := make([]string, 0, len(doc))
+
+	for k := range doc {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for name, alt_names := range aliases {
+
+		candidates := make(map[string]bool)
+
+		for _, n := range append([]string{name}, alt_names...) {
+			candidates[normalizeFieldName(n)] = true
+		}
+
+		var found_key string
+
+		for _, k := range keys {
+
+			if !candidates[normalizeFieldName(k)] {
+				continue
+			}
+
+			str_v, ok := doc[k].(string)
+
+			if !ok {
+
+				if doc[k] == nil {
+					continue
+				}
+
+				errs = append(errs, &FieldError{Field: name, Reason: fmt.Sprintf("must be a string (property '%s')", k)})
+				break
+			}
+
+			if found_key != "" && values[name] != str_v {
+				errs = append(errs, &FieldError{Field: name, Reason: fmt.Sprintf("has conflicting values (properties '%s' and '%s')", found_key, k)})
+				break
+			}
+
+			found_key = k
+			values[name] = str_v
+		}
+	}
+
+	return values, errs
+}
+
+// normalizeFieldName returns a lower-case copy of 'name' with all "-" and "_" characters removed.
+func normalizeFieldName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "-", "")
+	return strings.ReplaceAll(name, "_", "")
+}
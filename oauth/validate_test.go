@@ -0,0 +1,104 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewOAuth1CredentialsFromStringAliases(t *testing.T) {
+
+	ctx := context.Background()
+
+	docs := []string{
+		`{"consumer_key":"a","consumer_secret":"b","access_token":"c","access_token_secret":"d"}`,
+		`{"api_key":"a","api_key_secret":"b","access_token":"c","access_token_secret":"d"}`,
+		`{"ConsumerKey":"a","consumer-secret":"b","token":"c","secret":"d"}`,
+		`{"oauth_consumer_key":"a","oauth_consumer_secret":"b","oauth_token":"c","oauth_token_secret":"d"}`,
+		`{"consumer_key":"a","api_key":"a","consumer_secret":"b","access_token":"c","access_secret":"d"}`,
+	}
+
+	for _, str_creds := range docs {
+
+		creds, err := NewOAuth1CredentialsFromString(ctx, str_creds)
+
+		if err != nil {
+			t.Fatalf("Failed to parse %s, %v", str_creds, err)
+		}
+
+		if creds.ConsumerKey != "a" || creds.ConsumerSecret != "b" || creds.AccessToken != "c" || creds.AccessSecret != "d" {
+			t.Fatalf("Unexpected credentials for %s", str_creds)
+		}
+	}
+}
+
+func TestNewOAuth1CredentialsFromStringInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	tests := map[string][]string{
+		`{}`: {"consumer_key is missing", "consumer_secret is missing", "access_token is missing", "access_token_secret is missing"},
+		`{"consumer_key":" ","consumer_secret":"b c","access_token":"c","access_token_secret":"d"}`: {"consumer_key is blank", "consumer_secret contains whitespace"},
+		`{"consumer_key":"a","consumer_secret":"b","access_token":"c","access_token_secret":"dé"}`:  {"access_token_secret contains non-printable or non-ASCII characters"},
+		`{"consumer_key":1,"consumer_secret":"b","access_token":"c","access_token_secret":"d"}`:     {"consumer_key must be a string"},
+		`{"consumer_key":"a","api_key":"z","consumer_secret":"b","access_token":"c","secret":"d"}`:  {"consumer_key has conflicting values"},
+	}
+
+	for str_creds, reasons := range tests {
+
+		_, err := NewOAuth1CredentialsFromString(ctx, str_creds)
+
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Expected %s to be invalid, got %v", str_creds, err)
+		}
+
+		var creds_err *CredentialsError
+
+		if !errors.As(err, &creds_err) || len(creds_err.Fields) != len(reasons) {
+			t.Fatalf("Expected %d field errors for %s, got %v", len(reasons), str_creds, err)
+		}
+
+		for _, r := range reasons {
+
+			if !strings.Contains(err.Error(), r) {
+				t.Fatalf("Expected error for %s to contain '%s', got %v", str_creds, r, err)
+			}
+		}
+
+		// Secret values are never included in errors
+
+		if strings.Contains(err.Error(), "b c") || strings.Contains(err.Error(), "dé") {
+			t.Fatalf("Expected error not to contain secret values, %v", err)
+		}
+	}
+}
+
+func TestOAuth2CredentialsValidate(t *testing.T) {
+
+	creds := &OAuth2Credentials{
+		ClientId:     "client-id",
+		AccessToken:  "access-token",
+		RefreshToken: "refresh token",
+	}
+
+	err := creds.Validate()
+
+	if err == nil || !strings.Contains(err.Error(), "refresh_token contains whitespace") {
+		t.Fatalf("Expected malformed refresh token to be invalid, got %v", err)
+	}
+
+	creds.RefreshToken = ""
+
+	err = creds.Validate()
+
+	if err != nil {
+		t.Fatalf("Expected credentials without a refresh token to be valid, %v", err)
+	}
+
+	var nil_creds *OAuth2Credentials
+
+	if !errors.Is(nil_creds.Validate(), ErrInvalidCredentials) {
+		t.Fatalf("Expected nil credentials to be invalid")
+	}
+}
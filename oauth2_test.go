@@ -0,0 +1,89 @@
+package twitter_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/aaronland/go-broadcaster-twitter/twittertest"
+)
+
+func TestBroadcastOAuth2(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	creds := twittertest.DefaultOAuth2Credentials()
+	creds.ExpiresAt = time.Now().Add(-1 * time.Minute)
+
+	s := newTestServer(t, &twittertest.ServerOptions{OAuth2: creds})
+
+	sink_path := filepath.Join(t.TempDir(), "credentials.json")
+
+	params := url.Values{}
+	params.Set("token-sink", "file://"+sink_path)
+
+	uri, err := s.OAuth2BroadcasterURI(params)
+
+	if err != nil {
+		t.Fatalf("Failed to derive broadcaster URI, %v", err)
+	}
+
+	opts := &twitter.TwitterBroadcasterOptions{
+		HTTPClient: s.Client(),
+	}
+
+	br, err := twitter.NewTwitterBroadcasterWithOptions(ctx, uri, opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create broadcaster, %v", err)
+	}
+
+	br.(*twitter.TwitterBroadcaster).SetLogger(ctx, log.New(io.Discard, "", 0))
+
+	_, err = broadcast(t, br, "hello world")
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	if s.OAuth2Refreshes() != 1 {
+		t.Fatalf("Expected expired access token to be refreshed once, got %d", s.OAuth2Refreshes())
+	}
+
+	current, _ := s.OAuth2Credentials()
+
+	reqs := s.RequestsFor(http.MethodPost, twittertest.ENDPOINT_TWEETS)
+
+	if len(reqs) != 1 || reqs[0].Header.Get("Authorization") != "Bearer "+current.AccessToken {
+		t.Fatalf("Expected tweet to be published using the refreshed access token")
+	}
+
+	if len(s.Tweets()) != 1 {
+		t.Fatalf("Expected 1 tweet, got %d", len(s.Tweets()))
+	}
+
+	body, err := os.ReadFile(sink_path)
+
+	if err != nil {
+		t.Fatalf("Failed to read token sink, %v", err)
+	}
+
+	written, err := oauth.NewOAuth2CredentialsFromString(ctx, string(body))
+
+	if err != nil {
+		t.Fatalf("Failed to parse token sink, %v", err)
+	}
+
+	if written.AccessToken != current.AccessToken || written.RefreshToken != current.RefreshToken {
+		t.Fatalf("Expected refreshed credentials to be written to the token sink")
+	}
+}
@@ -0,0 +1,130 @@
+package twitter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OVERFLOW_ERROR is the `?overflow=` strategy which returns an error for messages that are too long (the default).
+const OVERFLOW_ERROR string = "error"
+
+// OVERFLOW_TRUNCATE is the `?overflow=` strategy which truncates messages on a word boundary and appends an ellipsis.
+const OVERFLOW_TRUNCATE string = "truncate"
+
+// OVERFLOW_TRUNCATE_LINK is the `?overflow=` strategy which truncates messages on a word boundary and appends a link.
+const OVERFLOW_TRUNCATE_LINK string = "truncate-link"
+
+// OVERFLOW_THREAD is the `?overflow=` strategy which splits messages in to a numbered thread of replies.
+const OVERFLOW_THREAD string = "thread"
+
+// ELLIPSIS is the string appended to truncated messages.
+const ELLIPSIS string = "…"
+
+// re_token matches runs of non-whitespace characters. URLs, hashtags and mentions are always a single token.
+var re_token = regexp.MustCompile(`\S+`)
+
+// isValidOverflow returns a boolean value indicating whether 'strategy' is a known overflow strategy.
+func isValidOverflow(strategy string) bool {
+
+	switch strategy {
+	case OVERFLOW_ERROR, OVERFLOW_TRUNCATE, OVERFLOW_TRUNCATE_LINK, OVERFLOW_THREAD:
+		return true
+	default:
+		return false
+	}
+}
+
+// applyOverflow returns the list of tweets to publish for 'text' according to 'strategy'. If 'text' fits in a
+// single tweet it is returned as-is. 'link' is the URL appended to messages truncated using the `OVERFLOW_TRUNCATE_LINK`
+// strategy. For the `OVERFLOW_ERROR` strategy 'text' is returned unchanged and it is left to the caller to validate it.
+func applyOverflow(text string, strategy string, link string) ([]string, error) {
+
+	if statusLength(text) <= MAX_STATUS_LENGTH {
+		return []string{text}, nil
+	}
+
+	switch strategy {
+	case OVERFLOW_ERROR:
+		return []string{text}, nil
+	case OVERFLOW_TRUNCATE:
+
+		truncated, err := truncateStatus(text, MAX_STATUS_LENGTH, ELLIPSIS, statusLength)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{truncated}, nil
+
+	case OVERFLOW_TRUNCATE_LINK:
+
+		truncated, err := truncateStatus(text, MAX_STATUS_LENGTH, fmt.Sprintf("%s %s", ELLIPSIS, link), statusLength)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{truncated}, nil
+
+	case OVERFLOW_THREAD:
+		return splitThread(text, MAX_STATUS_LENGTH, statusLength)
+	default:
+		return nil, fmt.Errorf("Invalid overflow strategy '%s'", strategy)
+	}
+}
+
+// truncateStatus returns the longest prefix of 'text', ending on a word boundary, which fits within 'max' characters
+// (as measured by 'length') once 'suffix' has been appended to it. Tokens (URLs, hashtags, mentions and words) are never
+// cut in half, with the exception of a plain word that is too long to fit on its own.
+func truncateStatus(text string, max int, suffix string, length func(string) int) (string, error) {
+
+	text = strings.TrimSpace(text)
+
+	if length(text) <= max {
+		return text, nil
+	}
+
+	if length(suffix) >= max {
+		return "", fmt.Errorf("Truncation suffix is longer than the maximum status length")
+	}
+
+	prefix := ""
+
+	for _, loc := range re_token.FindAllStringIndex(text, -1) {
+
+		candidate := text[:loc[1]]
+
+		if length(candidate+suffix) > max {
+
+			if prefix == "" && !isProtectedToken(text[loc[0]:loc[1]]) {
+
+				fragments := splitWord(text[loc[0]:loc[1]], max-length(suffix), length)
+				prefix = fragments[0]
+			}
+
+			break
+		}
+
+		prefix = candidate
+	}
+
+	prefix = strings.TrimRight(prefix, " \t\r\n,;:-–—")
+
+	if prefix == "" {
+		return "", fmt.Errorf("Unable to truncate status without breaking a URL, hashtag or mention")
+	}
+
+	return prefix + suffix, nil
+}
+
+// isProtectedToken returns a boolean value indicating whether 'token' is a URL, hashtag, cashtag or mention
+// which should not be cut in half when truncating a status.
+func isProtectedToken(token string) bool {
+
+	if strings.HasPrefix(token, "#") || strings.HasPrefix(token, "@") || strings.HasPrefix(token, "$") {
+		return true
+	}
+
+	return re_url.MatchString(token)
+}
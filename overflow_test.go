@@ -0,0 +1,141 @@
+package twitter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsValidOverflow(t *testing.T) {
+
+	for _, s := range []string{OVERFLOW_ERROR, OVERFLOW_TRUNCATE, OVERFLOW_TRUNCATE_LINK, OVERFLOW_THREAD} {
+
+		if !isValidOverflow(s) {
+			t.Fatalf("Expected '%s' to be a valid overflow strategy", s)
+		}
+	}
+
+	if isValidOverflow("ignore") {
+		t.Fatalf("Expected 'ignore' to be an invalid overflow strategy")
+	}
+}
+
+func TestApplyOverflow(t *testing.T) {
+
+	long := strings.TrimSpace(strings.Repeat("lorem ipsum ", 40))
+	link := "https://example.com/posts/1234"
+
+	parts, err := applyOverflow("hello world", OVERFLOW_TRUNCATE, link)
+
+	if err != nil {
+		t.Fatalf("Failed to apply overflow, %v", err)
+	}
+
+	if len(parts) != 1 || parts[0] != "hello world" {
+		t.Fatalf("Expected short text to be returned as-is, got %v", parts)
+	}
+
+	parts, err = applyOverflow(long, OVERFLOW_ERROR, link)
+
+	if err != nil {
+		t.Fatalf("Failed to apply overflow, %v", err)
+	}
+
+	if len(parts) != 1 || parts[0] != long {
+		t.Fatalf("Expected text to be returned unchanged with the error strategy")
+	}
+
+	parts, err = applyOverflow(long, OVERFLOW_TRUNCATE, link)
+
+	if err != nil {
+		t.Fatalf("Failed to apply overflow, %v", err)
+	}
+
+	if len(parts) != 1 || statusLength(parts[0]) > MAX_STATUS_LENGTH || !strings.HasSuffix(parts[0], "ipsum"+ELLIPSIS) {
+		t.Fatalf("Unexpected truncated status '%v'", parts)
+	}
+
+	parts, err = applyOverflow(long, OVERFLOW_TRUNCATE_LINK, link)
+
+	if err != nil {
+		t.Fatalf("Failed to apply overflow, %v", err)
+	}
+
+	if len(parts) != 1 || statusLength(parts[0]) > MAX_STATUS_LENGTH || !strings.HasSuffix(parts[0], ELLIPSIS+" "+link) {
+		t.Fatalf("Unexpected truncated status '%v'", parts)
+	}
+
+	parts, err = applyOverflow(long, OVERFLOW_THREAD, link)
+
+	if err != nil {
+		t.Fatalf("Failed to apply overflow, %v", err)
+	}
+
+	if len(parts) != 2 {
+		t.Fatalf("Expected text to be split in to 2 parts, got %d", len(parts))
+	}
+
+	_, err = applyOverflow(long, "ignore", link)
+
+	if err == nil {
+		t.Fatalf("Expected invalid overflow strategy to fail")
+	}
+}
+
+func TestTruncateStatus(t *testing.T) {
+
+	url := "https://example.com/a/long/path"
+
+	// The URL counts as 23 characters so "hello " plus the URL is 29 characters
+
+	truncated, err := truncateStatus("hello "+url+" world", 28, ELLIPSIS, statusLength)
+
+	if err != nil {
+		t.Fatalf("Failed to truncate status, %v", err)
+	}
+
+	if truncated != "hello"+ELLIPSIS {
+		t.Fatalf("Expected URL not to be cut, got '%s'", truncated)
+	}
+
+	truncated, err = truncateStatus("hello "+url+" world", 29, ELLIPSIS, statusLength)
+
+	if err != nil {
+		t.Fatalf("Failed to truncate status, %v", err)
+	}
+
+	if truncated != "hello "+url+ELLIPSIS {
+		t.Fatalf("Expected URL to be kept whole, got '%s'", truncated)
+	}
+
+	truncated, err = truncateStatus("one two, #hashtag", 12, ELLIPSIS, statusLength)
+
+	if err != nil {
+		t.Fatalf("Failed to truncate status, %v", err)
+	}
+
+	if truncated != "one two"+ELLIPSIS {
+		t.Fatalf("Expected hashtag not to be cut and trailing punctuation to be trimmed, got '%s'", truncated)
+	}
+
+	truncated, err = truncateStatus(strings.Repeat("a", 20), 10, ELLIPSIS, statusLength)
+
+	if err != nil {
+		t.Fatalf("Failed to truncate status, %v", err)
+	}
+
+	if truncated != strings.Repeat("a", 10-statusLength(ELLIPSIS))+ELLIPSIS {
+		t.Fatalf("Expected long word to be cut, got '%s'", truncated)
+	}
+
+	_, err = truncateStatus("#"+strings.Repeat("a", 20), 10, ELLIPSIS, statusLength)
+
+	if err == nil {
+		t.Fatalf("Expected truncating a single long hashtag to fail")
+	}
+
+	_, err = truncateStatus(strings.Repeat("a", 20), 10, strings.Repeat("b", 10), statusLength)
+
+	if err == nil {
+		t.Fatalf("Expected a suffix longer than the maximum length to fail")
+	}
+}
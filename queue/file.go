@@ -0,0 +1,213 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter/internal/jsonfile"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileQueue implements the `Queue` interface for items stored in a JSON-encoded file on the local filesystem.
+// The file is re-read before every operation so it may be inspected (or added to) by other processes, but writes
+// are not coordinated between processes. Image data is dropped from items once they are terminal and terminal
+// items are pruned from the file once they are older than the retention period.
+type FileQueue struct {
+	Queue
+	path      string
+	retention time.Duration
+	mu        *sync.Mutex
+}
+
+func init() {
+	ctx := context.Background()
+	RegisterQueue(ctx, "file", NewFileQueue)
+}
+
+// NewFileQueue returns a new `FileQueue` instance configured by 'uri' which is expected to take the form of:
+//
+//	file:///path/to/queue.json?{PARAMETERS}
+//
+// Valid parameters are:
+//   - `?retention=` The amount of time (a `time.Duration` string) since they were last updated after which items which
+//     have been sent, failed or were cancelled are removed from the file. A value of 0 keeps them forever. Default is 168h.
+//
+// The file is created, the first time an item is added, if it does not already exist.
+func NewFileQueue(ctx context.Context, uri string) (Queue, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	path := u.Path
+
+	if path == "" {
+		return nil, fmt.Errorf("Missing path")
+	}
+
+	abs_path, err := filepath.Abs(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to derive absolute path for %s, %w", path, err)
+	}
+
+	retention := DEFAULT_RETENTION
+
+	if u.Query().Has("retention") {
+
+		d, err := time.ParseDuration(u.Query().Get("retention"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?retention= parameter, %w", err)
+		}
+
+		if d < 0 {
+			return nil, fmt.Errorf("Invalid ?retention= parameter, must not be negative")
+		}
+
+		retention = d
+	}
+
+	q := &FileQueue{
+		path:      abs_path,
+		retention: retention,
+		mu:        new(sync.Mutex),
+	}
+
+	_, err = q.read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// Add adds 'i' to the queue, assigning its ID, status and creation time.
+func (q *FileQueue) Add(ctx context.Context, i *Item) error {
+
+	err := prepareItem(i)
+
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items, err := q.read()
+
+	if err != nil {
+		return err
+	}
+
+	items[i.Id] = i
+
+	return q.write(items)
+}
+
+// Get returns the `Item` associated with 'id' and a boolean value indicating whether it exists.
+func (q *FileQueue) Get(ctx context.Context, id string) (*Item, bool, error) {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items, err := q.read()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	i, ok := items[id]
+	return i, ok, nil
+}
+
+// Due returns the items which are due to be published at time 't', ordered by their send-at time.
+func (q *FileQueue) Due(ctx context.Context, t time.Time) ([]*Item, error) {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items, err := q.read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	due := make([]*Item, 0)
+
+	for _, i := range items {
+
+		if i.IsDue(t) {
+			due = append(due, i)
+		}
+	}
+
+	sortItems(due)
+	return due, nil
+}
+
+// Update stores 'i' replacing the existing item with the same ID.
+func (q *FileQueue) Update(ctx context.Context, i *Item) error {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items, err := q.read()
+
+	if err != nil {
+		return err
+	}
+
+	_, ok := items[i.Id]
+
+	if !ok {
+		return fmt.Errorf("Item %s does not exist", i.Id)
+	}
+
+	i.Updated = time.Now()
+	dropImageData(i)
+
+	items[i.Id] = i
+
+	return q.write(items)
+}
+
+// Close is a no-op.
+func (q *FileQueue) Close(ctx context.Context) error {
+	return nil
+}
+
+func (q *FileQueue) read() (map[string]*Item, error) {
+
+	items := make(map[string]*Item)
+
+	err := jsonfile.Read(q.path, &items)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// write atomically replaces the contents of 'q.path' with 'items', after removing terminal items older than the
+// retention period.
+func (q *FileQueue) write(items map[string]*Item) error {
+
+	if q.retention > 0 {
+
+		for id, i := range items {
+
+			if i.IsTerminal() && time.Since(i.Updated) > q.retention {
+				delete(items, id)
+			}
+		}
+	}
+
+	return jsonfile.Write(q.path, items)
+}
@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryQueue implements the `Queue` interface for items held in memory. Items do not survive restarts so it is
+// principally useful for testing.
+type MemoryQueue struct {
+	Queue
+	items map[string]*Item
+	mu    *sync.RWMutex
+}
+
+func init() {
+	ctx := context.Background()
+	RegisterQueue(ctx, "mem", NewMemoryQueue)
+}
+
+// NewMemoryQueue returns a new `MemoryQueue` instance configured by 'uri' which is expected to take the form of:
+//
+//	mem://
+func NewMemoryQueue(ctx context.Context, uri string) (Queue, error) {
+
+	q := &MemoryQueue{
+		items: make(map[string]*Item),
+		mu:    new(sync.RWMutex),
+	}
+
+	return q, nil
+}
+
+// Add adds 'i' to the queue, assigning its ID, status and creation time.
+func (q *MemoryQueue) Add(ctx context.Context, i *Item) error {
+
+	err := prepareItem(i)
+
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item := *i
+	q.items[i.Id] = &item
+
+	return nil
+}
+
+// Get returns the `Item` associated with 'id' and a boolean value indicating whether it exists.
+func (q *MemoryQueue) Get(ctx context.Context, id string) (*Item, bool, error) {
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	i, ok := q.items[id]
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	item := *i
+	return &item, true, nil
+}
+
+// Due returns the items which are due to be published at time 't', ordered by their send-at time.
+func (q *MemoryQueue) Due(ctx context.Context, t time.Time) ([]*Item, error) {
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	items := make([]*Item, 0)
+
+	for _, i := range q.items {
+
+		if i.IsDue(t) {
+			item := *i
+			items = append(items, &item)
+		}
+	}
+
+	sortItems(items)
+	return items, nil
+}
+
+// Update stores 'i' replacing the existing item with the same ID.
+func (q *MemoryQueue) Update(ctx context.Context, i *Item) error {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, ok := q.items[i.Id]
+
+	if !ok {
+		return fmt.Errorf("Item %s does not exist", i.Id)
+	}
+
+	i.Updated = time.Now()
+	dropImageData(i)
+
+	item := *i
+	q.items[i.Id] = &item
+
+	return nil
+}
+
+// Close is a no-op.
+func (q *MemoryQueue) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,259 @@
+// Package queue provides persistent queues for messages that are scheduled to be published at a later time.
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/aaronland/go-roster"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// STATUS_PENDING is the status of items waiting to be published.
+const STATUS_PENDING string = "pending"
+
+// STATUS_SENDING is the status of items that are being published. Items which are still "sending" when they
+// are next read from a queue were interrupted (for example because the process publishing them exited).
+const STATUS_SENDING string = "sending"
+
+// STATUS_SENT is the status of items that have been published.
+const STATUS_SENT string = "sent"
+
+// STATUS_FAILED is the status of items that could not be published.
+const STATUS_FAILED string = "failed"
+
+// STATUS_CANCELLED is the status of items that were cancelled before they were published.
+const STATUS_CANCELLED string = "cancelled"
+
+// DEFAULT_RETENTION is the default amount of time that items which have been sent, failed or were cancelled are kept
+// by queues which support pruning them.
+const DEFAULT_RETENTION time.Duration = 7 * 24 * time.Hour
+
+// Image is an encoded image included with a queued message.
+type Image struct {
+	// Body is the encoded image data.
+	Body []byte `json:"body"`
+	// ContentType is the MIME type of the encoded image data.
+	ContentType string `json:"content_type"`
+	// AltText is the (optional) description of the image.
+	AltText string `json:"alt_text,omitempty"`
+	// Original is a boolean flag indicating whether Body is the original encoded image data, to be uploaded as-is,
+	// rather than an encoding of the decoded image.
+	Original bool `json:"original,omitempty"`
+}
+
+// Message is the serializable representation of a `broadcaster.Message` instance.
+type Message struct {
+	// Title is the title of the message.
+	Title string `json:"title,omitempty"`
+	// Body is the body of the message.
+	Body string `json:"body"`
+	// Images is zero or more images included with the message.
+	Images []*Image `json:"images,omitempty"`
+}
+
+// Item is a message scheduled to be published at a given time.
+type Item struct {
+	// Id is the unique identifier of the item. It is assigned by the queue when the item is added.
+	Id string `json:"id"`
+	// SendAt is the time after which the message should be published.
+	SendAt time.Time `json:"send_at"`
+	// Message is the message to publish.
+	Message *Message `json:"message"`
+	// Status is the status of the item, one of the STATUS_ constants.
+	Status string `json:"status"`
+	// Attempts is the number of times publishing the message has been attempted.
+	Attempts int `json:"attempts"`
+	// TweetIds is the list of IDs of the tweets published for the message, in order.
+	TweetIds []string `json:"tweet_ids,omitempty"`
+	// Error is the error returned by the last failed attempt to publish the message.
+	Error string `json:"error,omitempty"`
+	// Created is the time the item was added to the queue.
+	Created time.Time `json:"created"`
+	// Updated is the time the item was last updated.
+	Updated time.Time `json:"updated"`
+}
+
+// IsDue returns a boolean value indicating whether 'i' should be published at time 't'. That is, whether it is
+// pending (or was interrupted while sending) and its send-at time is not after 't'.
+func (i *Item) IsDue(t time.Time) bool {
+
+	switch i.Status {
+	case STATUS_PENDING, STATUS_SENDING:
+		return !i.SendAt.After(t)
+	default:
+		return false
+	}
+}
+
+// IsTerminal returns a boolean value indicating whether 'i' has been sent, failed or was cancelled. Terminal items
+// are never published again.
+func (i *Item) IsTerminal() bool {
+
+	switch i.Status {
+	case STATUS_SENT, STATUS_FAILED, STATUS_CANCELLED:
+		return true
+	default:
+		return false
+	}
+}
+
+// Queue is an interface for storing messages scheduled to be published at a later time.
+type Queue interface {
+	// Add adds an `Item` to the queue, assigning its ID, status and creation time.
+	Add(context.Context, *Item) error
+	// Get returns the `Item` associated with an ID and a boolean value indicating whether it exists.
+	Get(context.Context, string) (*Item, bool, error)
+	// Due returns the items which are due to be published at a given time, ordered by their send-at time.
+	Due(context.Context, time.Time) ([]*Item, error)
+	// Update stores an `Item` replacing the existing item with the same ID.
+	Update(context.Context, *Item) error
+	// Close releases any resources used by the queue.
+	Close(context.Context) error
+}
+
+var queue_roster roster.Roster
+
+// QueueInitializationFunc is a function defined by individual queue implementations and used to create
+// an instance of that queue.
+type QueueInitializationFunc func(ctx context.Context, uri string) (Queue, error)
+
+// RegisterQueue registers 'scheme' as a key pointing to 'init_func' in an internal lookup table
+// used to create new `Queue` instances by the `NewQueue` method.
+func RegisterQueue(ctx context.Context, scheme string, init_func QueueInitializationFunc) error {
+
+	err := ensureQueueRoster()
+
+	if err != nil {
+		return err
+	}
+
+	return queue_roster.Register(ctx, scheme, init_func)
+}
+
+func ensureQueueRoster() error {
+
+	if queue_roster == nil {
+
+		r, err := roster.NewDefaultRoster()
+
+		if err != nil {
+			return err
+		}
+
+		queue_roster = r
+	}
+
+	return nil
+}
+
+// NewQueue returns a new `Queue` instance configured by 'uri'. The value of 'uri' is parsed
+// as a `url.URL` and its scheme is used as the key for a corresponding `QueueInitializationFunc`
+// function used to instantiate the new `Queue`. It is assumed that the scheme (and initialization
+// function) have been registered by the `RegisterQueue` method.
+func NewQueue(ctx context.Context, uri string) (Queue, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = ensureQueueRoster()
+
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := queue_roster.Driver(ctx, u.Scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("Unsupported queue '%s', %w", u.Scheme, err)
+	}
+
+	init_func := i.(QueueInitializationFunc)
+	return init_func(ctx, uri)
+}
+
+// Schemes returns the list of schemes that have been registered.
+func Schemes() []string {
+
+	ctx := context.Background()
+	schemes := []string{}
+
+	err := ensureQueueRoster()
+
+	if err != nil {
+		return schemes
+	}
+
+	for _, dr := range queue_roster.Drivers(ctx) {
+		scheme := fmt.Sprintf("%s://", strings.ToLower(dr))
+		schemes = append(schemes, scheme)
+	}
+
+	sort.Strings(schemes)
+	return schemes
+}
+
+// prepareItem assigns a new ID, the pending status and creation time to 'i'.
+func prepareItem(i *Item) error {
+
+	if i.Message == nil {
+		return fmt.Errorf("Missing message")
+	}
+
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+
+	if err != nil {
+		return fmt.Errorf("Failed to generate item ID, %w", err)
+	}
+
+	now := time.Now()
+
+	i.Id = hex.EncodeToString(b)
+	i.Status = STATUS_PENDING
+	i.Created = now
+	i.Updated = now
+
+	return nil
+}
+
+// sortItems sorts 'items' by their send-at time and then their creation time.
+func sortItems(items []*Item) {
+
+	sort.Slice(items, func(a, b int) bool {
+
+		if !items[a].SendAt.Equal(items[b].SendAt) {
+			return items[a].SendAt.Before(items[b].SendAt)
+		}
+
+		return items[a].Created.Before(items[b].Created)
+	})
+}
+
+// dropImageData removes the encoded image data from the message of 'i', if it is terminal, since it will never be
+// published again. The remaining image properties (content type and alt text) are retained.
+func dropImageData(i *Item) {
+
+	if !i.IsTerminal() || i.Message == nil || len(i.Message.Images) == 0 {
+		return
+	}
+
+	msg := *i.Message
+	msg.Images = make([]*Image, len(i.Message.Images))
+
+	for idx, im := range i.Message.Images {
+		im_copy := *im
+		im_copy.Body = nil
+		msg.Images[idx] = &im_copy
+	}
+
+	i.Message = &msg
+}
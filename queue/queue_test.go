@@ -0,0 +1,287 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster-twitter/internal/jsonfile"
+)
+
+func testQueue(t *testing.T, q Queue) {
+
+	ctx := context.Background()
+	now := time.Now()
+
+	err := q.Add(ctx, &Item{SendAt: now})
+
+	if err == nil {
+		t.Fatalf("Expected item without a message to fail")
+	}
+
+	later := &Item{
+		SendAt:  now.Add(-time.Minute),
+		Message: &Message{Body: "later"},
+	}
+
+	earlier := &Item{
+		SendAt: now.Add(-time.Hour),
+		Message: &Message{
+			Body:   "earlier",
+			Images: []*Image{{Body: []byte("GIF89a"), ContentType: "image/gif", AltText: "a picture"}},
+		},
+	}
+
+	future := &Item{
+		SendAt:  now.Add(time.Hour),
+		Message: &Message{Body: "future"},
+	}
+
+	for _, i := range []*Item{later, earlier, future} {
+
+		err := q.Add(ctx, i)
+
+		if err != nil {
+			t.Fatalf("Failed to add item, %v", err)
+		}
+
+		if i.Id == "" || i.Status != STATUS_PENDING {
+			t.Fatalf("Expected item to be assigned an ID and pending status")
+		}
+	}
+
+	due, err := q.Due(ctx, now)
+
+	if err != nil {
+		t.Fatalf("Failed to get due items, %v", err)
+	}
+
+	if len(due) != 2 || due[0].Id != earlier.Id || due[1].Id != later.Id {
+		t.Fatalf("Expected 2 due items ordered by send-at time")
+	}
+
+	earlier.Status = STATUS_SENT
+	earlier.TweetIds = []string{"1234"}
+
+	err = q.Update(ctx, earlier)
+
+	if err != nil {
+		t.Fatalf("Failed to update item, %v", err)
+	}
+
+	i, exists, err := q.Get(ctx, earlier.Id)
+
+	if err != nil {
+		t.Fatalf("Failed to get item, %v", err)
+	}
+
+	if !exists || i.Status != STATUS_SENT || len(i.TweetIds) != 1 || i.Message.Body != "earlier" {
+		t.Fatalf("Unexpected item %v", i)
+	}
+
+	// Image data is dropped once an item is terminal but the remaining properties are kept
+
+	if len(i.Message.Images) != 1 || i.Message.Images[0].Body != nil || i.Message.Images[0].AltText != "a picture" {
+		t.Fatalf("Expected image data to be dropped from sent item")
+	}
+
+	due, err = q.Due(ctx, now)
+
+	if err != nil {
+		t.Fatalf("Failed to get due items, %v", err)
+	}
+
+	if len(due) != 1 || due[0].Id != later.Id {
+		t.Fatalf("Expected sent item not to be due")
+	}
+
+	// Interrupted items are due again
+
+	later.Status = STATUS_SENDING
+
+	err = q.Update(ctx, later)
+
+	if err != nil {
+		t.Fatalf("Failed to update item, %v", err)
+	}
+
+	due, err = q.Due(ctx, now.Add(2*time.Hour))
+
+	if err != nil {
+		t.Fatalf("Failed to get due items, %v", err)
+	}
+
+	if len(due) != 2 || due[0].Id != later.Id || due[1].Id != future.Id {
+		t.Fatalf("Expected interrupted and future items to be due")
+	}
+
+	_, exists, err = q.Get(ctx, "missing")
+
+	if err != nil {
+		t.Fatalf("Failed to get item, %v", err)
+	}
+
+	if exists {
+		t.Fatalf("Expected missing item not to exist")
+	}
+
+	err = q.Update(ctx, &Item{Id: "missing", Message: &Message{}})
+
+	if err == nil {
+		t.Fatalf("Expected updating a missing item to fail")
+	}
+
+	err = q.Close(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to close queue, %v", err)
+	}
+}
+
+func TestMemoryQueue(t *testing.T) {
+
+	ctx := context.Background()
+
+	q, err := NewQueue(ctx, "mem://")
+
+	if err != nil {
+		t.Fatalf("Failed to create queue, %v", err)
+	}
+
+	testQueue(t, q)
+}
+
+func TestFileQueue(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "queue.json")
+	uri := fmt.Sprintf("file://%s", path)
+
+	q, err := NewQueue(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create queue, %v", err)
+	}
+
+	testQueue(t, q)
+
+	// Items are persisted between instances
+
+	q, _ = NewQueue(ctx, uri)
+
+	i := &Item{
+		SendAt:  time.Now(),
+		Message: &Message{Body: "hello world"},
+	}
+
+	err = q.Add(ctx, i)
+
+	if err != nil {
+		t.Fatalf("Failed to add item, %v", err)
+	}
+
+	q2, err := NewQueue(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create queue, %v", err)
+	}
+
+	_, exists, err := q2.Get(ctx, i.Id)
+
+	if err != nil || !exists {
+		t.Fatalf("Expected item to be persisted, %v", err)
+	}
+}
+
+func TestFileQueueRetention(t *testing.T) {
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "queue.json")
+	uri := fmt.Sprintf("file://%s?retention=1h", path)
+
+	q, err := NewQueue(ctx, uri)
+
+	if err != nil {
+		t.Fatalf("Failed to create queue, %v", err)
+	}
+
+	sent := &Item{SendAt: time.Now(), Message: &Message{Body: "sent"}}
+	pending := &Item{SendAt: time.Now(), Message: &Message{Body: "pending"}}
+
+	for _, i := range []*Item{sent, pending} {
+
+		err := q.Add(ctx, i)
+
+		if err != nil {
+			t.Fatalf("Failed to add item, %v", err)
+		}
+	}
+
+	sent.Status = STATUS_SENT
+
+	err = q.Update(ctx, sent)
+
+	if err != nil {
+		t.Fatalf("Failed to update item, %v", err)
+	}
+
+	// Age both items beyond the retention period by rewriting the file
+
+	items := make(map[string]*Item)
+
+	err = jsonfile.Read(path, &items)
+
+	if err != nil {
+		t.Fatalf("Failed to read queue, %v", err)
+	}
+
+	for _, i := range items {
+		i.Updated = time.Now().Add(-2 * time.Hour)
+	}
+
+	err = jsonfile.Write(path, items)
+
+	if err != nil {
+		t.Fatalf("Failed to write queue, %v", err)
+	}
+
+	err = q.Add(ctx, &Item{SendAt: time.Now(), Message: &Message{Body: "new"}})
+
+	if err != nil {
+		t.Fatalf("Failed to add item, %v", err)
+	}
+
+	_, exists, err := q.Get(ctx, sent.Id)
+
+	if err != nil || exists {
+		t.Fatalf("Expected sent item older than the retention period to be pruned, %v", err)
+	}
+
+	_, exists, err = q.Get(ctx, pending.Id)
+
+	if err != nil || !exists {
+		t.Fatalf("Expected pending item to be kept, %v", err)
+	}
+
+	for _, invalid := range []string{"retention=forever", "retention=-1h"} {
+
+		_, err := NewQueue(ctx, fmt.Sprintf("file://%s?%s", path, invalid))
+
+		if err == nil {
+			t.Fatalf("Expected ?%s to be invalid", invalid)
+		}
+	}
+}
+
+func TestNewQueueUnsupported(t *testing.T) {
+
+	_, err := NewQueue(context.Background(), "sqlite:///tmp/queue.db")
+
+	if err == nil {
+		t.Fatalf("Expected unsupported scheme to fail")
+	}
+}
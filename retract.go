@@ -0,0 +1,197 @@
+package twitter
+
+// https://developer.twitter.com/en/docs/twitter-api/tweets/manage-tweets/api-reference/delete-tweets-id
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-uid"
+	"strconv"
+	"strings"
+)
+
+// Retract deletes the tweet, or every tweet in the thread, identified by 'u' which is expected to be a value returned
+// by the `BroadcastMessage` method (or a `uid.MultiUID` instance wrapping one, as returned by `broadcaster.MultiBroadcaster`).
+// Threads are deleted in reverse order so that replies are removed before the tweets they reply to. Tweets which
+// have already been deleted are skipped. Messages which were scheduled (see `ScheduleMessage`) are cancelled or, if they
+// have already been published, retracted. If the credentials contain multiple accounts the tweets are deleted from the
+// accounts identified by the `AccountUID` instances in 'u'. Once the tweets have been deleted the records for them in the
+// dedupe store, if present, are removed so that the same message can be published again.
+func (b *TwitterBroadcaster) Retract(ctx context.Context, u uid.UID) error {
+
+	if b.accounts != nil {
+		return b.retractAccounts(ctx, u)
+	}
+
+	tweet_ids, err := tweetIdsFromUID(u)
+
+	if err != nil {
+		return err
+	}
+
+	if len(tweet_ids) == 0 {
+		return fmt.Errorf("UID does not contain any tweet IDs")
+	}
+
+	tw_client, release_client := b.acquireClient()
+	defer release_client()
+
+	for i := len(tweet_ids) - 1; i >= 0; i-- {
+
+		tweet_id := tweet_ids[i]
+
+		if strings.HasPrefix(tweet_id, SCHEDULED_ID_PREFIX+"-") {
+
+			err := b.cancelScheduled(ctx, strings.TrimPrefix(tweet_id, SCHEDULED_ID_PREFIX+"-"))
+
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err := tw_client.DeleteTweet(ctx, tweet_id)
+
+		if err != nil {
+
+			if errors.Is(err, ErrNotFound) {
+				b.logger.Printf("Tweet %s does not exist or has already been deleted, skipping", tweet_id)
+				continue
+			}
+
+			return fmt.Errorf("Failed to delete tweet %s, %w", tweet_id, err)
+		}
+
+		b.logger.Printf("twitter delete %s", tweet_id)
+	}
+
+	b.forgetPublished(ctx, tweet_ids)
+	return nil
+}
+
+// forgetPublished removes the records for the messages which published 'tweet_ids' from the dedupe store, if present.
+func (b *TwitterBroadcaster) forgetPublished(ctx context.Context, tweet_ids []string) {
+
+	if b.dedupe == nil {
+		return
+	}
+
+	for _, tweet_id := range tweet_ids {
+
+		r, exists, err := b.dedupe.GetByTweetId(ctx, tweet_id)
+
+		if err != nil {
+			b.logger.Printf("Warning: failed to query dedupe store for %s, %v", tweet_id, err)
+			continue
+		}
+
+		if !exists {
+			continue
+		}
+
+		err = b.dedupe.Delete(ctx, r.Key)
+
+		if err != nil {
+			b.logger.Printf("Warning: failed to remove %s from dedupe store, %v", strings.Join(r.TweetIds, ","), err)
+		}
+	}
+}
+
+// Correct publishes 'msg' as a correction of the tweet (or thread) identified by 'u' and then retracts the original.
+// If 'correction_uri' is not empty a "Correction: {URI}" line, linking to details about the correction, is appended
+// to the body of 'msg'. The correction is published before the original is deleted so that a failure to publish
+// leaves the original in place. If the original can not be retracted the UID of the correction is returned along
+// with the error. Once the original has been retracted its record in the dedupe store, if present, is removed.
+func (b *TwitterBroadcaster) Correct(ctx context.Context, u uid.UID, msg *broadcaster.Message, correction_uri string) (uid.UID, error) {
+
+	original_ids, err := tweetIdsFromUID(u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	corrected_msg := &broadcaster.Message{
+		Title:  msg.Title,
+		Body:   msg.Body,
+		Images: msg.Images,
+	}
+
+	if correction_uri != "" {
+		corrected_msg.Body = fmt.Sprintf("%s\n\nCorrection: %s", strings.TrimSpace(msg.Body), correction_uri)
+	}
+
+	corrected_uid, err := b.BroadcastMessage(ctx, corrected_msg)
+
+	if err != nil {
+		// corrected_uid is not nil if the correction was published to some, but not all, accounts
+		return corrected_uid, fmt.Errorf("Failed to publish correction, %w", err)
+	}
+
+	corrected_ids, err := tweetIdsFromUID(corrected_uid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The dedupe store will return the original tweet IDs if the correction is identical
+	// to the original in which case retracting them would delete the "correction"
+
+	for _, id := range corrected_ids {
+
+		for _, other := range original_ids {
+
+			if id == other {
+				return nil, fmt.Errorf("Correction is identical to the original message (%s)", id)
+			}
+		}
+	}
+
+	err = b.Retract(ctx, u)
+
+	if err != nil {
+		return corrected_uid, fmt.Errorf("Published correction %s but failed to retract original, %w", corrected_uid, err)
+	}
+
+	return corrected_uid, nil
+}
+
+// tweetIdsFromUID returns the list of (string) tweet IDs contained in 'u', flattening nested `uid.MultiUID` instances.
+func tweetIdsFromUID(u uid.UID) ([]string, error) {
+
+	if u == nil {
+		return nil, fmt.Errorf("Invalid UID")
+	}
+
+	switch v := u.Value().(type) {
+	case int64:
+		return []string{strconv.FormatInt(v, 10)}, nil
+	case string:
+
+		if v == "" {
+			return nil, fmt.Errorf("Invalid UID, empty tweet ID")
+		}
+
+		return []string{v}, nil
+	case []uid.UID:
+
+		tweet_ids := make([]string, 0)
+
+		for _, child := range v {
+
+			ids, err := tweetIdsFromUID(child)
+
+			if err != nil {
+				return nil, err
+			}
+
+			tweet_ids = append(tweet_ids, ids...)
+		}
+
+		return tweet_ids, nil
+	default:
+		return nil, fmt.Errorf("Unsupported UID type %T", u)
+	}
+}
@@ -0,0 +1,125 @@
+package twitter_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/aaronland/go-broadcaster"
+)
+
+func TestRetract(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("dedupe", "mem://")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+	}
+
+	u, err := br.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	tw, exists := s.Tweet(u.String())
+
+	if !exists {
+		t.Fatalf("Expected tweet %s to exist", u.String())
+	}
+
+	err = br.Retract(ctx, u)
+
+	if err != nil {
+		t.Fatalf("Failed to retract %s, %v", u.String(), err)
+	}
+
+	tw, _ = s.Tweet(u.String())
+
+	if !tw.Deleted {
+		t.Fatalf("Expected tweet %s to be deleted", u.String())
+	}
+
+	// Retracting a tweet which has already been deleted is not an error
+
+	err = br.Retract(ctx, u)
+
+	if err != nil {
+		t.Fatalf("Failed to retract deleted tweet %s, %v", u.String(), err)
+	}
+
+	// The dedupe record was removed so the same message can be published again
+
+	u2, err := br.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message again, %v", err)
+	}
+
+	if u2.String() == u.String() {
+		t.Fatalf("Expected a new tweet, got the retracted tweet %s", u.String())
+	}
+}
+
+func TestCorrect(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("dedupe", "mem://")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	msg := &broadcaster.Message{
+		Body: "hello wrold",
+	}
+
+	u, err := br.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	corrected := &broadcaster.Message{
+		Body: "hello world",
+	}
+
+	corrected_uid, err := br.Correct(ctx, u, corrected, "https://example.com/corrections/1")
+
+	if err != nil {
+		t.Fatalf("Failed to correct message, %v", err)
+	}
+
+	original, _ := s.Tweet(u.String())
+
+	if !original.Deleted {
+		t.Fatalf("Expected original tweet to be deleted")
+	}
+
+	correction, exists := s.Tweet(corrected_uid.String())
+
+	if !exists || correction.Deleted {
+		t.Fatalf("Expected correction %s to exist", corrected_uid.String())
+	}
+
+	// The original message can be published again once it has been corrected
+
+	u2, err := br.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast original message again, %v", err)
+	}
+
+	if u2.String() == u.String() {
+		t.Fatalf("Expected a new tweet, got the retracted tweet %s", u.String())
+	}
+}
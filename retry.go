@@ -0,0 +1,273 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ChimeraCoder/anaconda"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// DEFAULT_MAX_RETRIES is the default number of times a failed API request is retried.
+const DEFAULT_MAX_RETRIES int = 3
+
+// DEFAULT_MAX_WAIT is the default maximum amount of time to wait before retrying a failed API request.
+const DEFAULT_MAX_WAIT time.Duration = 5 * time.Minute
+
+// retry_initial_backoff is the (pre-jitter) amount of time to wait before the first retry of a server or network error.
+const retry_initial_backoff time.Duration = time.Second
+
+// retry_max_backoff_shift is the largest number of times the initial backoff is doubled, so that the backoff for
+// large attempt counts does not overflow.
+const retry_max_backoff_shift int = 30
+
+// retry_reset_margin is added to rate limit reset times to allow for clock skew.
+const retry_reset_margin time.Duration = time.Second
+
+// retryClient implements the `client` interface by wrapping another `client` and retrying media uploads and
+// tweets which fail because of rate limits, server errors or network errors.
+type retryClient struct {
+	client
+	max_retries int
+	max_wait    time.Duration
+	logger      *log.Logger
+}
+
+func newRetryClient(ctx context.Context, c client, max_retries int, max_wait time.Duration, logger *log.Logger) (*retryClient, error) {
+
+	if max_retries < 0 {
+		return nil, fmt.Errorf("Invalid max retries (%d), must not be negative", max_retries)
+	}
+
+	if max_wait <= 0 {
+		return nil, fmt.Errorf("Invalid max wait (%v), must be greater than zero", max_wait)
+	}
+
+	r := &retryClient{
+		client:      c,
+		max_retries: max_retries,
+		max_wait:    max_wait,
+		logger:      logger,
+	}
+
+	return r, nil
+}
+
+// UploadMedia uploads 'body' whose content type is 'content_type' and returns its media ID.
+func (r *retryClient) UploadMedia(ctx context.Context, body []byte, content_type string) (string, error) {
+
+	var media_id string
+
+	err := r.retry(ctx, "upload media", true, func() error {
+
+		id, err := r.client.UploadMedia(ctx, body, content_type)
+
+		if err != nil {
+			return err
+		}
+
+		media_id = id
+		return nil
+	})
+
+	return media_id, err
+}
+
+// CreateMediaMetadata associates 'alt_text' with the previously uploaded media 'media_id'.
+func (r *retryClient) CreateMediaMetadata(ctx context.Context, media_id string, alt_text string) error {
+
+	return r.retry(ctx, "create media metadata", true, func() error {
+		return r.client.CreateMediaMetadata(ctx, media_id, alt_text)
+	})
+}
+
+// PostTweet publishes 'tw' and returns the ID of the new tweet. Since posting a tweet is not idempotent it is only
+// retried if the request was rate limited or could not be sent at all; server errors and network errors which occur
+// after the request was sent are returned immediately because the tweet may already have been published.
+func (r *retryClient) PostTweet(ctx context.Context, tw *tweetRequest) (string, error) {
+
+	var tweet_id string
+
+	err := r.retry(ctx, "post tweet", false, func() error {
+
+		id, err := r.client.PostTweet(ctx, tw)
+
+		if err != nil {
+			return err
+		}
+
+		tweet_id = id
+		return nil
+	})
+
+	return tweet_id, err
+}
+
+// DeleteTweet deletes the tweet 'tweet_id'.
+func (r *retryClient) DeleteTweet(ctx context.Context, tweet_id string) error {
+
+	return r.retry(ctx, "delete tweet", true, func() error {
+		return r.client.DeleteTweet(ctx, tweet_id)
+	})
+}
+
+// retry calls 'fn' until it succeeds, it fails with an error that is not transient or `r.max_retries` retries
+// have been attempted. Rate limited requests are retried after the rate limit resets; server and network errors
+// are retried using a jittered exponential backoff. If the time to wait before the next retry exceeds `r.max_wait`
+// the error is returned immediately. If 'idempotent' is false only errors which guarantee that the request was not
+// processed (see `isUnprocessedError`) are retried.
+func (r *retryClient) retry(ctx context.Context, label string, idempotent bool, fn func() error) error {
+
+	for attempt := 0; ; attempt++ {
+
+		err := fn()
+
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= r.max_retries {
+
+			if attempt > 0 {
+				return fmt.Errorf("Failed to %s after %d retries, %w", label, attempt, err)
+			}
+
+			return err
+		}
+
+		wait, ok := retryDelay(err, attempt, r.max_wait)
+
+		if ok && !idempotent {
+			ok = isUnprocessedError(err)
+		}
+
+		if !ok {
+			return err
+		}
+
+		if wait > r.max_wait {
+			return fmt.Errorf("Failed to %s, next retry in %v exceeds maximum wait of %v, %w", label, wait.Round(time.Second), r.max_wait, err)
+		}
+
+		r.logger.Printf("Failed to %s (attempt %d of %d), retrying in %v, %v", label, attempt+1, r.max_retries+1, wait.Round(time.Millisecond), err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Failed to %s, %w (while waiting to retry after %v)", label, ctx.Err(), err)
+		case <-time.After(wait):
+			// pass
+		}
+	}
+}
+
+// retryDelay returns the amount of time to wait before retrying a request that failed with 'err' after
+// 'attempt' previous retries and a boolean value indicating whether the request should be retried at all.
+// Exponential backoffs are capped at 'max_wait'; rate limit resets are not.
+func retryDelay(err error, attempt int, max_wait time.Duration) (time.Duration, bool) {
+
+	if !isTransientError(err) {
+		return 0, false
+	}
+
+	reset, ok := rateLimitReset(err)
+
+	if ok {
+
+		wait := time.Until(reset) + retry_reset_margin
+
+		if wait < retry_reset_margin {
+			wait = retry_reset_margin
+		}
+
+		return wait, true
+	}
+
+	shift := attempt
+
+	if shift > retry_max_backoff_shift {
+		shift = retry_max_backoff_shift
+	}
+
+	backoff := retry_initial_backoff * time.Duration(1<<uint(shift))
+
+	if backoff > max_wait {
+		backoff = max_wait
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(backoff)))
+
+	return backoff/2 + jitter/2, true
+}
+
+// isUnprocessedError returns a boolean value indicating whether 'err' guarantees that the request which caused it
+// was not processed: rate limits and failures to establish a connection. Other transient errors, for example server
+// errors, timeouts or connections that were reset, are ambiguous because the request may have succeeded anyway.
+func isUnprocessedError(err error) bool {
+
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+
+	var api_err *APIError
+
+	if errors.As(err, &api_err) {
+		return api_err.StatusCode == http.StatusTooManyRequests
+	}
+
+	var anaconda_err *anaconda.ApiError
+
+	if errors.As(err, &anaconda_err) {
+		return anaconda_err.StatusCode == http.StatusTooManyRequests
+	}
+
+	var op_err *net.OpError
+
+	if errors.As(err, &op_err) && op_err.Op == "dial" {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// rateLimitReset returns the time at which the rate limit that caused 'err' resets and a boolean value indicating
+// whether 'err' is a rate limit error whose reset time is known.
+func rateLimitReset(err error) (time.Time, bool) {
+
+	var api_err *APIError
+
+	if errors.As(err, &api_err) {
+
+		if api_err.StatusCode != http.StatusTooManyRequests {
+			return time.Time{}, false
+		}
+
+		str_reset := api_err.Header.Get("x-rate-limit-reset")
+
+		if str_reset == "" {
+			return time.Time{}, false
+		}
+
+		reset, err := strconv.ParseInt(str_reset, 10, 64)
+
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return time.Unix(reset, 0), true
+	}
+
+	var anaconda_err *anaconda.ApiError
+
+	if errors.As(err, &anaconda_err) {
+		is_ratelimit, reset := anaconda_err.RateLimitCheck()
+		return reset, is_ratelimit
+	}
+
+	return time.Time{}, false
+}
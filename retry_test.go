@@ -0,0 +1,314 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+
+	h := http.Header{}
+	h.Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+
+	wait, ok := retryDelay(&APIError{StatusCode: http.StatusTooManyRequests, Header: h}, 0, DEFAULT_MAX_WAIT)
+
+	if !ok || wait < 30*time.Second || wait > time.Minute+2*retry_reset_margin {
+		t.Fatalf("Expected rate limit to be retried when it resets, got %v (%t)", wait, ok)
+	}
+
+	// Rate limits which have already reset are retried after the margin
+
+	h.Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+
+	wait, ok = retryDelay(&APIError{StatusCode: http.StatusTooManyRequests, Header: h}, 0, DEFAULT_MAX_WAIT)
+
+	if !ok || wait != retry_reset_margin {
+		t.Fatalf("Expected rate limit to be retried after %v, got %v (%t)", retry_reset_margin, wait, ok)
+	}
+
+	for attempt := 0; attempt < 4; attempt++ {
+
+		backoff := retry_initial_backoff * time.Duration(1<<uint(attempt))
+
+		wait, ok = retryDelay(&APIError{StatusCode: http.StatusInternalServerError}, attempt, DEFAULT_MAX_WAIT)
+
+		if !ok || wait < backoff/2 || wait > backoff {
+			t.Fatalf("Expected attempt %d to be retried after %v-%v, got %v (%t)", attempt, backoff/2, backoff, wait, ok)
+		}
+	}
+
+	// Backoffs for large attempt counts are capped at the maximum wait rather than overflowing
+
+	for _, attempt := range []int{30, 62, 63, 64, 1000} {
+
+		wait, ok = retryDelay(&APIError{StatusCode: http.StatusInternalServerError}, attempt, time.Minute)
+
+		if !ok || wait < 30*time.Second || wait > time.Minute {
+			t.Fatalf("Expected attempt %d to be retried after 30s-1m, got %v (%t)", attempt, wait, ok)
+		}
+	}
+
+	_, ok = retryDelay(&APIError{StatusCode: http.StatusBadRequest}, 0, DEFAULT_MAX_WAIT)
+
+	if ok {
+		t.Fatalf("Expected bad request not to be retried")
+	}
+}
+
+func TestRetryClientMaxRetries(t *testing.T) {
+
+	ctx := context.Background()
+
+	posts := 0
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+		posts += 1
+		http.Error(rsp, "Internal server error", http.StatusInternalServerError)
+	})
+
+	r, err := newRetryClient(ctx, c, 1, time.Minute, log.New(io.Discard, "", 0))
+
+	if err != nil {
+		t.Fatalf("Failed to create retry client, %v", err)
+	}
+
+	err = r.DeleteTweet(ctx, "1234")
+
+	var api_err *APIError
+
+	if !errors.As(err, &api_err) || api_err.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected server error, got %v", err)
+	}
+
+	if posts != 2 {
+		t.Fatalf("Expected 1 retry, got %d requests", posts)
+	}
+}
+
+func TestRetryClientMaxWait(t *testing.T) {
+
+	ctx := context.Background()
+
+	posts := 0
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+		posts += 1
+		rsp.Header().Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		http.Error(rsp, "Too many requests", http.StatusTooManyRequests)
+	})
+
+	r, err := newRetryClient(ctx, c, 3, time.Minute, log.New(io.Discard, "", 0))
+
+	if err != nil {
+		t.Fatalf("Failed to create retry client, %v", err)
+	}
+
+	_, err = r.PostTweet(ctx, &tweetRequest{Text: "hello world"})
+
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+
+	if posts != 1 {
+		t.Fatalf("Expected rate limit resetting after the maximum wait not to be retried, got %d requests", posts)
+	}
+}
+
+func TestNewRetryClientInvalid(t *testing.T) {
+
+	ctx := context.Background()
+	c := newTestV2Client(t, http.NotFound)
+
+	_, err := newRetryClient(ctx, c, -1, time.Minute, log.New(io.Discard, "", 0))
+
+	if err == nil {
+		t.Fatalf("Expected negative max retries to be invalid")
+	}
+
+	_, err = newRetryClient(ctx, c, 3, 0, log.New(io.Discard, "", 0))
+
+	if err == nil {
+		t.Fatalf("Expected zero max wait to be invalid")
+	}
+}
+
+type timeoutError struct{}
+
+func (e *timeoutError) Error() string   { return "i/o timeout" }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+func TestIsTransientError(t *testing.T) {
+
+	transient := []error{
+		&APIError{StatusCode: http.StatusTooManyRequests},
+		&APIError{StatusCode: http.StatusServiceUnavailable},
+		fmt.Errorf("Failed to post tweet, %w", &APIError{StatusCode: http.StatusInternalServerError}),
+		&RateLimitError{Err: ErrRateLimited},
+		&url.Error{Op: "Post", URL: "https://api.twitter.com/2/tweets", Err: &timeoutError{}},
+		&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+		fmt.Errorf("Failed to execute request, %w", syscall.ECONNRESET),
+		fmt.Errorf("Failed to read response, %w", io.ErrUnexpectedEOF),
+	}
+
+	for _, err := range transient {
+
+		if !isTransientError(err) {
+			t.Fatalf("Expected error to be transient, %v", err)
+		}
+	}
+
+	permanent := []error{
+		nil,
+		context.Canceled,
+		context.DeadlineExceeded,
+		&APIError{StatusCode: http.StatusBadRequest},
+		&APIError{StatusCode: http.StatusForbidden},
+		&Error{Kind: ErrMediaRejected, Err: fmt.Errorf("Failed to process media 1234")},
+		fmt.Errorf("Failed to unmarshal response, %w", errors.New("unexpected end of JSON input")),
+		fmt.Errorf("Failed to post tweet, response is missing tweet ID"),
+		fmt.Errorf("Invalid tweet ID 'abc'"),
+	}
+
+	for _, err := range permanent {
+
+		if isTransientError(err) {
+			t.Fatalf("Expected error to be permanent, %v", err)
+		}
+	}
+}
+
+func TestRetryClientPermanentError(t *testing.T) {
+
+	ctx := context.Background()
+
+	posts := 0
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+		posts += 1
+		rsp.WriteHeader(http.StatusOK)
+	})
+
+	r, err := newRetryClient(ctx, c, 3, time.Minute, log.New(io.Discard, "", 0))
+
+	if err != nil {
+		t.Fatalf("Failed to create retry client, %v", err)
+	}
+
+	_, err = r.PostTweet(ctx, &tweetRequest{Text: "hello world"})
+
+	if err == nil {
+		t.Fatalf("Expected PostTweet to fail")
+	}
+
+	if posts != 1 {
+		t.Fatalf("Expected permanent error not to be retried, got %d requests", posts)
+	}
+}
+
+func TestRetryClientTransientError(t *testing.T) {
+
+	ctx := context.Background()
+
+	posts := 0
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+
+		posts += 1
+
+		if posts == 1 {
+			http.Error(rsp, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		rsp.Write([]byte(`{"data":{"id":"1234","text":"hello world"}}`))
+	})
+
+	r, err := newRetryClient(ctx, c, 3, time.Minute, log.New(io.Discard, "", 0))
+
+	if err != nil {
+		t.Fatalf("Failed to create retry client, %v", err)
+	}
+
+	id, err := r.PostTweet(ctx, &tweetRequest{Text: "hello world"})
+
+	if err != nil {
+		t.Fatalf("Expected transient error to be retried, %v", err)
+	}
+
+	if id != "1234" || posts != 2 {
+		t.Fatalf("Unexpected result, tweet ID %s after %d requests", id, posts)
+	}
+}
+
+func TestIsUnprocessedError(t *testing.T) {
+
+	unprocessed := []error{
+		&APIError{StatusCode: http.StatusTooManyRequests},
+		&RateLimitError{Err: ErrRateLimited},
+		&url.Error{Op: "Post", URL: "https://api.twitter.com/2/tweets", Err: &net.OpError{Op: "dial", Net: "tcp", Err: &timeoutError{}}},
+		fmt.Errorf("Failed to execute request, %w", syscall.ECONNREFUSED),
+	}
+
+	for _, err := range unprocessed {
+
+		if !isUnprocessedError(err) {
+			t.Fatalf("Expected error to be unprocessed, %v", err)
+		}
+	}
+
+	ambiguous := []error{
+		&APIError{StatusCode: http.StatusInternalServerError},
+		&APIError{StatusCode: http.StatusServiceUnavailable},
+		&url.Error{Op: "Post", URL: "https://api.twitter.com/2/tweets", Err: &timeoutError{}},
+		&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET},
+		fmt.Errorf("Failed to read response, %w", io.ErrUnexpectedEOF),
+	}
+
+	for _, err := range ambiguous {
+
+		if isUnprocessedError(err) {
+			t.Fatalf("Expected error to be ambiguous, %v", err)
+		}
+	}
+}
+
+func TestRetryClientAmbiguousError(t *testing.T) {
+
+	ctx := context.Background()
+
+	posts := 0
+
+	c := newTestV2Client(t, func(rsp http.ResponseWriter, req *http.Request) {
+		posts += 1
+		http.Error(rsp, "Bad gateway", http.StatusBadGateway)
+	})
+
+	r, err := newRetryClient(ctx, c, 3, time.Minute, log.New(io.Discard, "", 0))
+
+	if err != nil {
+		t.Fatalf("Failed to create retry client, %v", err)
+	}
+
+	_, err = r.PostTweet(ctx, &tweetRequest{Text: "hello world"})
+
+	var api_err *APIError
+
+	if !errors.As(err, &api_err) || api_err.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Expected server error, got %v", err)
+	}
+
+	if posts != 1 {
+		t.Fatalf("Expected server error not to be retried when posting a tweet, got %d requests", posts)
+	}
+}
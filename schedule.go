@@ -0,0 +1,477 @@
+package twitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-broadcaster-twitter/queue"
+	"github.com/aaronland/go-uid"
+	"image"
+	"image/png"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SCHEDULED_ID_PREFIX is the prefix for the `uid.StringUID` values returned for messages added to the schedule queue.
+const SCHEDULED_ID_PREFIX string = "scheduled"
+
+type sendAtKey struct{}
+
+// WithSendAt returns a copy of 'ctx' carrying the time 't' after which messages broadcast using that context should
+// be published. If 't' is in the future `TwitterBroadcaster.BroadcastMessage` adds messages to the queue defined by
+// the `?schedule=` parameter rather than publishing them immediately.
+func WithSendAt(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, sendAtKey{}, t)
+}
+
+// sendAtFromContext returns the send-at time carried by 'ctx' and a boolean value indicating whether it exists.
+func sendAtFromContext(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(sendAtKey{}).(time.Time)
+	return t, ok
+}
+
+// ScheduleMessage adds 'msg' to the queue defined by the `?schedule=` parameter to be published after 'send_at' and
+// returns a `uid.StringUID` identifying the queued item. The message is validated, and its images prepared, when it is
+// published rather than when it is scheduled. Scheduled messages can be cancelled by passing the UID to `Retract`.
+func (b *TwitterBroadcaster) ScheduleMessage(ctx context.Context, msg *broadcaster.Message, send_at time.Time) (uid.UID, error) {
+
+	if b.schedule == nil {
+		return nil, fmt.Errorf("Scheduling messages requires the ?schedule= parameter")
+	}
+
+	q_msg, err := newQueueMessage(msg)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to prepare message for queue, %w", err)
+	}
+
+	if b.mode == MODE_DRYRUN {
+		b.logger.Printf("[dry-run] schedule message for %s", send_at.Format(time.RFC3339))
+		return uid.NewStringUID(ctx, fmt.Sprintf("%s-%s-%d", SCHEDULED_ID_PREFIX, DRYRUN_ID_PREFIX, time.Now().UnixNano()))
+	}
+
+	item := &queue.Item{
+		SendAt:  send_at,
+		Message: q_msg,
+	}
+
+	err = b.schedule.Add(ctx, item)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to add message to queue, %w", err)
+	}
+
+	b.logger.Printf("Scheduled message %s for %s", item.Id, send_at.Format(time.RFC3339))
+
+	return uid.NewStringUID(ctx, scheduledId(item.Id))
+}
+
+// PublishScheduled publishes every message in the schedule queue which is due and returns the number of messages
+// processed. The outcome of each message (its tweet IDs or the error publishing it) is recorded in the queue. Messages
+// which were interrupted while being published, for example because the process exited, are published again so
+// applications should also set the `?dedupe=` parameter to ensure those messages are not published twice. Messages
+// which fail because of a rate limit are left in the queue to be retried. If the credentials contain multiple accounts the
+// accounts a message has been published to are recorded, even if publishing to other accounts fails, and messages which
+// are retried are only published to the remaining accounts.
+func (b *TwitterBroadcaster) PublishScheduled(ctx context.Context) (int, error) {
+
+	if b.schedule == nil {
+		return 0, fmt.Errorf("Publishing scheduled messages requires the ?schedule= parameter")
+	}
+
+	items, err := b.schedule.Due(ctx, time.Now())
+
+	if err != nil {
+		return 0, fmt.Errorf("Failed to retrieve scheduled messages, %w", err)
+	}
+
+	count := 0
+
+	for _, item := range items {
+
+		if item.Status == queue.STATUS_SENDING {
+			b.logger.Printf("Warning: scheduled message %s was interrupted while being published, trying again", item.Id)
+		}
+
+		item.Status = queue.STATUS_SENDING
+		item.Attempts += 1
+
+		err := b.schedule.Update(ctx, item)
+
+		if err != nil {
+			return count, fmt.Errorf("Failed to update scheduled message %s, %w", item.Id, err)
+		}
+
+		publish_err := b.publishScheduledItem(ctx, item)
+
+		switch {
+		case publish_err == nil:
+			item.Status = queue.STATUS_SENT
+			item.Error = ""
+			b.logger.Printf("Published scheduled message %s as %s", item.Id, strings.Join(item.TweetIds, ","))
+		case errors.Is(publish_err, context.Canceled), errors.Is(publish_err, context.DeadlineExceeded), errors.Is(publish_err, ErrRateLimited):
+			item.Status = queue.STATUS_PENDING
+			item.Error = publish_err.Error()
+			b.logger.Printf("Failed to publish scheduled message %s, will try again, %v", item.Id, publish_err)
+		default:
+			item.Status = queue.STATUS_FAILED
+			item.Error = publish_err.Error()
+			b.logger.Printf("Failed to publish scheduled message %s, %v", item.Id, publish_err)
+		}
+
+		// Use a fresh context so that the outcome is recorded even if 'ctx' has been cancelled
+
+		update_ctx, update_cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = b.schedule.Update(update_ctx, item)
+		update_cancel()
+
+		if err != nil {
+			return count, fmt.Errorf("Failed to record outcome of scheduled message %s, %w", item.Id, err)
+		}
+
+		count += 1
+
+		if ctx.Err() != nil {
+			return count, ctx.Err()
+		}
+	}
+
+	return count, nil
+}
+
+// publishScheduledItem publishes the message in 'item', assigning the resulting tweet IDs to 'item'.
+func (b *TwitterBroadcaster) publishScheduledItem(ctx context.Context, item *queue.Item) error {
+
+	msg, err := messageFromQueue(ctx, item.Message)
+
+	if err != nil {
+		return fmt.Errorf("Failed to derive message from queue, %w", err)
+	}
+
+	// Clear any send-at time, and wait for the posting budget if throttled, so the message is not scheduled (again)
+	ctx = WithSendAt(ctx, time.Time{})
+	ctx = context.WithValue(ctx, throttleBlockKey{}, true)
+
+	if b.accounts != nil {
+		return b.publishScheduledAccounts(ctx, item, msg)
+	}
+
+	u, err := b.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+
+		// Record the tweets for a partially published thread
+		if u != nil {
+
+			tweet_ids, id_err := queueTweetIds(u)
+
+			if id_err == nil {
+				item.TweetIds = tweet_ids
+			}
+		}
+
+		return err
+	}
+
+	tweet_ids, err := queueTweetIds(u)
+
+	if err != nil {
+		return err
+	}
+
+	item.TweetIds = tweet_ids
+	return nil
+}
+
+// publishScheduledAccounts publishes 'msg', derived from 'item', to each of the accounts for 'b' which it has not already
+// been published to by a previous attempt and records the tweet IDs for every account it has been published to in 'item'.
+// Accounts for which a thread was only partially published are considered to have been published to.
+func (b *TwitterBroadcaster) publishScheduledAccounts(ctx context.Context, item *queue.Item, msg *broadcaster.Message) error {
+
+	published := make(map[string][]string)
+
+	if len(item.TweetIds) > 0 {
+
+		u, err := uidFromQueueTweetIds(ctx, item.TweetIds)
+
+		if err != nil {
+			return fmt.Errorf("Failed to derive published tweets for scheduled message %s, %w", item.Id, err)
+		}
+
+		by_account, err := tweetIdsByAccount(u)
+
+		if err != nil {
+			return fmt.Errorf("Failed to derive published tweets for scheduled message %s, %w", item.Id, err)
+		}
+
+		published = by_account
+	}
+
+	names := make([]string, 0)
+
+	for _, name := range b.account_names {
+
+		if _, ok := published[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	if len(names) < len(b.account_names) {
+		b.logger.Printf("Scheduled message %s has already been published to %d account(s), publishing to %s", item.Id, len(b.account_names)-len(names), strings.Join(names, ", "))
+	}
+
+	u, err := b.broadcastAccounts(ctx, msg, names)
+
+	// Record the tweets for the accounts which succeeded even if others failed
+
+	if u != nil {
+
+		by_account, id_err := tweetIdsByAccount(u)
+
+		if id_err == nil {
+
+			for name, tweet_ids := range by_account {
+				published[name] = tweet_ids
+			}
+		}
+	}
+
+	item.TweetIds = tweetIdsForQueue(published)
+	return err
+}
+
+// cancelScheduled cancels the queued item 'id'. If the item has already been published its tweets are retracted.
+func (b *TwitterBroadcaster) cancelScheduled(ctx context.Context, id string) error {
+
+	if strings.HasPrefix(id, DRYRUN_ID_PREFIX) {
+		b.logger.Printf("[dry-run] cancel scheduled message %s", id)
+		return nil
+	}
+
+	if b.schedule == nil {
+		return fmt.Errorf("Cancelling scheduled messages requires the ?schedule= parameter")
+	}
+
+	item, exists, err := b.schedule.Get(ctx, id)
+
+	if err != nil {
+		return fmt.Errorf("Failed to retrieve scheduled message %s, %w", id, err)
+	}
+
+	if !exists {
+		return fmt.Errorf("Scheduled message %s does not exist, %w", id, ErrNotFound)
+	}
+
+	switch item.Status {
+	case queue.STATUS_PENDING:
+
+		item.Status = queue.STATUS_CANCELLED
+
+		err = b.schedule.Update(ctx, item)
+
+		if err != nil {
+			return fmt.Errorf("Failed to cancel scheduled message %s, %w", id, err)
+		}
+
+		b.logger.Printf("Cancelled scheduled message %s", id)
+		return nil
+
+	case queue.STATUS_SENT:
+
+		u, err := uidFromQueueTweetIds(ctx, item.TweetIds)
+
+		if err != nil {
+			return err
+		}
+
+		return b.Retract(ctx, u)
+
+	case queue.STATUS_SENDING:
+		return fmt.Errorf("Scheduled message %s is being published", id)
+	default:
+		b.logger.Printf("Scheduled message %s has status '%s', skipping", id, item.Status)
+		return nil
+	}
+}
+
+// queueTweetIds returns the list of tweet IDs in 'u' to record in the schedule queue. Tweets published to named accounts
+// (see `AccountUID`) are recorded in the form "{ACCOUNT}#{TWEET_ID},{TWEET_ID}...".
+func queueTweetIds(u uid.UID) ([]string, error) {
+
+	by_account, err := tweetIdsByAccount(u)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tweetIdsForQueue(by_account), nil
+}
+
+// tweetIdsForQueue returns the list of tweet IDs, keyed by account name, in 'by_account' to record in the schedule queue.
+// Tweets which were not published to a named account are keyed by an empty string.
+func tweetIdsForQueue(by_account map[string][]string) []string {
+
+	tweet_ids := make([]string, 0)
+	tweet_ids = append(tweet_ids, by_account[""]...)
+
+	names := make([]string, 0)
+
+	for name := range by_account {
+
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		tweet_ids = append(tweet_ids, fmt.Sprintf("%s#%s", name, strings.Join(by_account[name], ",")))
+	}
+
+	return tweet_ids
+}
+
+// uidFromQueueTweetIds returns a `uid.UID` instance for the tweet IDs recorded in the schedule queue by `queueTweetIds`.
+func uidFromQueueTweetIds(ctx context.Context, tweet_ids []string) (uid.UID, error) {
+
+	plain_ids := make([]string, 0)
+	account_uids := make([]uid.UID, 0)
+
+	for _, id := range tweet_ids {
+
+		if !strings.Contains(id, "#") {
+			plain_ids = append(plain_ids, id)
+			continue
+		}
+
+		u, err := ParseAccountUID(ctx, id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		account_uids = append(account_uids, u)
+	}
+
+	if len(account_uids) == 0 {
+		return newBroadcastUID(ctx, plain_ids)
+	}
+
+	if len(plain_ids) > 0 {
+
+		u, err := newBroadcastUID(ctx, plain_ids)
+
+		if err != nil {
+			return nil, err
+		}
+
+		account_uids = append([]uid.UID{u}, account_uids...)
+	}
+
+	return uid.NewMultiUID(ctx, account_uids...), nil
+}
+
+// scheduledId returns the (string) ID used to identify the queued item 'id'.
+func scheduledId(id string) string {
+	return fmt.Sprintf("%s-%s", SCHEDULED_ID_PREFIX, id)
+}
+
+// newQueueMessage returns a `queue.Message` instance derived from 'msg'. Images that are `EncodedImage` instances
+// are stored as-is; all other images are stored as PNG-encoded data.
+func newQueueMessage(msg *broadcaster.Message) (*queue.Message, error) {
+
+	q_msg := &queue.Message{
+		Title:  msg.Title,
+		Body:   msg.Body,
+		Images: make([]*queue.Image, len(msg.Images)),
+	}
+
+	for idx, im := range msg.Images {
+
+		im, alt_text := imageWithAltText(im)
+
+		q_im := &queue.Image{
+			AltText: alt_text,
+		}
+
+		if enc_im, ok := im.(*EncodedImage); ok {
+
+			q_im.Body = enc_im.Body
+			q_im.ContentType = enc_im.ContentType
+			q_im.Original = true
+
+		} else {
+
+			var buf bytes.Buffer
+
+			err := png.Encode(&buf, im)
+
+			if err != nil {
+				return nil, fmt.Errorf("Failed to encode image %d, %w", idx, err)
+			}
+
+			q_im.Body = buf.Bytes()
+			q_im.ContentType = "image/png"
+		}
+
+		q_msg.Images[idx] = q_im
+	}
+
+	return q_msg, nil
+}
+
+// messageFromQueue returns a `broadcaster.Message` instance derived from 'q_msg'.
+func messageFromQueue(ctx context.Context, q_msg *queue.Message) (*broadcaster.Message, error) {
+
+	if q_msg == nil {
+		return nil, fmt.Errorf("Missing message")
+	}
+
+	msg := &broadcaster.Message{
+		Title:  q_msg.Title,
+		Body:   q_msg.Body,
+		Images: make([]image.Image, len(q_msg.Images)),
+	}
+
+	for idx, q_im := range q_msg.Images {
+
+		var im image.Image
+
+		if q_im.Original {
+
+			enc_im, err := NewEncodedImage(ctx, q_im.Body, q_im.ContentType)
+
+			if err != nil {
+				return nil, fmt.Errorf("Failed to decode image %d, %w", idx, err)
+			}
+
+			im = enc_im
+
+		} else {
+
+			dec_im, _, err := image.Decode(bytes.NewReader(q_im.Body))
+
+			if err != nil {
+				return nil, fmt.Errorf("Failed to decode image %d, %w", idx, err)
+			}
+
+			im = dec_im
+		}
+
+		if q_im.AltText != "" {
+			im = NewDescribedImage(im, q_im.AltText)
+		}
+
+		msg.Images[idx] = im
+	}
+
+	return msg, nil
+}
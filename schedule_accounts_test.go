@@ -0,0 +1,109 @@
+package twitter_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/aaronland/go-broadcaster-twitter/queue"
+	"github.com/aaronland/go-broadcaster-twitter/twittertest"
+)
+
+func TestPublishScheduledAccountsRetry(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+	accounts := newTestAccounts(t, s, "a", "b")
+
+	s.InjectAccountRateLimit(accounts["b"].(*oauth.OAuth1Credentials).AccessToken, twittertest.ENDPOINT_TWEETS, 1, time.Hour)
+
+	queue_uri := fmt.Sprintf("file://%s", filepath.Join(t.TempDir(), "queue.json"))
+
+	params := url.Values{}
+	params.Set("max-retries", "0")
+	params.Set("schedule", queue_uri)
+
+	tw_br := newTestAccountsBroadcaster(t, s, accounts, params)
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+	}
+
+	u, err := tw_br.ScheduleMessage(ctx, msg, time.Now())
+
+	if err != nil {
+		t.Fatalf("Failed to schedule message, %v", err)
+	}
+
+	item_id := strings.TrimPrefix(u.String(), twitter.SCHEDULED_ID_PREFIX+"-")
+
+	schedule, err := queue.NewQueue(ctx, queue_uri)
+
+	if err != nil {
+		t.Fatalf("Failed to open queue, %v", err)
+	}
+
+	// The first attempt publishes to "a" but is rate limited for "b"
+
+	_, err = tw_br.PublishScheduled(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to publish scheduled messages, %v", err)
+	}
+
+	item, _, err := schedule.Get(ctx, item_id)
+
+	if err != nil {
+		t.Fatalf("Failed to get scheduled message, %v", err)
+	}
+
+	if item.Status != queue.STATUS_PENDING {
+		t.Fatalf("Expected scheduled message to be pending, got '%s'", item.Status)
+	}
+
+	if len(item.TweetIds) != 1 || !strings.HasPrefix(item.TweetIds[0], "a#") {
+		t.Fatalf("Expected tweet for account 'a' to be recorded, got %v", item.TweetIds)
+	}
+
+	// The second attempt only publishes to "b"
+
+	_, err = tw_br.PublishScheduled(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to publish scheduled messages, %v", err)
+	}
+
+	item, _, err = schedule.Get(ctx, item_id)
+
+	if err != nil {
+		t.Fatalf("Failed to get scheduled message, %v", err)
+	}
+
+	if item.Status != queue.STATUS_SENT {
+		t.Fatalf("Expected scheduled message to be sent, got '%s' (%s)", item.Status, item.Error)
+	}
+
+	if len(item.TweetIds) != 2 || !strings.HasPrefix(item.TweetIds[0], "a#") || !strings.HasPrefix(item.TweetIds[1], "b#") {
+		t.Fatalf("Expected tweets for accounts 'a' and 'b' to be recorded, got %v", item.TweetIds)
+	}
+
+	posts := countPosts(s, accounts["a"])
+
+	if posts != 1 {
+		t.Fatalf("Expected message to be published to 'a' once, got %d", posts)
+	}
+
+	posts = countPosts(s, accounts["b"])
+
+	if posts != 2 {
+		t.Fatalf("Expected 2 attempts to publish to 'b', got %d", posts)
+	}
+}
@@ -0,0 +1,146 @@
+package twitter_test
+
+import (
+	"context"
+	"image"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-broadcaster-twitter"
+)
+
+func TestBroadcastScheduled(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("schedule", "mem://")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+		Images: []image.Image{
+			twitter.NewDescribedImage(newTestImage(32, 32), "A red square"),
+		},
+	}
+
+	u, err := br.BroadcastMessage(twitter.WithSendAt(ctx, time.Now().Add(time.Hour)), msg)
+
+	if err != nil {
+		t.Fatalf("Failed to schedule message, %v", err)
+	}
+
+	if !strings.HasPrefix(u.String(), twitter.SCHEDULED_ID_PREFIX+"-") {
+		t.Fatalf("Expected scheduled message ID, got '%s'", u.String())
+	}
+
+	count, err := br.PublishScheduled(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to publish scheduled messages, %v", err)
+	}
+
+	if count != 0 || len(s.Tweets()) != 0 {
+		t.Fatalf("Expected message not to be published before it is due")
+	}
+
+	_, err = br.ScheduleMessage(ctx, msg, time.Now().Add(-time.Minute))
+
+	if err != nil {
+		t.Fatalf("Failed to schedule message, %v", err)
+	}
+
+	count, err = br.PublishScheduled(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to publish scheduled messages, %v", err)
+	}
+
+	if count != 1 {
+		t.Fatalf("Expected 1 message to be published, got %d", count)
+	}
+
+	tweets := s.Tweets()
+
+	if len(tweets) != 1 || tweets[0].Text != "hello world" || len(tweets[0].MediaIds) != 1 {
+		t.Fatalf("Expected 1 tweet with 1 image")
+	}
+
+	m, _ := s.Media(tweets[0].MediaIds[0])
+
+	if m == nil || m.AltText != "A red square" {
+		t.Fatalf("Expected image description to be preserved by the queue")
+	}
+
+	// Messages are only published once
+
+	count, err = br.PublishScheduled(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to publish scheduled messages, %v", err)
+	}
+
+	if count != 0 {
+		t.Fatalf("Expected no messages to be published, got %d", count)
+	}
+}
+
+func TestCancelScheduled(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("schedule", "mem://")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+	}
+
+	u, err := br.ScheduleMessage(ctx, msg, time.Now().Add(-time.Minute))
+
+	if err != nil {
+		t.Fatalf("Failed to schedule message, %v", err)
+	}
+
+	err = br.Retract(ctx, u)
+
+	if err != nil {
+		t.Fatalf("Failed to cancel scheduled message, %v", err)
+	}
+
+	count, err := br.PublishScheduled(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to publish scheduled messages, %v", err)
+	}
+
+	if count != 0 || len(s.Tweets()) != 0 {
+		t.Fatalf("Expected cancelled message not to be published")
+	}
+}
+
+func TestScheduleWithoutQueue(t *testing.T) {
+
+	s := newTestServer(t, nil)
+	br := newTestBroadcaster(t, s, nil, nil)
+
+	msg := &broadcaster.Message{
+		Body: "hello world",
+	}
+
+	_, err := br.ScheduleMessage(context.Background(), msg, time.Now().Add(time.Hour))
+
+	if err == nil {
+		t.Fatalf("Expected scheduling a message without ?schedule= to fail")
+	}
+}
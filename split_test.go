@@ -0,0 +1,157 @@
+package twitter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var re_counter = regexp.MustCompile(` (\d+)/(\d+)$`)
+
+func TestSplitThread(t *testing.T) {
+
+	parts, err := splitThread("hello world", MAX_STATUS_LENGTH, statusLength)
+
+	if err != nil {
+		t.Fatalf("Failed to split text, %v", err)
+	}
+
+	if len(parts) != 1 || parts[0] != "hello world" {
+		t.Fatalf("Expected short text to be returned as-is, got %v", parts)
+	}
+
+	sentences := make([]string, 20)
+
+	for i := 0; i < 20; i++ {
+		sentences[i] = fmt.Sprintf("This is sentence number %d which has been padded with a few extra words.", i+1)
+	}
+
+	text := strings.Join(sentences, " ")
+
+	parts, err = splitThread(text, MAX_STATUS_LENGTH, statusLength)
+
+	if err != nil {
+		t.Fatalf("Failed to split text, %v", err)
+	}
+
+	if len(parts) < 2 {
+		t.Fatalf("Expected text to be split in to multiple parts, got %d", len(parts))
+	}
+
+	body := make([]string, len(parts))
+
+	for idx, p := range parts {
+
+		if statusLength(p) > MAX_STATUS_LENGTH {
+			t.Fatalf("Part %d is too long (%d)", idx+1, statusLength(p))
+		}
+
+		m := re_counter.FindStringSubmatch(p)
+
+		if m == nil {
+			t.Fatalf("Part %d is missing a counter, '%s'", idx+1, p)
+		}
+
+		if m[1] != fmt.Sprintf("%d", idx+1) || m[2] != fmt.Sprintf("%d", len(parts)) {
+			t.Fatalf("Part %d has an unexpected counter, '%s'", idx+1, m[0])
+		}
+
+		body[idx] = strings.TrimSuffix(p, m[0])
+
+		if !strings.HasSuffix(body[idx], ".") {
+			t.Fatalf("Expected part %d to end on a sentence boundary, '%s'", idx+1, body[idx])
+		}
+	}
+
+	if strings.Join(body, " ") != text {
+		t.Fatalf("Expected parts to reassemble the original text")
+	}
+}
+
+func TestSplitThreadLongWord(t *testing.T) {
+
+	word := strings.Repeat("a", 600)
+
+	parts, err := splitThread(word, MAX_STATUS_LENGTH, statusLength)
+
+	if err != nil {
+		t.Fatalf("Failed to split text, %v", err)
+	}
+
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+
+	joined := ""
+
+	for idx, p := range parts {
+
+		if statusLength(p) > MAX_STATUS_LENGTH {
+			t.Fatalf("Part %d is too long (%d)", idx+1, statusLength(p))
+		}
+
+		joined += strings.TrimSuffix(p, fmt.Sprintf(" %d/%d", idx+1, len(parts)))
+	}
+
+	if joined != word {
+		t.Fatalf("Expected parts to reassemble the original word")
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+
+	sentences := splitSentences(`One. Two! "Three?" Four… five`)
+	expected := []string{"One.", "Two!", `"Three?"`, "Four…", "five"}
+
+	if strings.Join(sentences, "|") != strings.Join(expected, "|") {
+		t.Fatalf("Unexpected sentences %q", sentences)
+	}
+}
+
+func TestDistributeMedia(t *testing.T) {
+
+	groups, err := distributeMedia([]string{"1", "2", "3", "4", "5"}, 2)
+
+	if err != nil {
+		t.Fatalf("Failed to distribute media, %v", err)
+	}
+
+	if len(groups) != 2 || strings.Join(groups[0], ",") != "1,2,3" || strings.Join(groups[1], ",") != "4,5" {
+		t.Fatalf("Unexpected groups %v", groups)
+	}
+
+	// Images are spread across every tweet rather than leaving the last tweets without any
+
+	groups, err = distributeMedia([]string{"1", "2", "3", "4", "5"}, 4)
+
+	if err != nil {
+		t.Fatalf("Failed to distribute media, %v", err)
+	}
+
+	sizes := make([]int, len(groups))
+
+	for idx, g := range groups {
+		sizes[idx] = len(g)
+	}
+
+	if fmt.Sprintf("%v", sizes) != "[2 1 1 1]" || strings.Join(groups[0], ",") != "1,2" || strings.Join(groups[3], ",") != "5" {
+		t.Fatalf("Unexpected groups %v", groups)
+	}
+
+	groups, err = distributeMedia(nil, 3)
+
+	if err != nil {
+		t.Fatalf("Failed to distribute media, %v", err)
+	}
+
+	if len(groups) != 3 || len(groups[0]) != 0 {
+		t.Fatalf("Unexpected groups %v", groups)
+	}
+
+	_, err = distributeMedia([]string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, 2)
+
+	if err == nil {
+		t.Fatalf("Expected distributing 9 images across 2 tweets to fail")
+	}
+}
@@ -0,0 +1,190 @@
+package twitter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/aaronland/go-broadcaster"
+	"github.com/sfomuseum/runtimevar"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// SHORTENER_PLACEHOLDER is the string in a `?shortener=` URI which is replaced by the (escaped) URL to shorten.
+const SHORTENER_PLACEHOLDER string = "{url}"
+
+// loadStatusTemplate returns a `text/template.Template` instance derived from 'value'. If 'value' contains a
+// template action ("{{") it is parsed as-is; otherwise it is treated as a `gocloud.dev/runtimevar` URI whose value
+// is the template to parse. Templates have access to the following functions:
+//
+//   - `truncate` Truncate a string to a maximum number of characters, for example `{{ truncate 100 .Body }}`.
+//   - `hashtag` Convert a string in to a hashtag, for example `{{ hashtag .Title }}`.
+//   - `length` The length of a string as counted by Twitter.
+//   - `shorten` Shorten a URL using the `?shortener=` URI, if defined.
+func loadStatusTemplate(ctx context.Context, value string) (*template.Template, error) {
+
+	body := value
+
+	if !strings.Contains(value, "{{") {
+
+		rt_ctx, rt_cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer rt_cancel()
+
+		v, err := runtimevar.StringVar(rt_ctx, value)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load template from %s, %w", value, err)
+		}
+
+		body = v
+	}
+
+	funcs := template.FuncMap{
+		"truncate": templateTruncate,
+		"hashtag":  templateHashtag,
+		"length":   statusLength,
+		// This is replaced at render time by renderStatus
+		"shorten": func(uri string) (string, error) {
+			return uri, nil
+		},
+	}
+
+	t, err := template.New("status").Funcs(funcs).Parse(body)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse template, %w", err)
+	}
+
+	return t, nil
+}
+
+// renderStatus renders 'msg' using 't' and returns the result with any leading or trailing whitespace removed.
+// 'shortener' is the (optional) `urlShortener` used by the "shorten" template function.
+func renderStatus(ctx context.Context, t *template.Template, shortener *urlShortener, msg *broadcaster.Message) (string, error) {
+
+	t, err := t.Clone()
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to clone template, %w", err)
+	}
+
+	t = t.Funcs(template.FuncMap{
+		"shorten": func(uri string) (string, error) {
+			return shortener.Shorten(ctx, uri)
+		},
+	})
+
+	var buf bytes.Buffer
+
+	err = t.Execute(&buf, msg)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to render template, %w", err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// templateTruncate truncates 's' on a word boundary so that it is no longer than 'max' characters, as counted
+// by Twitter, including a trailing ellipsis. For example:
+//
+//	{{ truncate 100 .Body }}
+func templateTruncate(max int, s string) string {
+
+	truncated, err := truncateStatus(s, max, ELLIPSIS, statusLength)
+
+	if err != nil {
+		return s
+	}
+
+	return truncated
+}
+
+// templateHashtag converts 's' in to a hashtag by removing any characters that are not letters, numbers or
+// underscores and capitalizing the first letter of each word. For example "San Francisco" becomes "#SanFrancisco".
+func templateHashtag(s string) string {
+
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_'
+	})
+
+	if len(words) == 0 {
+		return ""
+	}
+
+	var buf strings.Builder
+	buf.WriteString("#")
+
+	for _, w := range words {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		buf.WriteString(string(runes))
+	}
+
+	return buf.String()
+}
+
+// urlShortener shortens URLs using a `?shortener=` URI.
+type urlShortener struct {
+	// uri is the shortener URI containing a `SHORTENER_PLACEHOLDER` string.
+	uri string
+	// http_client is the `http.Client` used to call the shortener.
+	http_client *http.Client
+	// dry_run is a boolean flag indicating that URLs should be returned unchanged rather than calling the shortener.
+	dry_run bool
+}
+
+// Shorten returns a shortened version of 'uri' by replacing `SHORTENER_PLACEHOLDER` in the shortener URI with
+// the escaped value of 'uri' and returning the (plain text) body of the response. If 's' is nil, or in "dry-run"
+// mode, then 'uri' is returned unchanged. Since Twitter wraps all URLs using its t.co link shortener the length of
+// a status is the same whether or not its URLs have been shortened.
+func (s *urlShortener) Shorten(ctx context.Context, uri string) (string, error) {
+
+	if s == nil || s.dry_run {
+		return uri, nil
+	}
+
+	req_uri := strings.Replace(s.uri, SHORTENER_PLACEHOLDER, url.QueryEscape(uri), 1)
+
+	req_ctx, req_cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer req_cancel()
+
+	req, err := http.NewRequestWithContext(req_ctx, http.MethodGet, req_uri, nil)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to create shortener request, %w", err)
+	}
+
+	rsp, err := s.http_client.Do(req)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to shorten %s, %w", uri, err)
+	}
+
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to shorten %s, shortener returned status %d", uri, rsp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(rsp.Body, 2048))
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to read shortener response, %w", err)
+	}
+
+	short := strings.TrimSpace(string(body))
+
+	_, err = url.ParseRequestURI(short)
+
+	if err != nil {
+		return "", fmt.Errorf("Shortener returned an invalid URL for %s, %w", uri, err)
+	}
+
+	return short, nil
+}
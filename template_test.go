@@ -0,0 +1,105 @@
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aaronland/go-broadcaster"
+)
+
+func TestTemplateHashtag(t *testing.T) {
+
+	tests := map[string]string{
+		"San Francisco":       "#SanFrancisco",
+		"sfo museum":          "#SfoMuseum",
+		"terminal_2 (gate A)": "#Terminal_2GateA",
+		"!!!":                 "",
+	}
+
+	for input, expected := range tests {
+
+		v := templateHashtag(input)
+
+		if v != expected {
+			t.Fatalf("Expected '%s' for '%s', got '%s'", expected, input, v)
+		}
+	}
+}
+
+func TestTemplateTruncate(t *testing.T) {
+
+	v := templateTruncate(12, "hello world this is a test")
+
+	if statusLength(v) > 12 {
+		t.Fatalf("Expected truncated string to be no longer than 12 characters, got '%s'", v)
+	}
+
+	v = templateTruncate(100, "hello world")
+
+	if v != "hello world" {
+		t.Fatalf("Expected short string to be unchanged, got '%s'", v)
+	}
+}
+
+func TestRenderStatusShortener(t *testing.T) {
+
+	ctx := context.Background()
+
+	requests := 0
+
+	s := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		requests += 1
+		rsp.Write([]byte("https://example.com/s/1\n"))
+	}))
+
+	defer s.Close()
+
+	tpl, err := loadStatusTemplate(ctx, `{{ .Title }} {{ shorten .Body }}`)
+
+	if err != nil {
+		t.Fatalf("Failed to load template, %v", err)
+	}
+
+	msg := &broadcaster.Message{
+		Title: "Hello",
+		Body:  "https://example.com/a/very/long/url",
+	}
+
+	shortener := &urlShortener{
+		uri:         s.URL + "/?url=" + SHORTENER_PLACEHOLDER,
+		http_client: s.Client(),
+	}
+
+	v, err := renderStatus(ctx, tpl, shortener, msg)
+
+	if err != nil {
+		t.Fatalf("Failed to render status, %v", err)
+	}
+
+	if v != "Hello https://example.com/s/1" {
+		t.Fatalf("Unexpected status '%s'", v)
+	}
+
+	// URLs are not shortened in dry-run mode, or without a shortener
+
+	shortener.dry_run = true
+
+	for _, sh := range []*urlShortener{shortener, nil} {
+
+		v, err = renderStatus(ctx, tpl, sh, msg)
+
+		if err != nil {
+			t.Fatalf("Failed to render status, %v", err)
+		}
+
+		if v != "Hello https://example.com/a/very/long/url" {
+			t.Fatalf("Unexpected status '%s'", v)
+		}
+	}
+
+	if requests != 1 {
+		t.Fatalf("Expected 1 request to the shortener, got %d", requests)
+	}
+}
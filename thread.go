@@ -0,0 +1,198 @@
+package twitter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MAX_STATUS_LENGTH is the maximum length of a tweet.
+const MAX_STATUS_LENGTH int = 280
+
+// MAX_IMAGES_PER_TWEET is the maximum number of images that can be attached to a single tweet.
+const MAX_IMAGES_PER_TWEET int = 4
+
+// re_sentence matches the whitespace following the end of a sentence.
+var re_sentence = regexp.MustCompile(`([.!?…]+["'”’)\]]*)\s+`)
+
+// statusLength returns the length of 's' as counted by Twitter.
+func statusLength(s string) int {
+	return WeightedLength(s)
+}
+
+// splitThread splits 'text' in to one or more parts, each no longer than 'max' characters (as measured by
+// 'length') including a trailing " {N}/{TOTAL}" counter. Text is split on sentence boundaries when possible,
+// then on word boundaries and, as a last resort, in the middle of words that are longer than a single part.
+// If 'text' already fits within 'max' characters it is returned as-is without a counter.
+func splitThread(text string, max int, length func(string) int) ([]string, error) {
+
+	text = strings.TrimSpace(text)
+
+	if length(text) <= max {
+		return []string{text}, nil
+	}
+
+	// The width of the counter depends on the total number of parts which we don't
+	// know until we've split the text so start by assuming a single digit total and
+	// try again with wider counters until everything fits.
+
+	for digits := 1; digits <= 4; digits++ {
+
+		reserve := length(fmt.Sprintf(" %s/%s", strings.Repeat("9", digits), strings.Repeat("9", digits)))
+		part_max := max - reserve
+
+		if part_max < 1 {
+			break
+		}
+
+		parts := splitText(text, part_max, length)
+		count := len(parts)
+
+		if len(fmt.Sprintf("%d", count)) > digits {
+			continue
+		}
+
+		for idx, p := range parts {
+			parts[idx] = fmt.Sprintf("%s %d/%d", p, idx+1, count)
+		}
+
+		return parts, nil
+	}
+
+	return nil, fmt.Errorf("Unable to split text in to a thread")
+}
+
+// splitText splits 'text' in to parts no longer than 'max' characters preferring sentence boundaries
+// over word boundaries.
+func splitText(text string, max int, length func(string) int) []string {
+
+	parts := make([]string, 0)
+	current := ""
+
+	add := func(chunk string, sep string) {
+
+		if current == "" {
+			current = chunk
+			return
+		}
+
+		candidate := current + sep + chunk
+
+		if length(candidate) <= max {
+			current = candidate
+			return
+		}
+
+		parts = append(parts, current)
+		current = chunk
+	}
+
+	for _, sentence := range splitSentences(text) {
+
+		if length(sentence) <= max {
+			add(sentence, " ")
+			continue
+		}
+
+		for _, word := range strings.Fields(sentence) {
+
+			if length(word) <= max {
+				add(word, " ")
+				continue
+			}
+
+			for _, fragment := range splitWord(word, max, length) {
+
+				if current != "" {
+					parts = append(parts, current)
+				}
+
+				current = fragment
+			}
+		}
+	}
+
+	if current != "" {
+		parts = append(parts, current)
+	}
+
+	return parts
+}
+
+// splitSentences splits 'text' in to sentences, preserving their trailing punctuation.
+func splitSentences(text string) []string {
+
+	marked := re_sentence.ReplaceAllString(text, "$1\x00")
+	sentences := make([]string, 0)
+
+	for _, s := range strings.Split(marked, "\x00") {
+
+		s = strings.TrimSpace(s)
+
+		if s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+
+	return sentences
+}
+
+// splitWord splits 'word' in to fragments no longer than 'max' characters.
+func splitWord(word string, max int, length func(string) int) []string {
+
+	fragments := make([]string, 0)
+	current := ""
+
+	for _, r := range word {
+
+		candidate := current + string(r)
+
+		if current != "" && length(candidate) > max {
+			fragments = append(fragments, current)
+			candidate = string(r)
+		}
+
+		current = candidate
+	}
+
+	if current != "" {
+		fragments = append(fragments, current)
+	}
+
+	return fragments
+}
+
+// distributeMedia assigns 'media_ids' to 'count' tweets, in order, spreading them as evenly as possible: each tweet
+// is assigned the same number of items and any remainder is assigned, one each, to the first tweets. It returns an
+// error if a tweet would be assigned more than `MAX_IMAGES_PER_TWEET` items.
+func distributeMedia(media_ids []string, count int) ([][]string, error) {
+
+	groups := make([][]string, count)
+
+	if len(media_ids) == 0 {
+		return groups, nil
+	}
+
+	per_tweet := len(media_ids) / count
+	remainder := len(media_ids) % count
+
+	if per_tweet > MAX_IMAGES_PER_TWEET || (per_tweet == MAX_IMAGES_PER_TWEET && remainder > 0) {
+		return nil, fmt.Errorf("Too many images (%d) for %d tweet(s), maximum is %d per tweet", len(media_ids), count, MAX_IMAGES_PER_TWEET)
+	}
+
+	offset := 0
+
+	for i := 0; i < count; i++ {
+
+		n := per_tweet
+
+		if i < remainder {
+			n += 1
+		}
+
+		groups[i] = append(groups[i], media_ids[offset:offset+n]...)
+		offset += n
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,159 @@
+package twitter_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-broadcaster-twitter/twittertest"
+)
+
+// failingTransport is a `http.RoundTripper` which fails the 'fail_at' (1-based) request to publish a tweet
+// with a 400 Bad Request error.
+type failingTransport struct {
+	transport http.RoundTripper
+	fail_at   int
+	count     int
+	mu        *sync.Mutex
+}
+
+func (t *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	if req.Method == http.MethodPost && req.URL.Path == twittertest.ENDPOINT_TWEETS {
+
+		t.mu.Lock()
+		t.count += 1
+		count := t.count
+		t.mu.Unlock()
+
+		if count == t.fail_at {
+
+			rsp := &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Status:     "400 Bad Request",
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       http.NoBody,
+				Request:    req,
+			}
+
+			return rsp, nil
+		}
+	}
+
+	return t.transport.RoundTrip(req)
+}
+
+func longBody(words int) string {
+
+	parts := make([]string, words)
+
+	for i := 0; i < words; i++ {
+		parts[i] = "lorem"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestBroadcastThread(t *testing.T) {
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("overflow", "thread")
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	_, err := broadcast(t, br, longBody(100))
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast thread, %v", err)
+	}
+
+	tweets := s.Tweets()
+
+	if len(tweets) < 2 {
+		t.Fatalf("Expected a thread, got %d tweet(s)", len(tweets))
+	}
+
+	for idx, tw := range tweets {
+
+		if idx == 0 {
+
+			if tw.InReplyTo != "" {
+				t.Fatalf("Expected first tweet not to be a reply")
+			}
+
+			continue
+		}
+
+		if tw.InReplyTo != tweets[idx-1].Id {
+			t.Fatalf("Expected tweet %d to reply to %s, got '%s'", idx, tweets[idx-1].Id, tw.InReplyTo)
+		}
+	}
+}
+
+func TestBroadcastThreadPartialFailure(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	http_client := &http.Client{
+		Transport: &failingTransport{
+			transport: http.DefaultTransport,
+			fail_at:   2,
+			mu:        new(sync.Mutex),
+		},
+	}
+
+	params := url.Values{}
+	params.Set("overflow", "thread")
+	params.Set("dedupe", "mem://")
+	params.Set("max-retries", "0")
+
+	br := newTestBroadcaster(t, s, params, http_client)
+
+	msg := &broadcaster.Message{
+		Body: longBody(100),
+	}
+
+	u, err := br.BroadcastMessage(ctx, msg)
+
+	if err == nil {
+		t.Fatalf("Expected thread to fail part way through")
+	}
+
+	if u == nil {
+		t.Fatalf("Expected a UID for the partially published thread")
+	}
+
+	tweets := s.Tweets()
+
+	if len(tweets) != 1 {
+		t.Fatalf("Expected 1 published tweet, got %d", len(tweets))
+	}
+
+	if u.String() != tweets[0].Id {
+		t.Fatalf("Expected UID for %s, got %s", tweets[0].Id, u.String())
+	}
+
+	// The partially published thread is recorded in the dedupe store so it is not published again
+
+	u2, err := br.BroadcastMessage(ctx, msg)
+
+	if err != nil {
+		t.Fatalf("Expected dedupe store to return the partial thread, %v", err)
+	}
+
+	if u2.String() != u.String() {
+		t.Fatalf("Expected %s, got %s", u.String(), u2.String())
+	}
+
+	if len(s.Tweets()) != 1 {
+		t.Fatalf("Expected message not to be published again")
+	}
+}
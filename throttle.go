@@ -0,0 +1,303 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// THROTTLE_BLOCK is the value of the `?throttle=` parameter which causes messages that would exceed the posting
+// budget to wait (blocking the caller) until they can be published.
+const THROTTLE_BLOCK string = "block"
+
+// THROTTLE_QUEUE is the value of the `?throttle=` parameter which causes messages that would exceed the posting
+// budget to be added to the schedule queue (see `?schedule=`) to be published when they are allowed.
+const THROTTLE_QUEUE string = "queue"
+
+// THROTTLE_FAIL is the value of the `?throttle=` parameter which causes messages that would exceed the posting
+// budget to fail immediately with a `ThrottleError`.
+const THROTTLE_FAIL string = "fail"
+
+// ErrThrottled is the error returned when a message would exceed the posting budget defined by the `?min-interval=`,
+// `?max-per-hour=` and `?quiet-hours=` parameters.
+var ErrThrottled = errors.New("Throttled")
+
+// ThrottleError is the error returned when a message would exceed the posting budget and `?throttle=fail`.
+type ThrottleError struct {
+	// Until is the earliest time the message may be published.
+	Until time.Time
+}
+
+// Error returns the string representation of 'e'.
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("%v, next post allowed at %s", ErrThrottled, e.Until.Format(time.RFC3339))
+}
+
+// Is returns a boolean value indicating whether 'target' is `ErrThrottled`.
+func (e *ThrottleError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+// throttleBlockKey is the context key used to force the "block" behaviour for messages published from the schedule
+// queue, which would otherwise be added back to the queue if `?throttle=queue`.
+type throttleBlockKey struct{}
+
+// postLedger records the times that posts were published (or are reserved to be published) for an account.
+type postLedger struct {
+	mu    *sync.Mutex
+	times []time.Time
+}
+
+var ledgers = make(map[string]*postLedger)
+var ledgers_mu = new(sync.Mutex)
+
+// ledgerForAccount returns the `postLedger` shared by all the throttles in the current process for the account 'key'.
+func ledgerForAccount(key string) *postLedger {
+
+	ledgers_mu.Lock()
+	defer ledgers_mu.Unlock()
+
+	l, ok := ledgers[key]
+
+	if !ok {
+
+		l = &postLedger{
+			mu:    new(sync.Mutex),
+			times: make([]time.Time, 0),
+		}
+
+		ledgers[key] = l
+	}
+
+	return l
+}
+
+// quietHours is a daily period during which nothing is published.
+type quietHours struct {
+	// start is the start of the period in minutes after midnight.
+	start int
+	// end is the end of the period in minutes after midnight. If 'end' is less than 'start' the period spans midnight.
+	end      int
+	location *time.Location
+}
+
+// parseQuietHours parses 'str_hours', which is expected to take the form "HH:MM-HH:MM", as a `quietHours` instance
+// in 'loc'.
+func parseQuietHours(str_hours string, loc *time.Location) (*quietHours, error) {
+
+	str_start, str_end, ok := strings.Cut(str_hours, "-")
+
+	if !ok {
+		return nil, fmt.Errorf("Invalid quiet hours '%s', expected HH:MM-HH:MM", str_hours)
+	}
+
+	start, err := parseClockTime(str_start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := parseClockTime(str_end)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if start == end {
+		return nil, fmt.Errorf("Invalid quiet hours '%s', start and end must be different", str_hours)
+	}
+
+	q := &quietHours{
+		start:    start,
+		end:      end,
+		location: loc,
+	}
+
+	return q, nil
+}
+
+// parseClockTime parses 'str_t', which is expected to take the form "HH:MM", and returns the number of minutes after midnight.
+func parseClockTime(str_t string) (int, error) {
+
+	str_h, str_m, ok := strings.Cut(strings.TrimSpace(str_t), ":")
+
+	if !ok {
+		return 0, fmt.Errorf("Invalid time '%s', expected HH:MM", str_t)
+	}
+
+	h, err := strconv.Atoi(str_h)
+
+	if err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("Invalid hour in '%s'", str_t)
+	}
+
+	m, err := strconv.Atoi(str_m)
+
+	if err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("Invalid minute in '%s'", str_t)
+	}
+
+	return h*60 + m, nil
+}
+
+// until returns the end of the quiet period containing 't' and a boolean value indicating whether 't' is in a quiet period.
+func (q *quietHours) until(t time.Time) (time.Time, bool) {
+
+	local_t := t.In(q.location)
+	m := local_t.Hour()*60 + local_t.Minute()
+
+	y, mo, d := local_t.Date()
+	end := time.Date(y, mo, d, q.end/60, q.end%60, 0, 0, q.location)
+
+	switch {
+	case q.start < q.end:
+
+		if m >= q.start && m < q.end {
+			return end, true
+		}
+
+	default:
+
+		if m >= q.start {
+			return end.AddDate(0, 0, 1), true
+		}
+
+		if m < q.end {
+			return end, true
+		}
+	}
+
+	return t, false
+}
+
+// throttle enforces a posting budget for an account.
+type throttle struct {
+	min_interval time.Duration
+	max_per_hour int
+	quiet        *quietHours
+	ledger       *postLedger
+}
+
+// reserve returns the earliest time, at or after 'now', that a message may be published. If that time is 'now', or
+// 'wait' is true, the time is recorded in the ledger, so that concurrent callers are scheduled after it, and a function
+// to remove it (if the message is not published) is returned. Otherwise nothing is recorded and the function returned
+// is a no-op. Posts are published in the order they are reserved.
+func (t *throttle) reserve(now time.Time, wait bool) (time.Time, func()) {
+
+	t.ledger.mu.Lock()
+	defer t.ledger.mu.Unlock()
+
+	t.prune(now)
+
+	times := t.ledger.times
+	at := now
+
+	if len(times) > 0 {
+
+		last := times[len(times)-1]
+
+		if last.After(at) {
+			at = last
+		}
+
+		if t.min_interval > 0 && last.Add(t.min_interval).After(at) {
+			at = last.Add(t.min_interval)
+		}
+	}
+
+	if t.max_per_hour > 0 {
+
+		count := 0
+
+		for _, other := range times {
+
+			if other.After(at.Add(-time.Hour)) {
+				count += 1
+			}
+		}
+
+		if count >= t.max_per_hour {
+
+			next := times[len(times)-t.max_per_hour].Add(time.Hour)
+
+			if next.After(at) {
+				at = next
+			}
+		}
+	}
+
+	if t.quiet != nil {
+
+		end, quiet := t.quiet.until(at)
+
+		if quiet {
+			at = end
+		}
+	}
+
+	if at.After(now) && !wait {
+		return at, func() {}
+	}
+
+	t.ledger.times = append(t.ledger.times, at)
+
+	sort.Slice(t.ledger.times, func(i, j int) bool {
+		return t.ledger.times[i].Before(t.ledger.times[j])
+	})
+
+	release := func() {
+
+		t.ledger.mu.Lock()
+		defer t.ledger.mu.Unlock()
+
+		for idx, other := range t.ledger.times {
+
+			if other.Equal(at) {
+				t.ledger.times = append(t.ledger.times[:idx], t.ledger.times[idx+1:]...)
+				break
+			}
+		}
+	}
+
+	return at, release
+}
+
+// prune removes the times which no longer affect the posting budget, keeping the most recent. Callers must hold 't.ledger.mu'.
+func (t *throttle) prune(now time.Time) {
+
+	times := t.ledger.times
+	cutoff := now.Add(-time.Hour)
+
+	idx := 0
+
+	for idx < len(times)-1 && !times[idx].After(cutoff) {
+		idx += 1
+	}
+
+	t.ledger.times = times[idx:]
+}
+
+// waitUntil blocks until 'at' or until 'ctx' is cancelled.
+func waitUntil(ctx context.Context, at time.Time) error {
+
+	d := time.Until(at)
+
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,215 @@
+package twitter
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+)
+
+// newTestThrottle returns a new `throttle` instance with its own (empty) ledger.
+func newTestThrottle(min_interval time.Duration, max_per_hour int, quiet *quietHours) *throttle {
+
+	return &throttle{
+		min_interval: min_interval,
+		max_per_hour: max_per_hour,
+		quiet:        quiet,
+		ledger: &postLedger{
+			mu:    new(sync.Mutex),
+			times: make([]time.Time, 0),
+		},
+	}
+}
+
+func TestParseQuietHours(t *testing.T) {
+
+	q, err := parseQuietHours("22:00-07:30", time.UTC)
+
+	if err != nil {
+		t.Fatalf("Failed to parse quiet hours, %v", err)
+	}
+
+	if q.start != 22*60 || q.end != 7*60+30 {
+		t.Fatalf("Unexpected quiet hours %d-%d", q.start, q.end)
+	}
+
+	for _, str_hours := range []string{"22:00", "25:00-07:00", "22:00-07:60", "10pm-7am", "07:00-07:00"} {
+
+		_, err := parseQuietHours(str_hours, time.UTC)
+
+		if err == nil {
+			t.Fatalf("Expected quiet hours '%s' to be invalid", str_hours)
+		}
+	}
+}
+
+func TestQuietHoursUntil(t *testing.T) {
+
+	overnight, err := parseQuietHours("22:00-07:00", time.UTC)
+
+	if err != nil {
+		t.Fatalf("Failed to parse quiet hours, %v", err)
+	}
+
+	daytime, err := parseQuietHours("12:00-13:00", time.UTC)
+
+	if err != nil {
+		t.Fatalf("Failed to parse quiet hours, %v", err)
+	}
+
+	tests := []struct {
+		quiet    *quietHours
+		t        time.Time
+		expected time.Time
+		is_quiet bool
+	}{
+		{overnight, time.Date(2023, 1, 1, 23, 0, 0, 0, time.UTC), time.Date(2023, 1, 2, 7, 0, 0, 0, time.UTC), true},
+		{overnight, time.Date(2023, 1, 1, 3, 0, 0, 0, time.UTC), time.Date(2023, 1, 1, 7, 0, 0, 0, time.UTC), true},
+		{overnight, time.Date(2023, 1, 1, 7, 0, 0, 0, time.UTC), time.Date(2023, 1, 1, 7, 0, 0, 0, time.UTC), false},
+		{overnight, time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC), time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC), false},
+		{daytime, time.Date(2023, 1, 1, 12, 30, 0, 0, time.UTC), time.Date(2023, 1, 1, 13, 0, 0, 0, time.UTC), true},
+		{daytime, time.Date(2023, 1, 1, 23, 0, 0, 0, time.UTC), time.Date(2023, 1, 1, 23, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, test := range tests {
+
+		until, is_quiet := test.quiet.until(test.t)
+
+		if is_quiet != test.is_quiet || !until.Equal(test.expected) {
+			t.Fatalf("Expected %s to be quiet (%t) until %s, got %t until %s", test.t, test.is_quiet, test.expected, is_quiet, until)
+		}
+	}
+}
+
+func TestThrottleMinInterval(t *testing.T) {
+
+	th := newTestThrottle(10*time.Minute, 0, nil)
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	at, _ := th.reserve(now, false)
+
+	if !at.Equal(now) {
+		t.Fatalf("Expected first post to be allowed immediately, got %s", at)
+	}
+
+	next := now.Add(10 * time.Minute)
+
+	// Without waiting nothing is reserved
+
+	at, _ = th.reserve(now, false)
+
+	if !at.Equal(next) || len(th.ledger.times) != 1 {
+		t.Fatalf("Expected second post to be allowed at %s without a reservation, got %s (%d)", next, at, len(th.ledger.times))
+	}
+
+	at, release := th.reserve(now, true)
+
+	if !at.Equal(next) || len(th.ledger.times) != 2 {
+		t.Fatalf("Expected second post to be reserved at %s, got %s (%d)", next, at, len(th.ledger.times))
+	}
+
+	// Concurrent callers are scheduled after earlier reservations
+
+	at, _ = th.reserve(now, false)
+
+	if !at.Equal(next.Add(10 * time.Minute)) {
+		t.Fatalf("Expected third post to be allowed at %s, got %s", next.Add(10*time.Minute), at)
+	}
+
+	release()
+
+	if len(th.ledger.times) != 1 {
+		t.Fatalf("Expected reservation to be released")
+	}
+
+	at, _ = th.reserve(now.Add(time.Hour), false)
+
+	if !at.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Expected post to be allowed once the minimum interval has passed, got %s", at)
+	}
+}
+
+func TestThrottleMaxPerHour(t *testing.T) {
+
+	th := newTestThrottle(0, 2, nil)
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	th.reserve(now, false)
+	th.reserve(now.Add(time.Minute), false)
+
+	at, _ := th.reserve(now.Add(2*time.Minute), false)
+
+	if !at.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Expected third post to be allowed at %s, got %s", now.Add(time.Hour), at)
+	}
+
+	at, _ = th.reserve(now.Add(61*time.Minute), false)
+
+	if !at.Equal(now.Add(61 * time.Minute)) {
+		t.Fatalf("Expected post to be allowed after an hour, got %s", at)
+	}
+}
+
+func TestThrottleQuietHours(t *testing.T) {
+
+	q, err := parseQuietHours("22:00-07:00", time.UTC)
+
+	if err != nil {
+		t.Fatalf("Failed to parse quiet hours, %v", err)
+	}
+
+	th := newTestThrottle(0, 0, q)
+	now := time.Date(2023, 1, 1, 23, 0, 0, 0, time.UTC)
+
+	at, _ := th.reserve(now, false)
+
+	if !at.Equal(time.Date(2023, 1, 2, 7, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected post to be delayed until the end of quiet hours, got %s", at)
+	}
+
+	if len(th.ledger.times) != 0 {
+		t.Fatalf("Expected nothing to be reserved")
+	}
+}
+
+func TestThrottleError(t *testing.T) {
+
+	var err error = &ThrottleError{Until: time.Now()}
+
+	if !errors.Is(err, ErrThrottled) {
+		t.Fatalf("Expected ThrottleError to be ErrThrottled")
+	}
+}
+
+func TestAccountKey(t *testing.T) {
+
+	oauth1 := &oauth.OAuth1Credentials{AccessToken: "1234-abcd"}
+	oauth2 := &oauth.OAuth2Credentials{ClientId: "client", AccessToken: "token"}
+	rotated := &oauth.OAuth2Credentials{ClientId: "client", AccessToken: "rotated"}
+	account := &Account{Id: "1234", ScreenName: "example"}
+
+	tests := []struct {
+		creds    oauth.Credentials
+		account  *Account
+		name     string
+		expected string
+	}{
+		{oauth1, nil, "", "user:1234"},
+		{oauth2, account, "", "user:1234"},
+		{&oauth.OAuth1Credentials{AccessToken: "abcd"}, nil, "", "oauth1:abcd"},
+		{oauth2, nil, "", "oauth2:client:"},
+		{rotated, nil, "", "oauth2:client:"},
+		{oauth2, nil, "alice", "oauth2:client:alice"},
+	}
+
+	for _, test := range tests {
+
+		k := accountKey(test.creds, test.account, test.name)
+
+		if k != test.expected {
+			t.Fatalf("Expected account key '%s', got '%s'", test.expected, k)
+		}
+	}
+}
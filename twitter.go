@@ -2,7 +2,7 @@ package twitter
 
 // https://developer.twitter.com/en/docs/labs/overview/error-codes
 // https://developer.twitter.com/en/docs/basics/authentication/overview/3-legged-oauth
-// https://developer.twitter.com/en/docs/tweets/post-and-engage/api-reference/post-statuses-update.html
+// https://developer.twitter.com/en/docs/twitter-api/tweets/manage-tweets/api-reference/post-tweets
 // https://developer.twitter.com/en/docs/media/upload-media/overview
 // https://developer.twitter.com/en/docs/media/upload-media/uploading-media/chunked-media-upload
 // https://developer.twitter.com/en/docs/media/upload-media/api-reference/post-media-upload.html
@@ -10,18 +10,22 @@ package twitter
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
-	"github.com/ChimeraCoder/anaconda"
-	"github.com/aaronland/go-broadcaster"	
+	"github.com/aaronland/go-broadcaster"
+	"github.com/aaronland/go-broadcaster-twitter/dedupe"
 	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"github.com/aaronland/go-broadcaster-twitter/queue"
 	"github.com/aaronland/go-image-encode"
 	"github.com/aaronland/go-uid"
-	"github.com/sfomuseum/runtimevar"
 	"image"
 	"log"
+	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 )
 
@@ -32,13 +36,142 @@ func init() {
 
 type TwitterBroadcaster struct {
 	broadcaster.Broadcaster
-	twitter_client *anaconda.TwitterApi
-	testing        bool
+	client_state   *clientState
+	client_mu      *sync.RWMutex
+	factory        *clientFactory
+	mode           string
+	test_prefix    string
 	encoder        encode.Encoder
+	encoders       map[string]encode.Encoder
+	fit_images     bool
+	require_alt    bool
+	overflow       string
+	overflow_link  string
+	template       *template.Template
+	shortener      *urlShortener
+	verify         string
+	verify_timeout time.Duration
+	account        *Account
+	account_mu     *sync.Mutex
+	account_name   string
+	accounts       map[string]*TwitterBroadcaster
+	account_names  []string
+	concurrency    int
+	dedupe         dedupe.Store
+	dedupe_ttl     time.Duration
+	schedule       queue.Queue
+	throttle       *throttle
+	throttle_mode  string
 	logger         *log.Logger
+	log_writer     *loggerWriter
 }
 
+// TwitterBroadcasterOptions defines programmatic configuration options for `NewTwitterBroadcasterWithOptions`
+// which can not be expressed as URI parameters.
+type TwitterBroadcasterOptions struct {
+	// HTTPClient is the `http.Client` used for all requests to the Twitter API. If nil `http.DefaultClient` is used.
+	HTTPClient *http.Client
+}
+
+// NewTwitterBroadcaster returns a new `TwitterBroadcaster` configured by 'uri' which is expected to
+// take the form of:
+//
+//	twitter://?credentials={RUNTIMEVAR_URI}
+//
+// Where {RUNTIMEVAR_URI} is a valid `gocloud.dev/runtimevar` URI which resolves to a JSON-encoded
+// `oauth.OAuth1Credentials` or `oauth.OAuth2Credentials` document (see `oauth.NewCredentialsFromString`). OAuth2
+// credentials can only be used with the v2 API; their access tokens are refreshed automatically before they expire.
+// Alternatively OAuth1 credentials can be assembled from individual values, each of which may be read from a different store:
+//
+//	twitter://?consumer-key={RUNTIMEVAR_URI}&consumer-secret={RUNTIMEVAR_URI}&access-token={RUNTIMEVAR_URI}&access-secret={RUNTIMEVAR_URI}
+//
+// If `?credentials=` is not present any of those parameters which are absent are read from the `TWITTER_CONSUMER_KEY`,
+// `TWITTER_CONSUMER_SECRET`, `TWITTER_ACCESS_TOKEN` and `TWITTER_ACCESS_SECRET` environment variables respectively, so
+// a URI with no credentials parameters at all reads every value from the environment. Leading and trailing whitespace
+// is removed from individual values. `?credentials=` can not be combined with the individual parameters and it is an
+// error for any value to be missing from both the URI and the environment.
+//
+// If the credentials document is an `oauth.AccountsCredentials` document, containing the credentials for several named
+// accounts, every message is published to each account (see `BroadcastMessage`). Each account has its own posting budget
+// and dedupe records but otherwise shares the configuration defined by the URI. The `?watch=` and `?throttle=queue`
+// parameters can not be used with multiple accounts.
+//
+// Optional parameters are:
+//
+//   - `?api=` The Twitter API to use for publishing tweets. Valid options are "v2" and "v1.1". Default is "v2".
+//   - `?chunk-size=` The number of bytes to send with each chunked media upload request. Default is 1MB.
+//   - `?encoder=` A valid `aaronland/go-image-encode` URI (for example "jpeg://?quality=85") used to encode all images.
+//     If empty an encoder is chosen for each image: GIF for paletted images, PNG for images with transparency and JPEG otherwise.
+//   - `?fit=` A boolean flag indicating whether images should be downscaled and/or re-encoded to fit Twitter's size limits. Default is true.
+//   - `?require-alt-text=` A boolean flag indicating whether every image must have a description (see `DescribedImage`). If true
+//     missing descriptions, or failures to publish them, are errors. If false they are logged as warnings. Default is false.
+//   - `?overflow=` The strategy for handling message bodies longer than a single tweet. Valid options are "error", "truncate"
+//     (cut on a word boundary and append an ellipsis), "truncate-link" (cut and append the value of `?overflow-link=`) and
+//     "thread" (split in to a numbered thread of replies, with images distributed across the thread). Default is "error".
+//   - `?overflow-link=` The URL to append to truncated messages when `?overflow=truncate-link`.
+//   - `?thread=` A boolean flag which, if true, is the same as `?overflow=thread`. Default is false.
+//   - `?template=` A Go `text/template` used to render a `broadcaster.Message` as the text of a tweet, or a valid `gocloud.dev/runtimevar`
+//     URI which resolves to a template. If empty the message body is used as-is. See `loadStatusTemplate` for the available functions.
+//   - `?shortener=` A URL with a "{url}" placeholder used by the template `shorten` function to shorten URLs. The URL must return the
+//     shortened URL as plain text. It is requested using the `HTTPClient` defined in `TwitterBroadcasterOptions`. URLs are not
+//     shortened in "dry-run" mode.
+//   - `?mode=` The mode to publish messages in. Valid options are "live", "test" (prefix every message with the value of `?test-prefix=`)
+//     and "dry-run" (verify credentials, as `?verify=` dictates, and validate messages and prepare their images but log, rather than
+//     send, the requests that would have published or deleted tweets and media and return synthetic `uid.StringUID` values). Messages published in "dry-run" mode are not subject to, and do not count
+//     against, the posting budget defined by `?min-interval=`, `?max-per-hour=` and `?quiet-hours=`. Default is "live".
+//   - `?test-prefix=` The string to prepend to messages when `?mode=test`. Default is `DEFAULT_TEST_PREFIX`.
+//   - `?token-sink=` A valid `oauth.TokenSink` URI (for example "file:///path/to/credentials.json") that OAuth2 credentials are
+//     written to after their access token has been refreshed. If the credentials contain multiple accounts the URI must contain
+//     an `ACCOUNT_PLACEHOLDER` string (for example "file:///path/to/{account}.json") which is replaced by the name of each account.
+//   - `?watch=` Reload the credentials, without restarting, when they change. Valid options are "notify" (reload when the
+//     `gocloud.dev/runtimevar` variable reports a change, for example when a file:// variable is modified or, for awsparamstore://
+//     variables, polling at the interval defined by their `?wait=` parameter) or a `time.Duration` string (for example "5m")
+//     defining how often to re-read the variable. New credentials are verified (unless `?verify=never`) before they replace
+//     the current credentials; if they are invalid the current credentials are kept. Calls which are in progress when the
+//     credentials are reloaded finish using the previous credentials. The credentials are watched until the context passed
+//     to `NewTwitterBroadcaster` is cancelled. "notify" requires the `?credentials=` parameter; credentials assembled from
+//     individual values can only be re-read at an interval.
+//   - `?concurrency=` The maximum number of accounts to publish a message to at the same time when the credentials contain
+//     multiple accounts. Default is `DEFAULT_CONCURRENCY`.
+//   - `?api-base=` The base URL for Twitter API requests. Default is `DEFAULT_API_BASE`.
+//   - `?upload-base=` The base URL for Twitter media upload requests. Default is `DEFAULT_UPLOAD_BASE`.
+//   - `?verify=` When to verify the credentials. Valid options are "eager" (when the broadcaster is created, retrying transient
+//     failures with a backoff), "lazy" (the first time a message is broadcast) and "never". Default is "eager".
+//   - `?verify-timeout=` The maximum amount of time (a `time.Duration` string) to spend retrying eager verification. Default is 30s.
+//   - `?max-retries=` The number of times to retry media uploads and tweets that fail because of rate limits, server errors or network
+//     errors. Rate limited requests are retried when the rate limit resets; other errors use a jittered exponential backoff. Tweets are
+//     only retried if they were rate limited or the connection could not be established, since after other failures the tweet may
+//     already have been published. Default is 3.
+//   - `?max-wait=` The maximum amount of time (a `time.Duration` string) to wait before a single retry. If a rate limit resets later than
+//     this the error is returned immediately; exponential backoffs are capped at this value. Default is 5m.
+//   - `?dedupe=` A valid `dedupe.Store` URI (for example "mem://", "file:///path/to/dedupe.json" or "sqlite:///path/to/dedupe.db")
+//     used to record the tweets published for each message, keyed on a hash of the status text and images. Messages which have
+//     already been published are not published again; instead the previously recorded tweet IDs are returned. Duplicate status
+//     (187) errors are treated as successes if a matching record exists. The "sqlite://" store requires applications to import
+//     a SQLite `database/sql` driver.
+//   - `?dedupe-ttl=` The amount of time (a `time.Duration` string) a message recorded in the dedupe store is considered to have
+//     been published. Once a record is older than this the same message, for example a recurring announcement, can be published
+//     again. A value of 0 means records never expire. Default is 12h.
+//   - `?schedule=` A valid `queue.Queue` URI (for example "mem://" or "file:///path/to/queue.json") used to store
+//     messages which are broadcast with a future send-at time (see `WithSendAt`) until they are published by `PublishScheduled`.
+//     File queues drop image data once a message is sent, failed or cancelled and prune those messages after a week (see `queue.NewFileQueue`).
+//   - `?min-interval=` The minimum amount of time (a `time.Duration` string) between messages published to the account.
+//   - `?max-per-hour=` The maximum number of messages published to the account in any one hour period.
+//   - `?quiet-hours=` A daily period, in the form "HH:MM-HH:MM" (for example "22:00-07:00"), during which no messages are published.
+//   - `?tz=` The name of the time zone (for example "America/Los_Angeles") that `?quiet-hours=` are relative to. Default is the local time zone.
+//   - `?throttle=` What to do with messages that would exceed the posting budget defined by `?min-interval=`, `?max-per-hour=` and
+//     `?quiet-hours=`. Valid options are "block" (wait until the message can be published), "queue" (add the message to the
+//     `?schedule=` queue to be published when allowed) and "fail" (return a `ThrottleError`). Default is "block". The budget is
+//     shared by all the broadcasters for the same account in the current process and each message (including threads) counts once.
 func NewTwitterBroadcaster(ctx context.Context, uri string) (broadcaster.Broadcaster, error) {
+	opts := &TwitterBroadcasterOptions{}
+	return NewTwitterBroadcasterWithOptions(ctx, uri, opts)
+}
+
+// NewTwitterBroadcasterWithOptions returns a new `TwitterBroadcaster` configured by 'uri' and 'opts'. 'uri' takes the
+// same form as the URIs passed to `NewTwitterBroadcaster`. This is principally useful for running a `TwitterBroadcaster`
+// against a local (or staging) stand-in for the Twitter API in combination with the `?api-base=` and `?upload-base=` parameters.
+func NewTwitterBroadcasterWithOptions(ctx context.Context, uri string, opts *TwitterBroadcasterOptions) (broadcaster.Broadcaster, error) {
 
 	parsed, err := url.Parse(uri)
 
@@ -47,121 +180,979 @@ func NewTwitterBroadcaster(ctx context.Context, uri string) (broadcaster.Broadca
 	}
 
 	query := parsed.Query()
-	creds_uri := query.Get("credentials")
+	load_creds, err := newCredentialsLoader(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	api := API_V2
+
+	if query.Has("api") {
+		api = query.Get("api")
+	}
+
+	if api != API_V2 && api != API_V1 {
+		return nil, fmt.Errorf("Invalid ?api= parameter, '%s'", api)
+	}
+
+	client_opts := &clientOptions{
+		HTTPClient: opts.HTTPClient,
+	}
+
+	if query.Has("api-base") {
+
+		api_base, err := parseBaseURL(query.Get("api-base"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?api-base= parameter, %w", err)
+		}
+
+		client_opts.APIBase = api_base
+	}
+
+	if query.Has("upload-base") {
+
+		upload_base, err := parseBaseURL(query.Get("upload-base"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?upload-base= parameter, %w", err)
+		}
+
+		client_opts.UploadBase = upload_base
+	}
+
+	if query.Has("chunk-size") {
+
+		chunk_size, err := strconv.ParseInt(query.Get("chunk-size"), 10, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?chunk-size= parameter, %w", err)
+		}
+
+		client_opts.ChunkSize = chunk_size
+	}
+
+	var enc encode.Encoder
+
+	if query.Has("encoder") {
+
+		enc, err = newImageEncoder(ctx, query.Get("encoder"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create encoder, %w", err)
+		}
+	}
+
+	fit_images := true
+
+	if query.Has("fit") {
+
+		v, err := strconv.ParseBool(query.Get("fit"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?fit= parameter, %w", err)
+		}
+
+		fit_images = v
+	}
+
+	require_alt := false
+
+	if query.Has("require-alt-text") {
+
+		v, err := strconv.ParseBool(query.Get("require-alt-text"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?require-alt-text= parameter, %w", err)
+		}
+
+		require_alt = v
+	}
+
+	overflow := OVERFLOW_ERROR
+
+	if query.Has("thread") {
+
+		v, err := strconv.ParseBool(query.Get("thread"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?thread= parameter, %w", err)
+		}
+
+		if v {
+			overflow = OVERFLOW_THREAD
+		}
+	}
+
+	if query.Has("overflow") {
+
+		v := query.Get("overflow")
+
+		if !isValidOverflow(v) {
+			return nil, fmt.Errorf("Invalid ?overflow= parameter, '%s'", v)
+		}
+
+		if overflow == OVERFLOW_THREAD && v != OVERFLOW_THREAD {
+			return nil, fmt.Errorf("?thread=true conflicts with ?overflow=%s", v)
+		}
+
+		overflow = v
+	}
+
+	overflow_link := query.Get("overflow-link")
+
+	if overflow == OVERFLOW_TRUNCATE_LINK && overflow_link == "" {
+		return nil, fmt.Errorf("Missing ?overflow-link= parameter, required by ?overflow=%s", OVERFLOW_TRUNCATE_LINK)
+	}
+
+	var status_t *template.Template
+
+	if query.Has("template") {
+
+		t, err := loadStatusTemplate(ctx, query.Get("template"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		status_t = t
+	}
+
+	mode := MODE_LIVE
+
+	if query.Has("mode") {
+
+		mode = query.Get("mode")
+
+		if !isValidMode(mode) {
+			return nil, fmt.Errorf("Invalid ?mode= parameter, '%s'", mode)
+		}
+	}
+
+	var shortener *urlShortener
+
+	if query.Has("shortener") {
+
+		shortener_uri := query.Get("shortener")
+
+		if !strings.Contains(shortener_uri, SHORTENER_PLACEHOLDER) {
+			return nil, fmt.Errorf("Invalid ?shortener= parameter, missing %s placeholder", SHORTENER_PLACEHOLDER)
+		}
+
+		shortener = &urlShortener{
+			uri:         shortener_uri,
+			http_client: client_opts.httpClient(),
+			dry_run:     mode == MODE_DRYRUN,
+		}
+	}
+
+	test_prefix := DEFAULT_TEST_PREFIX
+
+	if query.Has("test-prefix") {
+
+		if mode != MODE_TEST {
+			return nil, fmt.Errorf("?test-prefix= parameter is only valid when ?mode=%s", MODE_TEST)
+		}
+
+		test_prefix = query.Get("test-prefix")
+	}
+
+	verify := VERIFY_EAGER
+
+	if query.Has("verify") {
+
+		verify = query.Get("verify")
+
+		if !isValidVerify(verify) {
+			return nil, fmt.Errorf("Invalid ?verify= parameter, '%s'", verify)
+		}
+	}
+
+	verify_timeout := DEFAULT_VERIFY_TIMEOUT
+
+	if query.Has("verify-timeout") {
+
+		d, err := time.ParseDuration(query.Get("verify-timeout"))
 
-	if creds_uri == "" {
-		return nil, fmt.Errorf("Missing ?credentials= parameter")
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?verify-timeout= parameter, %w", err)
+		}
+
+		if d <= 0 {
+			return nil, fmt.Errorf("Invalid ?verify-timeout= parameter, must be greater than zero")
+		}
+
+		verify_timeout = d
+	}
+
+	max_retries := DEFAULT_MAX_RETRIES
+
+	if query.Has("max-retries") {
+
+		v, err := strconv.Atoi(query.Get("max-retries"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?max-retries= parameter, %w", err)
+		}
+
+		max_retries = v
+	}
+
+	max_wait := DEFAULT_MAX_WAIT
+
+	if query.Has("max-wait") {
+
+		d, err := time.ParseDuration(query.Get("max-wait"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?max-wait= parameter, %w", err)
+		}
+
+		max_wait = d
+	}
+
+	var dedupe_store dedupe.Store
+
+	if query.Has("dedupe") {
+
+		s, err := dedupe.NewStore(ctx, query.Get("dedupe"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?dedupe= parameter, %w", err)
+		}
+
+		dedupe_store = s
+	}
+
+	dedupe_ttl := DEFAULT_DEDUPE_TTL
+
+	if query.Has("dedupe-ttl") {
+
+		if dedupe_store == nil {
+			return nil, fmt.Errorf("?dedupe-ttl= parameter is only valid with ?dedupe=")
+		}
+
+		d, err := time.ParseDuration(query.Get("dedupe-ttl"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?dedupe-ttl= parameter, %w", err)
+		}
+
+		if d < 0 {
+			return nil, fmt.Errorf("Invalid ?dedupe-ttl= parameter, must not be negative")
+		}
+
+		dedupe_ttl = d
+	}
+
+	var schedule_queue queue.Queue
+
+	if query.Has("schedule") {
+
+		q, err := queue.NewQueue(ctx, query.Get("schedule"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?schedule= parameter, %w", err)
+		}
+
+		schedule_queue = q
+	}
+
+	var min_interval time.Duration
+
+	if query.Has("min-interval") {
+
+		d, err := time.ParseDuration(query.Get("min-interval"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?min-interval= parameter, %w", err)
+		}
+
+		if d < 0 {
+			return nil, fmt.Errorf("Invalid ?min-interval= parameter, must not be negative")
+		}
+
+		min_interval = d
+	}
+
+	var max_per_hour int
+
+	if query.Has("max-per-hour") {
+
+		v, err := strconv.Atoi(query.Get("max-per-hour"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?max-per-hour= parameter, %w", err)
+		}
+
+		if v < 0 {
+			return nil, fmt.Errorf("Invalid ?max-per-hour= parameter, must not be negative")
+		}
+
+		max_per_hour = v
+	}
+
+	var quiet *quietHours
+
+	tz := time.Local
+
+	if query.Has("tz") {
+
+		loc, err := time.LoadLocation(query.Get("tz"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?tz= parameter, %w", err)
+		}
+
+		tz = loc
+	}
+
+	if query.Has("quiet-hours") {
+
+		q, err := parseQuietHours(query.Get("quiet-hours"), tz)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?quiet-hours= parameter, %w", err)
+		}
+
+		quiet = q
+	}
+
+	throttle_mode := THROTTLE_BLOCK
+
+	if query.Has("throttle") {
+
+		throttle_mode = query.Get("throttle")
+
+		switch throttle_mode {
+		case THROTTLE_BLOCK, THROTTLE_FAIL:
+			// pass
+		case THROTTLE_QUEUE:
+
+			if schedule_queue == nil {
+				return nil, fmt.Errorf("Invalid ?throttle= parameter, '%s' requires the ?schedule= parameter", throttle_mode)
+			}
+
+		default:
+			return nil, fmt.Errorf("Invalid ?throttle= parameter, '%s'", throttle_mode)
+		}
+	}
+
+	concurrency := DEFAULT_CONCURRENCY
+
+	if query.Has("concurrency") {
+
+		v, err := strconv.Atoi(query.Get("concurrency"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?concurrency= parameter, %w", err)
+		}
+
+		if v < 1 {
+			return nil, fmt.Errorf("Invalid ?concurrency= parameter, must be at least 1")
+		}
+
+		concurrency = v
 	}
 
-	rt_ctx, rt_cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer rt_cancel()
+	log_writer := newLoggerWriter(log.Default())
+	logger := log.New(log_writer, "", 0)
 
-	str_creds, err := runtimevar.StringVar(rt_ctx, creds_uri)
+	str_creds, err := load_creds(ctx)
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to config from credentials, %w", err)
 	}
 
-	creds, err := oauth.NewOAuth1CredentialsFromString(ctx, str_creds)
+	creds, err := oauth.NewCredentialsFromString(ctx, str_creds)
 
 	if err != nil {
 		return nil, err
 	}
 
-	tw_client := anaconda.NewTwitterApiWithCredentials(creds.AccessToken, creds.AccessSecret, creds.ConsumerKey, creds.ConsumerSecret)
+	accounts_creds, has_accounts := creds.(*oauth.AccountsCredentials)
 
-	_, err = tw_client.VerifyCredentials()
+	if has_accounts {
 
-	if err != nil {
-		return nil, err
+		switch {
+		case query.Has("watch"):
+			return nil, fmt.Errorf("?watch= parameter can not be used with credentials for multiple accounts")
+		case query.Has("token-sink") && !strings.Contains(query.Get("token-sink"), ACCOUNT_PLACEHOLDER):
+			return nil, fmt.Errorf("?token-sink= parameter must contain an %s placeholder when the credentials contain multiple accounts", ACCOUNT_PLACEHOLDER)
+		case throttle_mode == THROTTLE_QUEUE:
+			return nil, fmt.Errorf("?throttle=%s can not be used with credentials for multiple accounts", THROTTLE_QUEUE)
+		}
+	}
+
+	factory := &clientFactory{
+		api:         api,
+		mode:        mode,
+		options:     client_opts,
+		max_retries: max_retries,
+		max_wait:    max_wait,
+		logger:      logger,
+	}
+
+	// Token sinks for multiple accounts are created by setAccounts
+
+	if query.Has("token-sink") && !has_accounts {
+
+		sink, err := oauth.NewTokenSink(ctx, query.Get("token-sink"))
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?token-sink= parameter, %w", err)
+		}
+
+		factory.token_sink = sink
+	}
+
+	var watcher *credentialsWatcher
+
+	if query.Has("watch") {
+
+		w, err := newCredentialsWatcher(ctx, query, load_creds, query.Get("watch"), str_creds)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid ?watch= parameter, %w", err)
+		}
+
+		watcher = w
+	}
+
+	var tw_throttle *throttle
+
+	if min_interval > 0 || max_per_hour > 0 || quiet != nil {
+
+		tw_throttle = &throttle{
+			min_interval: min_interval,
+			max_per_hour: max_per_hour,
+			quiet:        quiet,
+		}
 	}
 
-	enc, err := encode.NewEncoder(ctx, "png://")
+	encoders, err := newDefaultEncoders(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	logger := log.Default()
-
 	br := &TwitterBroadcaster{
-		twitter_client: tw_client,
-		testing:        false,
+		client_mu:      new(sync.RWMutex),
+		factory:        factory,
+		mode:           mode,
+		test_prefix:    test_prefix,
 		encoder:        enc,
+		encoders:       encoders,
+		fit_images:     fit_images,
+		require_alt:    require_alt,
+		overflow:       overflow,
+		overflow_link:  overflow_link,
+		template:       status_t,
+		shortener:      shortener,
+		verify:         verify,
+		verify_timeout: verify_timeout,
+		account_mu:     new(sync.Mutex),
+		concurrency:    concurrency,
+		dedupe:         dedupe_store,
+		dedupe_ttl:     dedupe_ttl,
+		schedule:       schedule_queue,
+		throttle:       tw_throttle,
+		throttle_mode:  throttle_mode,
 		logger:         logger,
+		log_writer:     log_writer,
+	}
+
+	if has_accounts {
+
+		err := br.setAccounts(ctx, accounts_creds, query.Get("token-sink"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return br, nil
+	}
+
+	tw_client, err := factory.newClient(ctx, creds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	br.client_state = newClientState(tw_client)
+
+	if verify == VERIFY_EAGER {
+
+		a, err := verifyWithBackoff(ctx, tw_client, verify_timeout, logger)
+
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Printf("Verified Twitter credentials for %s", a)
+		br.account = a
+	}
+
+	// The ledger is assigned once the account is (possibly) verified so that it can be keyed on the account ID
+
+	if tw_throttle != nil {
+		tw_throttle.ledger = ledgerForAccount(accountKey(creds, br.account, ""))
+	}
+
+	if watcher != nil {
+		go watcher.run(ctx, br)
 	}
 
 	return br, nil
 }
 
+// BroadcastMessage publishes 'msg' as a tweet, or as a thread of tweets if the overflow strategy is "thread"
+// and the body of 'msg' is longer than a single tweet. It returns a `uid.Int64UID` containing the tweet ID for
+// single tweets or a `uid.MultiUID` containing the IDs of every tweet, in order, for threads. In "dry-run" mode
+// the IDs are synthetic and returned as `uid.StringUID` instances. If 'ctx' carries a send-at time in the future
+// (see `WithSendAt`) the message is added to the schedule queue instead and a `uid.StringUID` identifying the
+// queued item is returned. If the credentials contain multiple accounts the message is published to each account,
+// uploading its images separately for each, and a `uid.MultiUID` containing an `AccountUID` for each account is
+// returned. If the message can not be published to one or more accounts an `AccountsError` is returned along with
+// the UIDs for the accounts which succeeded. If a thread fails part way through the error is returned along with a
+// UID for the tweets that were published, which are also recorded in the dedupe store (if present), so they can be
+// retracted.
 func (b *TwitterBroadcaster) BroadcastMessage(ctx context.Context, msg *broadcaster.Message) (uid.UID, error) {
 
-	params := url.Values{}
+	send_at, ok := sendAtFromContext(ctx)
+
+	if ok && send_at.After(time.Now()) {
+		return b.ScheduleMessage(ctx, msg, send_at)
+	}
 
-	if len(msg.Images) > 0 {
+	if b.accounts != nil {
+		return b.broadcastAccounts(ctx, msg, b.account_names)
+	}
 
-		for _, im := range msg.Images {
+	if b.verify == VERIFY_LAZY {
 
-			media_id, err := b.uploadImage(im)
+		_, err := b.Account(ctx)
 
-			if err != nil {
-				return nil, err
-			}
+		if err != nil {
+			return nil, err
+		}
+	}
 
-			str_media_id := strconv.FormatInt(media_id, 10)
+	// Validate all the image descriptions before uploading anything
 
-			params.Add("media_ids", str_media_id)
+	for idx, im := range msg.Images {
+
+		_, alt_text := imageWithAltText(im)
+
+		err := validateAltText(alt_text, b.require_alt)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid alt text for image %d, %w", idx, err)
+		}
+
+		if alt_text == "" {
+			b.logger.Printf("Warning: image %d is missing alt text", idx)
 		}
 	}
 
 	status := msg.Body
 
-	if b.testing {
-		status = fmt.Sprintf("this is a test and there may be more / please disregard and apologies for the distraction / meanwhile: %s", status)
+	if b.template != nil {
+
+		v, err := renderStatus(ctx, b.template, b.shortener, msg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		status = v
+	}
+
+	if b.mode == MODE_TEST && b.test_prefix != "" {
+		status = fmt.Sprintf("%s %s", b.test_prefix, status)
+	}
+
+	parts, err := applyOverflow(status, b.overflow, b.overflow_link)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to apply overflow strategy '%s', %w", b.overflow, err)
+	}
+
+	// Validate the text of every tweet before uploading anything
+
+	for idx, text := range parts {
+
+		var err error
+
+		if len(msg.Images) > 0 {
+			err = validateStatusContent(text)
+		} else {
+			err = ValidateStatus(text)
+		}
+
+		if err != nil {
+
+			if len(parts) > 1 {
+				return nil, fmt.Errorf("Invalid status for part %d of %d, %w", idx+1, len(parts), err)
+			}
+
+			return nil, err
+		}
+	}
+
+	if len(msg.Images) > len(parts)*MAX_IMAGES_PER_TWEET {
+		return nil, fmt.Errorf("Too many images (%d) for %d tweet(s), maximum is %d per tweet", len(msg.Images), len(parts), MAX_IMAGES_PER_TWEET)
+	}
+
+	var dedupe_key string
+
+	if b.dedupe != nil {
+
+		dedupe_key = dedupeKey(status, msg.Images)
+
+		// Messages are recorded separately for each account when the credentials contain multiple accounts
+		if b.account_name != "" {
+			dedupe_key = fmt.Sprintf("%s:%s", b.account_name, dedupe_key)
+		}
+
+		r, exists, err := b.publishedRecord(ctx, dedupe_key)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to query dedupe store, %w", err)
+		}
+
+		if exists {
+			b.logger.Printf("Message has already been published as %s, skipping", strings.Join(r.TweetIds, ","))
+			return newBroadcastUID(ctx, r.TweetIds)
+		}
+	}
+
+	tweet_ids := make([]string, 0)
+
+	// Nothing is published in "dry-run" mode so it does not count against the posting budget
+
+	if b.throttle != nil && b.mode != MODE_DRYRUN {
+
+		now := time.Now()
+
+		_, force_block := ctx.Value(throttleBlockKey{}).(bool)
+		wait := force_block || b.throttle_mode == THROTTLE_BLOCK
+
+		at, release := b.throttle.reserve(now, wait)
+
+		if at.After(now) {
+
+			switch {
+			case wait:
+
+				b.logger.Printf("Posting budget exceeded, waiting until %s", at.Format(time.RFC3339))
+
+				err := waitUntil(ctx, at)
+
+				if err != nil {
+					release()
+					return nil, err
+				}
+
+			case b.throttle_mode == THROTTLE_QUEUE:
+				b.logger.Printf("Posting budget exceeded, scheduling message for %s", at.Format(time.RFC3339))
+				return b.ScheduleMessage(ctx, msg, at)
+			default:
+				return nil, &ThrottleError{Until: at}
+			}
+		}
+
+		// Give the reserved slot back if nothing is published
+
+		defer func() {
+			if len(tweet_ids) == 0 {
+				release()
+			}
+		}()
 	}
 
-	tw, err := b.twitter_client.PostTweet(status, params)
+	// Use the same client for every request so that the message is not affected by credentials being reloaded
+
+	tw_client, release_client := b.acquireClient()
+	defer release_client()
+
+	media_ids, err := b.uploadImages(ctx, tw_client, msg.Images)
 
 	if err != nil {
 		return nil, err
 	}
 
-	b.logger.Printf("twitter post %d (media id: %s) ", tw.Id, params.Get("media_id"))
+	media_groups, err := distributeMedia(media_ids, len(parts))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, text := range parts {
+
+		tw := &tweetRequest{
+			Text: text,
+		}
+
+		for _, media_id := range media_groups[idx] {
+			tw.AddMediaId(media_id)
+		}
+
+		if idx > 0 {
+			tw.SetInReplyTo(tweet_ids[idx-1])
+		}
+
+		tweet_id, err := tw_client.PostTweet(ctx, tw)
+
+		if err != nil {
+
+			// The message may have been published by another process (or a previous attempt whose
+			// response was lost) since the dedupe store was last checked
+
+			if idx == 0 && b.dedupe != nil && errors.Is(err, ErrDuplicateStatus) {
+
+				r, exists, get_err := b.publishedRecord(ctx, dedupe_key)
+
+				if get_err == nil && exists {
+					b.logger.Printf("Duplicate status matches previously published %s, treating as success", strings.Join(r.TweetIds, ","))
+					return newBroadcastUID(ctx, r.TweetIds)
+				}
+			}
+
+			if idx == 0 {
+				return nil, err
+			}
+
+			// Record, and return, the part of the thread that was published so that it can be
+			// retracted and is not published a second time if the message is sent again
+
+			err = fmt.Errorf("Failed to post part %d of %d (after posting %s), %w", idx+1, len(parts), strings.Join(tweet_ids, ","), err)
+
+			b.recordPublished(ctx, dedupe_key, tweet_ids)
+
+			partial_uid, uid_err := newBroadcastUID(ctx, tweet_ids)
+
+			if uid_err != nil {
+				return nil, err
+			}
+
+			return partial_uid, err
+		}
+
+		b.logger.Printf("twitter post %s (media ids: %s) ", tweet_id, strings.Join(tw.MediaIds(), ","))
+
+		tweet_ids = append(tweet_ids, tweet_id)
+	}
+
+	b.recordPublished(ctx, dedupe_key, tweet_ids)
+
+	return newBroadcastUID(ctx, tweet_ids)
+}
+
+// recordPublished records 'tweet_ids' as the tweets published for the message identified by 'dedupe_key' in
+// the dedupe store, if present. Nothing is recorded in "dry-run" mode.
+func (b *TwitterBroadcaster) recordPublished(ctx context.Context, dedupe_key string, tweet_ids []string) {
+
+	if b.dedupe == nil || b.mode == MODE_DRYRUN {
+		return
+	}
+
+	r := &dedupe.Record{
+		Key:      dedupe_key,
+		TweetIds: tweet_ids,
+		Created:  time.Now(),
+	}
+
+	err := b.dedupe.Put(ctx, r)
 
-	return uid.NewInt64UID(ctx, tw.Id)
+	if err != nil {
+		b.logger.Printf("Warning: failed to record %s in dedupe store, %v", strings.Join(tweet_ids, ","), err)
+	}
 }
 
+// SetLogger assigns 'logger' as the logger for 'b', the accounts it publishes to and the clients it uses. It is safe
+// to call while messages are being published or credentials are being reloaded.
 func (b *TwitterBroadcaster) SetLogger(ctx context.Context, logger *log.Logger) error {
-	b.logger = logger
+	b.log_writer.setLogger(logger)
 	return nil
 }
 
-func (b *TwitterBroadcaster) uploadImage(im image.Image) (int64, error) {
+// Account returns the Twitter account associated with the credentials used by 'b'. If the credentials have not
+// been verified yet (because `?verify=lazy` or `?verify=never`) they are verified, and the account cached, first.
+func (b *TwitterBroadcaster) Account(ctx context.Context) (*Account, error) {
 
-	ctx := context.Background()
+	if b.accounts != nil {
+		return nil, fmt.Errorf("Credentials contain multiple accounts, use the Accounts method")
+	}
+
+	b.account_mu.Lock()
+	defer b.account_mu.Unlock()
+
+	if b.account != nil {
+		return b.account, nil
+	}
+
+	tw_client, release_client := b.acquireClient()
+	defer release_client()
+
+	a, err := tw_client.VerifyCredentials(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to verify credentials, %w", err)
+	}
+
+	b.logger.Printf("Verified Twitter credentials for %s", a)
+
+	b.account = a
+	return b.account, nil
+}
+
+// uploadImages uploads 'images', attaching any image descriptions, and returns their media IDs in order.
+func (b *TwitterBroadcaster) uploadImages(ctx context.Context, tw_client client, images []image.Image) ([]string, error) {
+
+	media_ids := make([]string, len(images))
+
+	for idx, im := range images {
+
+		im, alt_text := imageWithAltText(im)
+
+		media_id, err := b.uploadImage(ctx, tw_client, im)
 
-	// but what if GIF...
+		if err != nil {
+			return nil, err
+		}
+
+		if alt_text != "" {
+
+			err = tw_client.CreateMediaMetadata(ctx, media_id, alt_text)
+
+			if err != nil {
+
+				if b.require_alt {
+					return nil, fmt.Errorf("Failed to create alt text for image %d (media %s), %w", idx, media_id, err)
+				}
+
+				b.logger.Printf("Warning: failed to create alt text for image %d (media %s), %v", idx, media_id, err)
+			}
+		}
+
+		media_ids[idx] = media_id
+	}
+
+	return media_ids, nil
+}
+
+// uploadImage uploads 'im' and returns its media ID. If 'im' is an `EncodedImage` instance that fits
+// within Twitter's limits its original bytes are uploaded as-is. Otherwise 'im' is encoded using the
+// encoder returned by `encoderForImage` and, if enabled, fitted to Twitter's limits. Animated GIFs that
+// do not fit within Twitter's limits are rejected with an `ErrMediaRejected` error.
+func (b *TwitterBroadcaster) uploadImage(ctx context.Context, tw_client client, im image.Image) (string, error) {
+
+	if enc_im, ok := im.(*EncodedImage); ok {
+
+		if !b.fit_images || fitsLimits(enc_im) {
+			return b.uploadMedia(ctx, tw_client, enc_im.Body, enc_im.ContentType)
+		}
+
+		// Re-encoding only preserves a single frame so rather than silently posting a still image
+		// animated GIFs that exceed Twitter's limits are rejected.
+
+		if enc_im.Animated() {
+			err := fmt.Errorf("Animated GIF (%d frames, %d bytes, %dx%d) exceeds Twitter's limits and can not be fitted without losing its animation", enc_im.Frames, len(enc_im.Body), enc_im.Bounds().Dx(), enc_im.Bounds().Dy())
+			return "", &Error{Kind: ErrMediaRejected, Err: err}
+		}
+
+		b.logger.Printf("Encoded image (%s, %d bytes) exceeds Twitter's limits, re-encoding", enc_im.ContentType, len(enc_im.Body))
+		im = enc_im.Image
+	}
+
+	enc := b.encoderForImage(im)
+
+	if !b.fit_images {
+
+		out := new(bytes.Buffer)
+
+		err := enc.Encode(ctx, im, out)
+
+		if err != nil {
+			return "", fmt.Errorf("Failed to encode image, %w", err)
+		}
 
-	out := new(bytes.Buffer)
+		return b.uploadMedia(ctx, tw_client, out.Bytes(), enc.MimeType())
+	}
 
-	err := b.encoder.Encode(ctx, im, out)
+	r, err := fitImage(ctx, im, enc)
 
 	if err != nil {
-		return -1, err
+		return "", &Error{Kind: ErrMediaRejected, Err: err}
+	}
+
+	if r.Resized() {
+		b.logger.Printf("Fitted image to Twitter's limits, %s", r)
+	}
+
+	return b.uploadMedia(ctx, tw_client, r.Body, r.ContentType)
+}
+
+// encoderForImage returns the `encode.Encoder` instance to use for encoding 'im'.
+func (b *TwitterBroadcaster) encoderForImage(im image.Image) encode.Encoder {
+
+	if b.encoder != nil {
+		return b.encoder
 	}
 
-	return b.uploadMedia(out.Bytes())
+	scheme := encoderSchemeForImage(im)
+	return b.encoders[scheme]
+}
+
+func (b *TwitterBroadcaster) uploadMedia(ctx context.Context, tw_client client, body []byte, content_type string) (string, error) {
+	return tw_client.UploadMedia(ctx, body, content_type)
 }
 
-func (b *TwitterBroadcaster) uploadMedia(body []byte) (int64, error) {
+// newBroadcastUID returns a `uid.UID` instance for 'tweet_ids': a single tweet UID for one tweet or a `uid.MultiUID`
+// instance for a thread.
+func newBroadcastUID(ctx context.Context, tweet_ids []string) (uid.UID, error) {
 
-	b64_body := base64.StdEncoding.EncodeToString(body)
+	if len(tweet_ids) == 1 {
+		return newTweetUID(ctx, tweet_ids[0])
+	}
+
+	return newThreadUID(ctx, tweet_ids)
+}
 
-	rsp, err := b.twitter_client.UploadMedia(b64_body)
+// newTweetUID returns a `uid.UID` instance derived from the (string) tweet ID 'tweet_id'.
+func newTweetUID(ctx context.Context, tweet_id string) (uid.UID, error) {
+
+	// Synthetic IDs returned in "dry-run" mode
+	if strings.HasPrefix(tweet_id, DRYRUN_ID_PREFIX) {
+		return uid.NewStringUID(ctx, tweet_id)
+	}
+
+	id, err := strconv.ParseInt(tweet_id, 10, 64)
 
 	if err != nil {
-		return -1, err
+		return nil, fmt.Errorf("Failed to parse tweet ID '%s', %w", tweet_id, err)
+	}
+
+	return uid.NewInt64UID(ctx, id)
+}
+
+// newThreadUID returns a `uid.MultiUID` instance containing a `uid.Int64UID` for each of 'tweet_ids'.
+func newThreadUID(ctx context.Context, tweet_ids []string) (uid.UID, error) {
+
+	uids := make([]uid.UID, len(tweet_ids))
+
+	for idx, tweet_id := range tweet_ids {
+
+		u, err := newTweetUID(ctx, tweet_id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		uids[idx] = u
 	}
 
-	return rsp.MediaID, nil
+	return uid.NewMultiUID(ctx, uids...), nil
 }
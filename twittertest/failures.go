@@ -0,0 +1,194 @@
+package twittertest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ERROR_RATE_LIMITED is the Twitter error code for requests that exceed a rate limit.
+const ERROR_RATE_LIMITED int = 88
+
+// ERROR_DUPLICATE_STATUS is the Twitter error code for statuses that duplicate a previous status.
+const ERROR_DUPLICATE_STATUS int = 187
+
+// ERROR_OVER_CAPACITY is the Twitter error code for requests that fail because Twitter is over capacity.
+const ERROR_OVER_CAPACITY int = 130
+
+// ERROR_INTERNAL is the Twitter error code for requests that fail because of an internal error.
+const ERROR_INTERNAL int = 131
+
+// Failure defines an error response that a `Server` returns in place of the normal response for matching requests.
+type Failure struct {
+	// Method is the HTTP method of the requests to fail. If empty requests with any method are failed.
+	Method string
+	// Path is the URL path (or path prefix) of the requests to fail, for example `ENDPOINT_TWEETS`. If empty
+	// requests to any path are failed.
+	Path string
+	// AccessToken is the OAuth1 or OAuth2 access token of the requests to fail, for example the access token returned
+	// by `AddAccount`. If empty requests for any account are failed.
+	AccessToken string
+	// StatusCode is the HTTP status code of the error response.
+	StatusCode int
+	// Code is the (v1.1) Twitter error code of the error response. If 0 no error code is included.
+	Code int
+	// Message is the human-readable error message of the error response.
+	Message string
+	// Header is an optional list of additional HTTP headers to include in the error response.
+	Header http.Header
+	// Times is the number of times the failure is returned before it is removed. If 0 or less the failure
+	// is returned for every matching request until `ClearFailures` is called.
+	Times int
+}
+
+// matches returns a boolean value indicating whether 'req' should be failed by 'f'.
+func (f *Failure) matches(req *http.Request) bool {
+
+	if f.Method != "" && !strings.EqualFold(f.Method, req.Method) {
+		return false
+	}
+
+	if f.Path != "" && !strings.HasPrefix(req.URL.Path, f.Path) {
+		return false
+	}
+
+	if f.AccessToken != "" && accessToken(req) != f.AccessToken {
+		return false
+	}
+
+	return true
+}
+
+// InjectFailure causes 's' to return the error response defined by 'f' for matching requests. Failures are
+// evaluated in the order they were injected and take precedence over credential verification and validation.
+func (s *Server) InjectFailure(f *Failure) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, f)
+}
+
+// InjectRateLimit causes the next 'times' requests to 'path' to fail with a 429 "Rate limit exceeded" (88)
+// error whose rate limit headers indicate that the limit will be reset after 'reset'.
+func (s *Server) InjectRateLimit(path string, times int, reset time.Duration) {
+	s.InjectFailure(rateLimitFailure(path, times, reset))
+}
+
+// InjectAccountRateLimit causes the next 'times' requests to 'path' for the account whose access token is
+// 'access_token' to fail with a 429 "Rate limit exceeded" (88) error whose rate limit headers indicate that the
+// limit will be reset after 'reset'. Requests for other accounts are unaffected.
+func (s *Server) InjectAccountRateLimit(access_token string, path string, times int, reset time.Duration) {
+
+	f := rateLimitFailure(path, times, reset)
+	f.AccessToken = access_token
+
+	s.InjectFailure(f)
+}
+
+// rateLimitFailure returns a `Failure` for the next 'times' requests to 'path' which exceed a rate limit that
+// is reset after 'reset'.
+func rateLimitFailure(path string, times int, reset time.Duration) *Failure {
+
+	h := http.Header{}
+	h.Set("x-rate-limit-limit", "300")
+	h.Set("x-rate-limit-remaining", "0")
+	h.Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(reset).Unix(), 10))
+
+	f := &Failure{
+		Path:       path,
+		StatusCode: http.StatusTooManyRequests,
+		Code:       ERROR_RATE_LIMITED,
+		Message:    "Rate limit exceeded",
+		Header:     h,
+		Times:      times,
+	}
+
+	return f
+}
+
+// InjectDuplicateStatus causes the next 'times' requests to publish a tweet, using either the v2 or v1.1 API,
+// to fail with a 403 "Status is a duplicate" (187) error. Note that 's' also returns this error, without
+// needing to be told to, for tweets whose text is the same as a previous (undeleted) tweet.
+func (s *Server) InjectDuplicateStatus(times int) {
+
+	for _, path := range []string{ENDPOINT_TWEETS, ENDPOINT_STATUSES_UPDATE} {
+
+		f := &Failure{
+			Method:     http.MethodPost,
+			Path:       path,
+			StatusCode: http.StatusForbidden,
+			Code:       ERROR_DUPLICATE_STATUS,
+			Message:    duplicateStatusMessage(path),
+			Times:      times,
+		}
+
+		s.InjectFailure(f)
+	}
+}
+
+// InjectServerError causes the next 'times' requests to 'path' to fail with a 'status_code' (5XX) error.
+func (s *Server) InjectServerError(path string, status_code int, times int) {
+
+	code := ERROR_INTERNAL
+
+	if status_code == http.StatusServiceUnavailable {
+		code = ERROR_OVER_CAPACITY
+	}
+
+	f := &Failure{
+		Path:       path,
+		StatusCode: status_code,
+		Code:       code,
+		Message:    http.StatusText(status_code),
+		Times:      times,
+	}
+
+	s.InjectFailure(f)
+}
+
+// ClearFailures removes all the failures injected in to 's'.
+func (s *Server) ClearFailures() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = make([]*Failure, 0)
+}
+
+// nextFailure returns the first injected failure matching 'req', decrementing its remaining count, or nil.
+func (s *Server) nextFailure(req *http.Request) *Failure {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx, f := range s.failures {
+
+		if !f.matches(req) {
+			continue
+		}
+
+		if f.Times > 0 {
+
+			f.Times -= 1
+
+			if f.Times == 0 {
+				s.failures = append(s.failures[:idx], s.failures[idx+1:]...)
+			}
+		}
+
+		return f
+	}
+
+	return nil
+}
+
+func duplicateStatusMessage(path string) string {
+
+	if isV2(path) {
+		return "You are not allowed to create a Tweet with duplicate content."
+	}
+
+	return "Status is a duplicate."
+}
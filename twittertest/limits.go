@@ -0,0 +1,190 @@
+package twittertest
+
+// https://developer.twitter.com/en/docs/counting-characters
+// https://developer.twitter.com/en/docs/twitter-api/v1/media/upload-media/uploading-media/media-best-practices
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"regexp"
+	"strings"
+)
+
+// The limits enforced by a `Server` are defined independently of the `twitter` package so that tests exercise
+// the package against Twitter's documented limits rather than against its own idea of them.
+
+// API_V2 is the value of `Tweet.API` for tweets published using the v2 API.
+const API_V2 string = "v2"
+
+// API_V1 is the value of `Tweet.API` for tweets published using the v1.1 API.
+const API_V1 string = "v1.1"
+
+// MAX_STATUS_LENGTH is the maximum weighted length (see `StatusLength`) of a tweet.
+const MAX_STATUS_LENGTH int = 280
+
+// MAX_MEDIA_PER_TWEET is the maximum number of media IDs that can be attached to a tweet.
+const MAX_MEDIA_PER_TWEET int = 4
+
+// MAX_ALT_TEXT_LENGTH is the maximum number of characters in the description (alt text) of a media upload.
+const MAX_ALT_TEXT_LENGTH int = 1000
+
+// MAX_SIMPLE_UPLOAD_BYTES is the maximum size of a simple (non-chunked) media upload.
+const MAX_SIMPLE_UPLOAD_BYTES int64 = 5 * 1024 * 1024
+
+// MAX_SEGMENT_BYTES is the maximum size of a single chunked upload APPEND request.
+const MAX_SEGMENT_BYTES int64 = 5 * 1024 * 1024
+
+// MAX_IMAGE_BYTES is the maximum size of an image upload.
+const MAX_IMAGE_BYTES int64 = 5 * 1024 * 1024
+
+// MAX_GIF_BYTES is the maximum size of an (animated) GIF upload.
+const MAX_GIF_BYTES int64 = 15 * 1024 * 1024
+
+// MAX_IMAGE_DIMENSION is the maximum width and height of an image upload.
+const MAX_IMAGE_DIMENSION int = 8192
+
+// MAX_GIF_WIDTH is the maximum width of an (animated) GIF upload.
+const MAX_GIF_WIDTH int = 1280
+
+// MAX_GIF_HEIGHT is the maximum height of an (animated) GIF upload.
+const MAX_GIF_HEIGHT int = 1080
+
+// MEDIA_CATEGORY_IMAGE is the media category of image uploads.
+const MEDIA_CATEGORY_IMAGE string = "tweet_image"
+
+// MEDIA_CATEGORY_GIF is the media category of (animated) GIF uploads.
+const MEDIA_CATEGORY_GIF string = "tweet_gif"
+
+// MEDIA_CATEGORY_VIDEO is the media category of video uploads.
+const MEDIA_CATEGORY_VIDEO string = "tweet_video"
+
+// URL_LENGTH is the number of characters every URL in a tweet counts as, since Twitter wraps them using t.co.
+const URL_LENGTH int = 23
+
+// re_status_url matches URLs with a scheme, or bare domains with a common top-level domain, in a tweet.
+var re_status_url = regexp.MustCompile(`(?i)(?:https?://[^\s]+|\b(?:[a-z0-9-]+\.)+(?:com|net|org|io|co|dev|app|info)\b(?:/[^\s]*)?)`)
+
+// mediaCategory returns the media category for uploads whose MIME type is 'content_type'.
+func mediaCategory(content_type string) string {
+
+	content_type = strings.ToLower(content_type)
+
+	switch {
+	case content_type == "image/gif":
+		return MEDIA_CATEGORY_GIF
+	case strings.HasPrefix(content_type, "video/"):
+		return MEDIA_CATEGORY_VIDEO
+	default:
+		return MEDIA_CATEGORY_IMAGE
+	}
+}
+
+// StatusLength returns the weighted length of 'text' as counted by Twitter: URLs count as `URL_LENGTH` characters,
+// emoji (including sequences joined by zero-width joiners, flags and keycaps) count as 2, characters in the Latin,
+// Greek, Cyrillic and similar scripts (and common punctuation) count as 1 and everything else counts as 2.
+func StatusLength(text string) int {
+
+	text = norm.NFC.String(text)
+
+	length := 0
+	offset := 0
+
+	for _, loc := range re_status_url.FindAllStringIndex(text, -1) {
+
+		end := loc[1]
+
+		// Trailing punctuation is not part of the URL
+
+		for end > loc[0] && strings.ContainsRune(`.,;:!?'")]}`, rune(text[end-1])) {
+			end -= 1
+		}
+
+		length += textLength(text[offset:loc[0]]) + URL_LENGTH + textLength(text[end:loc[1]])
+		offset = loc[1]
+	}
+
+	return length + textLength(text[offset:])
+}
+
+// textLength returns the weighted length of 'text', which is assumed not to contain any URLs. Emoji, and characters
+// followed by emoji modifiers, count as 2.
+func textLength(text string) int {
+
+	runes := []rune(text)
+	length := 0
+
+	for i := 0; i < len(runes); {
+
+		end, modified := clusterEnd(runes, i)
+
+		if modified || isEmojiBase(runes[i]) {
+			length += 2
+		} else {
+			length += runeLength(runes[i])
+		}
+
+		i = end
+	}
+
+	return length
+}
+
+// clusterEnd returns the index of the first rune after the cluster starting at 'runes[i]', that is the character and
+// any emoji modifiers which follow it, and a boolean value indicating whether the cluster contains emoji modifiers.
+func clusterEnd(runes []rune, i int) (int, bool) {
+
+	j := i + 1
+	modified := false
+
+	for j < len(runes) {
+
+		r := runes[j]
+
+		switch {
+		case r == '\u200D' && j+1 < len(runes):
+			// Zero-width joiner sequences
+			j += 2
+			modified = true
+		case r == '\uFE0F' || r == '\u20E3' || (r >= 0x1F3FB && r <= 0x1F3FF) || (r >= 0xE0020 && r <= 0xE007F):
+			// Emoji presentation selectors, keycaps, skin tones and tags
+			j += 1
+			modified = true
+		case j == i+1 && isRegionalIndicator(runes[i]) && isRegionalIndicator(r):
+			// Flags
+			j += 1
+			modified = true
+		case r == '\uFE0E':
+			// Text presentation selectors do not change the length of the character they follow
+			j += 1
+		default:
+			return j, modified
+		}
+	}
+
+	return j, modified
+}
+
+// runeLength returns the weighted length of 'r' when it is not part of an emoji.
+func runeLength(r rune) int {
+
+	switch {
+	case r <= 0x10FF:
+		return 1
+	case r >= 0x2000 && r <= 0x200D:
+		return 1
+	case r >= 0x2010 && r <= 0x201F:
+		return 1
+	case r >= 0x2032 && r <= 0x2037:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// isEmojiBase returns a boolean value indicating whether 'r' is an emoji on its own.
+func isEmojiBase(r rune) bool {
+	return (r >= 0x1F000 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
@@ -0,0 +1,37 @@
+package twittertest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStatusLength(t *testing.T) {
+
+	tests := map[string]int{
+		"":                     0,
+		"hello world":          11,
+		"café":                 4,
+		"cafe\u0301":           4,
+		"こんにちは":                10,
+		"\U0001F600":           2,
+		"\U0001F44D\U0001F3FD": 2,
+		"\U0001F468\u200D\U0001F469\u200D\U0001F467": 2,
+		"\U0001F1FA\U0001F1F8":                       2,
+		"1\uFE0F\u20E3":                              2,
+		"\u00A9\uFE0F":                               2,
+		"\u2014":                                     1,
+		"https://example.com/a/very/long/path/that/is/longer/than/twenty/three": 23,
+		"see https://example.com.": 28,
+		"example.com":              23,
+		strings.Repeat("a", 280):   280,
+	}
+
+	for text, expected := range tests {
+
+		l := StatusLength(text)
+
+		if l != expected {
+			t.Fatalf("Expected length of '%s' to be %d, got %d", text, expected, l)
+		}
+	}
+}
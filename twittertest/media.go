@@ -0,0 +1,494 @@
+package twittertest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// MAX_VIDEO_BYTES is the maximum size of a video upload.
+const MAX_VIDEO_BYTES int64 = 512 * 1024 * 1024
+
+// MAX_SEGMENT_INDEX is the highest segment index allowed for a chunked upload APPEND request.
+const MAX_SEGMENT_INDEX int = 999
+
+// MEDIA_STATE_UPLOADING is the state of a chunked upload which has been initialized but not finalized.
+const MEDIA_STATE_UPLOADING string = "uploading"
+
+// MEDIA_STATE_IN_PROGRESS is the state of a chunked upload which has been finalized but not yet processed.
+const MEDIA_STATE_IN_PROGRESS string = "in_progress"
+
+// MEDIA_STATE_SUCCEEDED is the state of media which can be attached to a tweet.
+const MEDIA_STATE_SUCCEEDED string = "succeeded"
+
+// errInvalidMediaId is the error returned for requests which reference an unknown (or unfinished) media ID.
+var errInvalidMediaId = &apiError{http.StatusBadRequest, 324, "Invalid media_id parameter."}
+
+// Media is a record of a media file uploaded to a `Server`.
+type Media struct {
+	// Id is the media ID of the upload.
+	Id string
+	// Category is the Twitter media category of the upload, for example "tweet_image".
+	Category string
+	// ContentType is the MIME type of the upload.
+	ContentType string
+	// Chunked is a boolean flag indicating whether the media was sent using a chunked upload.
+	Chunked bool
+	// Size is the size of the upload in bytes.
+	Size int64
+	// Width is the width of the upload in pixels (images only).
+	Width int
+	// Height is the height of the upload in pixels (images only).
+	Height int
+	// AltText is the description (alt text) associated with the upload.
+	AltText string
+	// State is the processing state of the upload.
+	State string
+	// Body is the body of the upload.
+	Body []byte
+
+	total_bytes      int64
+	segments         map[int][]byte
+	checks_remaining int
+}
+
+func (s *Server) handleMediaUpload(rsp http.ResponseWriter, req *http.Request, form url.Values, files map[string][]byte) {
+
+	if req.Method == http.MethodGet {
+		s.handleMediaStatus(rsp, req, req.URL.Query())
+		return
+	}
+
+	if req.Method != http.MethodPost {
+		s.writeError(rsp, req, &apiError{http.StatusMethodNotAllowed, 0, "Method not allowed"}, nil)
+		return
+	}
+
+	switch form.Get("command") {
+	case "":
+		s.handleMediaSimple(rsp, req, form, files)
+	case "INIT":
+		s.handleMediaInit(rsp, req, form)
+	case "APPEND":
+		s.handleMediaAppend(rsp, req, form, files)
+	case "FINALIZE":
+		s.handleMediaFinalize(rsp, req, form)
+	default:
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 0, fmt.Sprintf("Invalid command '%s'", form.Get("command"))}, nil)
+	}
+}
+
+func (s *Server) handleMediaSimple(rsp http.ResponseWriter, req *http.Request, form url.Values, files map[string][]byte) {
+
+	body, ok := files["media"]
+
+	if !ok {
+
+		data := form.Get("media_data")
+
+		if data == "" {
+			s.writeError(rsp, req, &apiError{http.StatusBadRequest, 38, "media parameter is missing."}, nil)
+			return
+		}
+
+		v, err := base64.StdEncoding.DecodeString(data)
+
+		if err != nil {
+			s.writeError(rsp, req, &apiError{http.StatusBadRequest, 324, "Invalid media_data, not base64-encoded."}, nil)
+			return
+		}
+
+		body = v
+	}
+
+	content_type := http.DetectContentType(body)
+	category := form.Get("media_category")
+
+	if category == "" {
+		category = mediaCategory(content_type)
+	}
+
+	if category != MEDIA_CATEGORY_IMAGE || int64(len(body)) > MAX_SIMPLE_UPLOAD_BYTES {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 324, "Simple uploads are limited to images no larger than 5MB, use a chunked upload."}, nil)
+		return
+	}
+
+	m := &Media{
+		Category:    category,
+		ContentType: content_type,
+		Size:        int64(len(body)),
+		Body:        body,
+		State:       MEDIA_STATE_SUCCEEDED,
+	}
+
+	e := validateMedia(m)
+
+	if e != nil {
+		s.writeError(rsp, req, e, nil)
+		return
+	}
+
+	s.mu.Lock()
+	m.Id = s.nextId()
+	s.media[m.Id] = m
+	s.mu.Unlock()
+
+	s.writeJSON(rsp, http.StatusOK, mediaResponse(m))
+}
+
+func (s *Server) handleMediaInit(rsp http.ResponseWriter, req *http.Request, form url.Values) {
+
+	total_bytes, err := strconv.ParseInt(form.Get("total_bytes"), 10, 64)
+
+	if err != nil || total_bytes <= 0 {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 324, "Invalid total_bytes parameter."}, nil)
+		return
+	}
+
+	content_type := form.Get("media_type")
+
+	if content_type == "" {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 38, "media_type parameter is missing."}, nil)
+		return
+	}
+
+	category := form.Get("media_category")
+
+	if category == "" {
+		category = mediaCategory(content_type)
+	}
+
+	max_bytes := maxBytes(category)
+
+	if total_bytes > max_bytes {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 324, fmt.Sprintf("File size exceeds %d bytes.", max_bytes)}, nil)
+		return
+	}
+
+	m := &Media{
+		Category:    category,
+		ContentType: content_type,
+		Chunked:     true,
+		State:       MEDIA_STATE_UPLOADING,
+		total_bytes: total_bytes,
+		segments:    make(map[int][]byte),
+	}
+
+	s.mu.Lock()
+	m.Id = s.nextId()
+	s.media[m.Id] = m
+	s.mu.Unlock()
+
+	s.writeJSON(rsp, http.StatusAccepted, mediaResponse(m))
+}
+
+func (s *Server) handleMediaAppend(rsp http.ResponseWriter, req *http.Request, form url.Values, files map[string][]byte) {
+
+	chunk, ok := files["media"]
+
+	if !ok {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 38, "media parameter is missing."}, nil)
+		return
+	}
+
+	if int64(len(chunk)) > MAX_SEGMENT_BYTES {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 324, fmt.Sprintf("Segment exceeds %d bytes.", MAX_SEGMENT_BYTES)}, nil)
+		return
+	}
+
+	segment, err := strconv.Atoi(form.Get("segment_index"))
+
+	if err != nil || segment < 0 || segment > MAX_SEGMENT_INDEX {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 324, "Invalid segment_index parameter."}, nil)
+		return
+	}
+
+	e := s.appendMedia(form.Get("media_id"), segment, chunk)
+
+	if e != nil {
+		s.writeError(rsp, req, e, nil)
+		return
+	}
+
+	rsp.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleMediaFinalize(rsp http.ResponseWriter, req *http.Request, form url.Values) {
+
+	v, e := s.finalizeMedia(form.Get("media_id"))
+
+	if e != nil {
+		s.writeError(rsp, req, e, nil)
+		return
+	}
+
+	s.writeJSON(rsp, http.StatusOK, v)
+}
+
+func (s *Server) handleMediaStatus(rsp http.ResponseWriter, req *http.Request, query url.Values) {
+
+	if query.Get("command") != "STATUS" {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 0, "Invalid command"}, nil)
+		return
+	}
+
+	v, e := s.mediaStatus(query.Get("media_id"))
+
+	if e != nil {
+		s.writeError(rsp, req, e, nil)
+		return
+	}
+
+	s.writeJSON(rsp, http.StatusOK, v)
+}
+
+// mediaMetadataRequest is the JSON body of a `POST /1.1/media/metadata/create.json` request.
+type mediaMetadataRequest struct {
+	MediaId string `json:"media_id"`
+	AltText *struct {
+		Text string `json:"text"`
+	} `json:"alt_text"`
+}
+
+func (s *Server) handleMediaMetadata(rsp http.ResponseWriter, req *http.Request, body []byte) {
+
+	var md *mediaMetadataRequest
+
+	err := json.Unmarshal(body, &md)
+
+	if err != nil || md == nil || md.AltText == nil {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 0, "Invalid JSON body"}, nil)
+		return
+	}
+
+	if utf8.RuneCountInString(md.AltText.Text) > MAX_ALT_TEXT_LENGTH {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 0, fmt.Sprintf("Alt text exceeds %d characters.", MAX_ALT_TEXT_LENGTH)}, nil)
+		return
+	}
+
+	s.mu.Lock()
+
+	m, ok := s.media[md.MediaId]
+
+	if ok {
+		m.AltText = md.AltText.Text
+	}
+
+	s.mu.Unlock()
+
+	if !ok {
+		s.writeError(rsp, req, errInvalidMediaId, nil)
+		return
+	}
+
+	rsp.WriteHeader(http.StatusOK)
+}
+
+// appendMedia adds 'chunk' as segment 'segment' of the chunked upload 'media_id'.
+func (s *Server) appendMedia(media_id string, segment int, chunk []byte) *apiError {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.media[media_id]
+
+	if !ok || m.State != MEDIA_STATE_UPLOADING {
+		return errInvalidMediaId
+	}
+
+	m.segments[segment] = chunk
+	return nil
+}
+
+// finalizeMedia assembles and validates the segments of the chunked upload 'media_id' and returns the
+// body of the FINALIZE response.
+func (s *Server) finalizeMedia(media_id string) (map[string]interface{}, *apiError) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.media[media_id]
+
+	if !ok || m.State != MEDIA_STATE_UPLOADING {
+		return nil, errInvalidMediaId
+	}
+
+	indices := make([]int, 0, len(m.segments))
+
+	for idx := range m.segments {
+		indices = append(indices, idx)
+	}
+
+	sort.Ints(indices)
+
+	var buf bytes.Buffer
+
+	for i, idx := range indices {
+
+		if i != idx {
+			return nil, &apiError{http.StatusBadRequest, 324, fmt.Sprintf("Segment %d is missing.", i)}
+		}
+
+		buf.Write(m.segments[idx])
+	}
+
+	if int64(buf.Len()) != m.total_bytes {
+		return nil, &apiError{http.StatusBadRequest, 324, fmt.Sprintf("File size mismatch, expected %d bytes but received %d.", m.total_bytes, buf.Len())}
+	}
+
+	m.Body = buf.Bytes()
+	m.Size = int64(buf.Len())
+	m.segments = nil
+
+	e := validateMedia(m)
+
+	if e != nil {
+		delete(s.media, m.Id)
+		return nil, e
+	}
+
+	m.State = MEDIA_STATE_SUCCEEDED
+	processing := m.Category != MEDIA_CATEGORY_IMAGE
+
+	if s.processing_checks > 0 {
+		m.State = MEDIA_STATE_IN_PROGRESS
+		m.checks_remaining = s.processing_checks
+		processing = true
+	}
+
+	return s.mediaProcessingResponse(m, processing), nil
+}
+
+// mediaStatus advances the processing of 'media_id' and returns the body of the STATUS response.
+func (s *Server) mediaStatus(media_id string) (map[string]interface{}, *apiError) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.media[media_id]
+
+	if !ok || m.State == MEDIA_STATE_UPLOADING {
+		return nil, errInvalidMediaId
+	}
+
+	if m.State == MEDIA_STATE_IN_PROGRESS {
+
+		m.checks_remaining -= 1
+
+		if m.checks_remaining <= 0 {
+			m.State = MEDIA_STATE_SUCCEEDED
+		}
+	}
+
+	return s.mediaProcessingResponse(m, true), nil
+}
+
+// validateMedia ensures that 'm' does not exceed Twitter's size and dimension limits.
+func validateMedia(m *Media) *apiError {
+
+	max_bytes := maxBytes(m.Category)
+
+	if m.Size > max_bytes {
+		return &apiError{http.StatusBadRequest, 324, fmt.Sprintf("File size exceeds %d bytes.", max_bytes)}
+	}
+
+	if m.Category == MEDIA_CATEGORY_VIDEO {
+		return nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(m.Body))
+
+	if err != nil {
+		return &apiError{http.StatusBadRequest, 324, "Unsupported or unrecognized media type."}
+	}
+
+	m.Width = cfg.Width
+	m.Height = cfg.Height
+
+	max_w, max_h := MAX_IMAGE_DIMENSION, MAX_IMAGE_DIMENSION
+
+	if mediaCategory(m.ContentType) == MEDIA_CATEGORY_GIF {
+		max_w, max_h = MAX_GIF_WIDTH, MAX_GIF_HEIGHT
+	}
+
+	if cfg.Width > max_w || cfg.Height > max_h {
+		return &apiError{http.StatusBadRequest, 324, fmt.Sprintf("Image dimensions (%dx%d) exceed %dx%d.", cfg.Width, cfg.Height, max_w, max_h)}
+	}
+
+	return nil
+}
+
+// maxBytes returns the maximum size of an upload in 'category'.
+func maxBytes(category string) int64 {
+
+	switch category {
+	case MEDIA_CATEGORY_VIDEO:
+		return MAX_VIDEO_BYTES
+	case MEDIA_CATEGORY_GIF:
+		return MAX_GIF_BYTES
+	default:
+		return MAX_IMAGE_BYTES
+	}
+}
+
+func mediaResponse(m *Media) map[string]interface{} {
+
+	id, _ := strconv.ParseInt(m.Id, 10, 64)
+
+	rsp := map[string]interface{}{
+		"media_id":           id,
+		"media_id_string":    m.Id,
+		"expires_after_secs": 86400,
+	}
+
+	if m.Size > 0 {
+		rsp["size"] = m.Size
+	}
+
+	return rsp
+}
+
+// mediaProcessingResponse returns the response for a FINALIZE or STATUS request for 'm', including
+// its processing information if 'processing' is true. Callers must hold 's.mu'.
+func (s *Server) mediaProcessingResponse(m *Media, processing bool) map[string]interface{} {
+
+	rsp := mediaResponse(m)
+
+	if !processing {
+		return rsp
+	}
+
+	info := map[string]interface{}{
+		"state": m.State,
+	}
+
+	switch m.State {
+	case MEDIA_STATE_SUCCEEDED:
+		info["progress_percent"] = 100
+	default:
+		info["check_after_secs"] = s.check_after_secs
+		info["progress_percent"] = 100 * (s.processing_checks - m.checks_remaining) / s.processing_checks
+	}
+
+	rsp["processing_info"] = info
+	return rsp
+}
+
+func (m *Media) copy() *Media {
+
+	c := *m
+	c.Body = append([]byte{}, m.Body...)
+	c.segments = nil
+
+	return &c
+}
@@ -0,0 +1,209 @@
+package twittertest
+
+// https://developer.twitter.com/en/docs/authentication/oauth-1-0a/creating-a-signature
+// https://www.rfc-editor.org/rfc/rfc5849#section-3.4
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// verifySignature ensures that 'req' has a valid OAuth1 (HMAC-SHA1) Authorization header signed using
+// the consumer and access credentials for one of the accounts assigned to 's' and returns that account.
+// 'form' is the list of form-encoded parameters included in the request body, if any.
+func (s *Server) verifySignature(req *http.Request, form url.Values) (*User, error) {
+
+	params, err := parseAuthorizationHeader(req.Header.Get("Authorization"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Get("oauth_signature_method") != "HMAC-SHA1" {
+		return nil, fmt.Errorf("Unsupported signature method '%s'", params.Get("oauth_signature_method"))
+	}
+
+	s.mu.Lock()
+	a, ok := s.accounts[params.Get("oauth_token")]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("Invalid access token")
+	}
+
+	creds := a.credentials
+
+	if params.Get("oauth_consumer_key") != creds.ConsumerKey {
+		return nil, fmt.Errorf("Invalid consumer key")
+	}
+
+	if params.Get("oauth_nonce") == "" || params.Get("oauth_timestamp") == "" {
+		return nil, fmt.Errorf("Missing nonce or timestamp")
+	}
+
+	signature := params.Get("oauth_signature")
+
+	if signature == "" {
+		return nil, fmt.Errorf("Missing signature")
+	}
+
+	params.Del("oauth_signature")
+	params.Del("realm")
+
+	for k, v := range req.URL.Query() {
+		params[k] = append(params[k], v...)
+	}
+
+	for k, v := range form {
+		params[k] = append(params[k], v...)
+	}
+
+	base := signatureBaseString(req, params)
+
+	key := encodeParameter(creds.ConsumerSecret) + "&" + encodeParameter(creds.AccessSecret)
+
+	mac := hmac.New(sha1.New, []byte(key))
+	mac.Write([]byte(base))
+
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, fmt.Errorf("Invalid signature")
+	}
+
+	return a.user, nil
+}
+
+// accessToken returns the OAuth1 or OAuth2 access token in the Authorization header of 'req' or an empty string
+// if it does not have one.
+func accessToken(req *http.Request) string {
+
+	header := req.Header.Get("Authorization")
+
+	if strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+
+	params, err := parseAuthorizationHeader(header)
+
+	if err != nil {
+		return ""
+	}
+
+	return params.Get("oauth_token")
+}
+
+// parseAuthorizationHeader returns the (decoded) parameters in an OAuth1 Authorization header.
+func parseAuthorizationHeader(header string) (url.Values, error) {
+
+	if !strings.HasPrefix(header, "OAuth ") {
+		return nil, fmt.Errorf("Missing or invalid Authorization header")
+	}
+
+	params := url.Values{}
+
+	for _, pair := range strings.Split(strings.TrimPrefix(header, "OAuth "), ",") {
+
+		pair = strings.TrimSpace(pair)
+
+		if pair == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(pair, "=")
+
+		if !ok {
+			return nil, fmt.Errorf("Invalid Authorization header parameter '%s'", pair)
+		}
+
+		v = strings.Trim(v, `"`)
+
+		decoded, err := url.PathUnescape(v)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Authorization header parameter '%s', %w", k, err)
+		}
+
+		params.Set(k, decoded)
+	}
+
+	return params, nil
+}
+
+// signatureBaseString returns the OAuth1 signature base string for 'req' and 'params'.
+func signatureBaseString(req *http.Request, params url.Values) string {
+
+	scheme := "http"
+
+	if req.TLS != nil {
+		scheme = "https"
+	}
+
+	host := strings.ToLower(req.Host)
+
+	switch {
+	case scheme == "http" && strings.HasSuffix(host, ":80"):
+		host = strings.TrimSuffix(host, ":80")
+	case scheme == "https" && strings.HasSuffix(host, ":443"):
+		host = strings.TrimSuffix(host, ":443")
+	}
+
+	base_url := fmt.Sprintf("%s://%s%s", scheme, host, req.URL.EscapedPath())
+
+	pairs := make([]string, 0)
+
+	for k, values := range params {
+
+		for _, v := range values {
+			pairs = append(pairs, encodeParameter(k)+"="+encodeParameter(v))
+		}
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join([]string{
+		encodeParameter(strings.ToUpper(req.Method)),
+		encodeParameter(base_url),
+		encodeParameter(strings.Join(pairs, "&")),
+	}, "&")
+}
+
+// encodeParameter percent-encodes 's' according to RFC 3986 (section 2.1) as required by OAuth1.
+func encodeParameter(s string) string {
+
+	var buf strings.Builder
+
+	for i := 0; i < len(s); i++ {
+
+		c := s[i]
+
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+
+	return buf.String()
+}
+
+// isFormEncoded returns a boolean value indicating whether the body of 'req' is form-encoded and therefore
+// included when calculating its OAuth1 signature.
+func isFormEncoded(req *http.Request) bool {
+
+	media_type, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	if err != nil {
+		return false
+	}
+
+	return media_type == "application/x-www-form-urlencoded"
+}
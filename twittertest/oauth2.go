@@ -0,0 +1,155 @@
+package twittertest
+
+// https://developer.twitter.com/en/docs/authentication/oauth-2-0/user-access-token
+
+import (
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DEFAULT_OAUTH2_EXPIRES_IN is the number of seconds that the OAuth2 access tokens issued by a `Server` are valid for.
+const DEFAULT_OAUTH2_EXPIRES_IN int = 7200
+
+// DefaultOAuth2Credentials returns a set of OAuth2 credentials, whose access token expires in two hours, for use
+// with the `ServerOptions.OAuth2` property.
+func DefaultOAuth2Credentials() *oauth.OAuth2Credentials {
+
+	creds := &oauth.OAuth2Credentials{
+		ClientId:     "twittertest-client-id",
+		AccessToken:  "twittertest-oauth2-access-token",
+		RefreshToken: "twittertest-oauth2-refresh-token",
+		TokenType:    "bearer",
+		Scope:        "tweet.read tweet.write users.read offline.access",
+		ExpiresAt:    time.Now().Add(time.Duration(DEFAULT_OAUTH2_EXPIRES_IN) * time.Second),
+	}
+
+	return creds
+}
+
+// OAuth2Credentials returns the current OAuth2 credentials for 's' and a boolean value indicating whether 's' was
+// configured with OAuth2 credentials. The credentials change each time the access token is refreshed.
+func (s *Server) OAuth2Credentials() (*oauth.OAuth2Credentials, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.oauth2 == nil {
+		return nil, false
+	}
+
+	creds := *s.oauth2
+	return &creds, true
+}
+
+// OAuth2Refreshes returns the number of times the OAuth2 access token for 's' has been refreshed.
+func (s *Server) OAuth2Refreshes() int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.oauth2_refreshes
+}
+
+// OAuth2BroadcasterURI returns a URI for use with `NewTwitterBroadcasterWithOptions` which targets 's' using its
+// current OAuth2 credentials. Any values in 'params' are appended to the URI.
+func (s *Server) OAuth2BroadcasterURI(params url.Values) (string, error) {
+
+	creds, ok := s.OAuth2Credentials()
+
+	if !ok {
+		return "", fmt.Errorf("Server does not have OAuth2 credentials")
+	}
+
+	return s.broadcasterURI(params, creds)
+}
+
+// verifyBearerToken ensures that 'req' has an Authorization header containing the current, unexpired, OAuth2
+// access token for 's' and returns the account it belongs to, which is always the server's default account.
+func (s *Server) verifyBearerToken(req *http.Request) (*User, error) {
+
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.oauth2 == nil {
+		return nil, fmt.Errorf("OAuth2 is not enabled")
+	}
+
+	if token != s.oauth2.AccessToken {
+		return nil, fmt.Errorf("Invalid access token")
+	}
+
+	if !s.oauth2.ExpiresAt.IsZero() && time.Now().After(s.oauth2.ExpiresAt) {
+		return nil, fmt.Errorf("Access token has expired")
+	}
+
+	return s.user, nil
+}
+
+// handleOAuth2Token exchanges a refresh token for a new access token (and refresh token).
+func (s *Server) handleOAuth2Token(rsp http.ResponseWriter, req *http.Request, form url.Values) {
+	status_code, body := s.refreshOAuth2Token(req, form)
+	s.writeJSON(rsp, status_code, body)
+}
+
+// refreshOAuth2Token validates the refresh token request 'req' and, if valid, issues a new access token
+// (and refresh token). It returns the status code and body of the response.
+func (s *Server) refreshOAuth2Token(req *http.Request, form url.Values) (int, map[string]interface{}) {
+
+	invalid := func(description string) (int, map[string]interface{}) {
+		return http.StatusBadRequest, map[string]interface{}{
+			"error":             "invalid_request",
+			"error_description": description,
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.oauth2 == nil {
+		return invalid("OAuth2 is not enabled.")
+	}
+
+	if form.Get("grant_type") != "refresh_token" {
+		return invalid("Unsupported grant type.")
+	}
+
+	if form.Get("client_id") != s.oauth2.ClientId {
+		return invalid("Missing required parameter [client_id].")
+	}
+
+	if s.oauth2.ClientSecret != "" {
+
+		id, secret, ok := req.BasicAuth()
+
+		if !ok || id != s.oauth2.ClientId || secret != s.oauth2.ClientSecret {
+			return http.StatusUnauthorized, map[string]interface{}{
+				"error":             "unauthorized_client",
+				"error_description": "Missing valid authorization header.",
+			}
+		}
+	}
+
+	if s.oauth2.RefreshToken == "" || form.Get("refresh_token") != s.oauth2.RefreshToken {
+		return invalid("Value passed for the token was invalid.")
+	}
+
+	s.oauth2_refreshes += 1
+
+	s.oauth2.AccessToken = fmt.Sprintf("twittertest-oauth2-access-token-%d", s.oauth2_refreshes)
+	s.oauth2.RefreshToken = fmt.Sprintf("twittertest-oauth2-refresh-token-%d", s.oauth2_refreshes)
+	s.oauth2.ExpiresAt = time.Now().Add(time.Duration(DEFAULT_OAUTH2_EXPIRES_IN) * time.Second)
+
+	return http.StatusOK, map[string]interface{}{
+		"token_type":    "bearer",
+		"expires_in":    DEFAULT_OAUTH2_EXPIRES_IN,
+		"access_token":  s.oauth2.AccessToken,
+		"refresh_token": s.oauth2.RefreshToken,
+		"scope":         s.oauth2.Scope,
+	}
+}
@@ -0,0 +1,651 @@
+// Package twittertest provides an in-process stand-in for the parts of the Twitter API used by
+// `TwitterBroadcaster` for use in tests.
+package twittertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ENDPOINT_VERIFY_CREDENTIALS is the path of the v2 credentials verification endpoint.
+const ENDPOINT_VERIFY_CREDENTIALS string = "/2/users/me"
+
+// ENDPOINT_VERIFY_CREDENTIALS_V1 is the path of the v1.1 credentials verification endpoint.
+const ENDPOINT_VERIFY_CREDENTIALS_V1 string = "/1.1/account/verify_credentials.json"
+
+// ENDPOINT_TWEETS is the path of the v2 endpoint for publishing (POST) and deleting (DELETE /2/tweets/{ID}) tweets.
+const ENDPOINT_TWEETS string = "/2/tweets"
+
+// ENDPOINT_STATUSES_UPDATE is the path of the v1.1 endpoint for publishing tweets.
+const ENDPOINT_STATUSES_UPDATE string = "/1.1/statuses/update.json"
+
+// ENDPOINT_STATUSES_DESTROY is the path prefix of the v1.1 endpoint for deleting tweets (POST /1.1/statuses/destroy/{ID}.json).
+const ENDPOINT_STATUSES_DESTROY string = "/1.1/statuses/destroy/"
+
+// ENDPOINT_MEDIA_UPLOAD is the path of the (simple and chunked) media upload endpoint.
+const ENDPOINT_MEDIA_UPLOAD string = "/1.1/media/upload.json"
+
+// ENDPOINT_OAUTH2_TOKEN is the path of the OAuth2 token (refresh) endpoint.
+const ENDPOINT_OAUTH2_TOKEN string = "/2/oauth2/token"
+
+// ENDPOINT_MEDIA_METADATA is the path of the media metadata (alt text) endpoint.
+const ENDPOINT_MEDIA_METADATA string = "/1.1/media/metadata/create.json"
+
+// first_id is the first (snowflake-like) ID assigned to tweets and media.
+const first_id int64 = 1580000000000000000
+
+// User defines the account associated with the credentials a `Server` accepts.
+type User struct {
+	// Id is the numeric ID of the account.
+	Id string `json:"id"`
+	// Name is the display name of the account.
+	Name string `json:"name"`
+	// Username is the username (screen name) of the account.
+	Username string `json:"username"`
+}
+
+// account is an account, and the OAuth1 credentials for it, that a `Server` accepts.
+type account struct {
+	credentials *oauth.OAuth1Credentials
+	user        *User
+}
+
+// ServerOptions defines configuration options for creating a new `Server` instance.
+type ServerOptions struct {
+	// Credentials are the OAuth1 credentials that requests must be signed with. If nil `DefaultCredentials` is used.
+	Credentials *oauth.OAuth1Credentials
+	// OAuth2 are the (optional) OAuth2 credentials whose access token requests may be authorized with instead of
+	// being signed with 'Credentials'. The access token can be refreshed using `ENDPOINT_OAUTH2_TOKEN`.
+	OAuth2 *oauth.OAuth2Credentials
+	// User is the account associated with 'Credentials'. If nil a default account is used.
+	User *User
+	// ProcessingChecks is the number of STATUS requests a chunked upload reports as "in_progress" before it
+	// "succeeds". If 0 chunked uploads are processed immediately.
+	ProcessingChecks int
+	// CheckAfterSecs is the `check_after_secs` value reported for chunked uploads that are being processed.
+	CheckAfterSecs int
+}
+
+// Request is a record of a request sent to a `Server`.
+type Request struct {
+	// Method is the HTTP method of the request.
+	Method string
+	// Path is the URL path of the request.
+	Path string
+	// Query is the URL query of the request.
+	Query url.Values
+	// Form is the list of form-encoded (or multipart, excluding files) parameters in the request body.
+	Form url.Values
+	// Header is the HTTP header of the request.
+	Header http.Header
+	// Body is the raw body of the request.
+	Body []byte
+	// AccessToken is the OAuth1 or OAuth2 access token the request was signed or authorized with, if any.
+	AccessToken string
+	// StatusCode is the HTTP status code of the response returned by the `Server`.
+	StatusCode int
+	// Time is the time the request was received.
+	Time time.Time
+}
+
+// Tweet is a record of a tweet published using a `Server`.
+type Tweet struct {
+	// Id is the ID of the tweet.
+	Id string
+	// Text is the text of the tweet.
+	Text string
+	// MediaIds is the list of media IDs attached to the tweet.
+	MediaIds []string
+	// InReplyTo is the ID of the tweet this tweet is a reply to, if any.
+	InReplyTo string
+	// API is the Twitter API ("v2" or "v1.1") used to publish the tweet.
+	API string
+	// UserId is the ID of the account which published the tweet.
+	UserId string
+	// Deleted is a boolean flag indicating whether the tweet has been deleted.
+	Deleted bool
+	// Created is the time the tweet was published.
+	Created time.Time
+}
+
+// Server is an `httptest.Server` instance implementing the Twitter API endpoints used by `TwitterBroadcaster`:
+// credential verification, simple and chunked media uploads, media metadata and publishing and deleting tweets
+// using both the v2 and v1.1 APIs. Every request must be signed with the OAuth1 credentials for one of the server's
+// accounts (see `AddAccount`) or authorized with its OAuth2 access token, if configured. Statuses longer than `MAX_STATUS_LENGTH` (as measured by
+// `StatusLength`), duplicate statuses and media which exceed Twitter's limits are rejected with the same error
+// codes as Twitter. Every request is recorded and errors can be injected using `InjectFailure`.
+type Server struct {
+	*httptest.Server
+	credentials       *oauth.OAuth1Credentials
+	oauth2            *oauth.OAuth2Credentials
+	oauth2_refreshes  int
+	user              *User
+	accounts          map[string]*account
+	processing_checks int
+	check_after_secs  int
+	mu                *sync.Mutex
+	next_id           int64
+	requests          []*Request
+	tweets            []*Tweet
+	media             map[string]*Media
+	failures          []*Failure
+}
+
+// apiError is an error response returned by a `Server`.
+type apiError struct {
+	StatusCode int
+	Code       int
+	Message    string
+}
+
+// DefaultCredentials returns the OAuth1 credentials used by a `Server` when none are specified.
+func DefaultCredentials() *oauth.OAuth1Credentials {
+
+	creds := &oauth.OAuth1Credentials{
+		ConsumerKey:    "twittertest-consumer-key",
+		ConsumerSecret: "twittertest-consumer-secret",
+		AccessToken:    "twittertest-access-token",
+		AccessSecret:   "twittertest-access-secret",
+	}
+
+	return creds
+}
+
+// NewServer starts and returns a new `Server` instance configured by 'opts' (which may be nil). Callers
+// should call the `Close` method when they are finished with it.
+func NewServer(ctx context.Context, opts *ServerOptions) (*Server, error) {
+
+	if opts == nil {
+		opts = &ServerOptions{}
+	}
+
+	if opts.ProcessingChecks < 0 || opts.CheckAfterSecs < 0 {
+		return nil, fmt.Errorf("Invalid processing options, values must not be negative")
+	}
+
+	creds := opts.Credentials
+
+	if creds == nil {
+		creds = DefaultCredentials()
+	}
+
+	user := opts.User
+
+	if user == nil {
+		user = &User{
+			Id:       "1234567890",
+			Name:     "Twitter Test",
+			Username: "twittertest",
+		}
+	}
+
+	var oauth2_creds *oauth.OAuth2Credentials
+
+	if opts.OAuth2 != nil {
+		c := *opts.OAuth2
+		oauth2_creds = &c
+	}
+
+	s := &Server{
+		credentials:       creds,
+		oauth2:            oauth2_creds,
+		user:              user,
+		accounts:          make(map[string]*account),
+		processing_checks: opts.ProcessingChecks,
+		check_after_secs:  opts.CheckAfterSecs,
+		mu:                new(sync.Mutex),
+		next_id:           first_id,
+		requests:          make([]*Request, 0),
+		tweets:            make([]*Tweet, 0),
+		media:             make(map[string]*Media),
+		failures:          make([]*Failure, 0),
+	}
+
+	s.accounts[creds.AccessToken] = &account{credentials: creds, user: user}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handleRequest))
+	return s, nil
+}
+
+// AddAccount adds an account for 'user' to 's' and returns the OAuth1 credentials that requests for it must be signed
+// with. The credentials share the consumer key and secret of the server's default credentials. Tweets are recorded,
+// and checked for duplicates, separately for each account.
+func (s *Server) AddAccount(user *User) (*oauth.OAuth1Credentials, error) {
+
+	if user == nil || user.Id == "" {
+		return nil, fmt.Errorf("Missing user ID")
+	}
+
+	// Twitter access tokens start with the ID of the account they belong to
+
+	creds := &oauth.OAuth1Credentials{
+		ConsumerKey:    s.credentials.ConsumerKey,
+		ConsumerSecret: s.credentials.ConsumerSecret,
+		AccessToken:    fmt.Sprintf("%s-twittertest-access-token", user.Id),
+		AccessSecret:   fmt.Sprintf("twittertest-access-secret-%s", user.Id),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.accounts[creds.AccessToken]
+
+	if exists || user.Id == s.user.Id {
+		return nil, fmt.Errorf("Account %s already exists", user.Id)
+	}
+
+	s.accounts[creds.AccessToken] = &account{credentials: creds, user: user}
+
+	c := *creds
+	return &c, nil
+}
+
+// Credentials returns the OAuth1 credentials that requests to 's' must be signed with.
+func (s *Server) Credentials() *oauth.OAuth1Credentials {
+	creds := *s.credentials
+	return &creds
+}
+
+// BroadcasterURI returns a URI for use with `NewTwitterBroadcasterWithOptions` which targets 's' using its
+// credentials (encoded as a "constant://" runtimevar URI). Any values in 'params' are appended to the URI.
+// Callers should also pass the `http.Client` returned by the `Client` method.
+func (s *Server) BroadcasterURI(params url.Values) (string, error) {
+	return s.broadcasterURI(params, s.Credentials())
+}
+
+// AccountsBroadcasterURI returns a URI for use with `NewTwitterBroadcasterWithOptions` which targets 's' using
+// credentials for multiple accounts, keyed by account name, for example the credentials returned by `AddAccount`.
+// Any values in 'params' are appended to the URI.
+func (s *Server) AccountsBroadcasterURI(accounts map[string]oauth.Credentials, params url.Values) (string, error) {
+
+	creds := map[string]interface{}{
+		"accounts": accounts,
+	}
+
+	return s.broadcasterURI(params, creds)
+}
+
+// broadcasterURI returns a URI for use with `NewTwitterBroadcasterWithOptions` which targets 's' using 'creds'.
+func (s *Server) broadcasterURI(params url.Values, creds interface{}) (string, error) {
+
+	enc_creds, err := json.Marshal(creds)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to marshal credentials, %w", err)
+	}
+
+	creds_q := url.Values{}
+	creds_q.Set("val", string(enc_creds))
+
+	q := url.Values{}
+
+	for k, v := range params {
+		q[k] = v
+	}
+
+	q.Set("credentials", "constant://?"+creds_q.Encode())
+	q.Set("api-base", s.URL)
+	q.Set("upload-base", s.URL)
+
+	return "twitter://?" + q.Encode(), nil
+}
+
+// Requests returns the list of requests received by 's', in order.
+func (s *Server) Requests() []*Request {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := make([]*Request, len(s.requests))
+
+	for idx, r := range s.requests {
+		c := *r
+		requests[idx] = &c
+	}
+
+	return requests
+}
+
+// RequestsFor returns the list of requests received by 's' whose method is 'method' and whose path starts
+// with 'path'. If 'method' is empty requests with any method are returned.
+func (s *Server) RequestsFor(method string, path string) []*Request {
+
+	requests := make([]*Request, 0)
+
+	for _, r := range s.Requests() {
+
+		if method != "" && r.Method != method {
+			continue
+		}
+
+		if !strings.HasPrefix(r.Path, path) {
+			continue
+		}
+
+		requests = append(requests, r)
+	}
+
+	return requests
+}
+
+// Tweets returns a copy of every tweet published using 's', including deleted tweets, in order.
+func (s *Server) Tweets() []*Tweet {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tweets := make([]*Tweet, len(s.tweets))
+
+	for idx, tw := range s.tweets {
+		tweets[idx] = tw.copy()
+	}
+
+	return tweets
+}
+
+// Tweet returns a copy of the tweet whose ID is 'id'.
+func (s *Server) Tweet(id string) (*Tweet, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tw, ok := s.findTweet(id)
+
+	if !ok {
+		return nil, false
+	}
+
+	return tw.copy(), true
+}
+
+// Media returns a copy of the media whose ID is 'id'.
+func (s *Server) Media(id string) (*Media, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.media[id]
+
+	if !ok {
+		return nil, false
+	}
+
+	return m.copy(), true
+}
+
+// Reset removes all the requests, tweets, media and failures recorded by 's'.
+func (s *Server) Reset() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = make([]*Request, 0)
+	s.tweets = make([]*Tweet, 0)
+	s.media = make(map[string]*Media)
+	s.failures = make([]*Failure, 0)
+}
+
+// handleRequest records 'req', returns any injected failures, verifies its signature and then dispatches it.
+func (s *Server) handleRequest(rsp http.ResponseWriter, req *http.Request) {
+
+	body, err := io.ReadAll(req.Body)
+
+	if err != nil {
+		http.Error(rsp, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	form, files, err := parseBody(req, body)
+
+	if err != nil {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 0, err.Error()}, nil)
+		return
+	}
+
+	record := &Request{
+		Method:      req.Method,
+		Path:        req.URL.Path,
+		Query:       req.URL.Query(),
+		Form:        form,
+		Header:      req.Header.Clone(),
+		Body:        body,
+		AccessToken: accessToken(req),
+		Time:        time.Now(),
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, record)
+	s.mu.Unlock()
+
+	wr := &recordingWriter{ResponseWriter: rsp, request: record, mu: s.mu}
+
+	f := s.nextFailure(req)
+
+	if f != nil {
+		s.writeError(wr, req, &apiError{f.StatusCode, f.Code, f.Message}, f.Header)
+		return
+	}
+
+	// The token endpoint uses client credentials rather than user credentials
+
+	if req.Method == http.MethodPost && req.URL.Path == ENDPOINT_OAUTH2_TOKEN {
+		s.handleOAuth2Token(wr, req, form)
+		return
+	}
+
+	var signed_form url.Values
+
+	if isFormEncoded(req) {
+		signed_form = form
+	}
+
+	var user *User
+
+	if strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+		user, err = s.verifyBearerToken(req)
+	} else {
+		user, err = s.verifySignature(req, signed_form)
+	}
+
+	if err != nil {
+		s.writeError(wr, req, &apiError{http.StatusUnauthorized, 32, "Could not authenticate you."}, nil)
+		return
+	}
+
+	path := req.URL.Path
+
+	switch {
+	case req.Method == http.MethodGet && path == ENDPOINT_VERIFY_CREDENTIALS:
+		s.writeJSON(wr, http.StatusOK, map[string]interface{}{"data": user})
+	case req.Method == http.MethodGet && path == ENDPOINT_VERIFY_CREDENTIALS_V1:
+		s.writeJSON(wr, http.StatusOK, userV1(user))
+	case req.Method == http.MethodPost && path == ENDPOINT_TWEETS:
+		s.handleCreateTweet(wr, req, user, body)
+	case req.Method == http.MethodDelete && strings.HasPrefix(path, ENDPOINT_TWEETS+"/"):
+		s.handleDeleteTweet(wr, req, strings.TrimPrefix(path, ENDPOINT_TWEETS+"/"))
+	case req.Method == http.MethodPost && path == ENDPOINT_STATUSES_UPDATE:
+		s.handleStatusesUpdate(wr, req, user, form)
+	case req.Method == http.MethodPost && strings.HasPrefix(path, ENDPOINT_STATUSES_DESTROY) && strings.HasSuffix(path, ".json"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, ENDPOINT_STATUSES_DESTROY), ".json")
+		s.handleDeleteTweet(wr, req, id)
+	case path == ENDPOINT_MEDIA_UPLOAD:
+		s.handleMediaUpload(wr, req, form, files)
+	case req.Method == http.MethodPost && path == ENDPOINT_MEDIA_METADATA:
+		s.handleMediaMetadata(wr, req, body)
+	default:
+		s.writeError(wr, req, &apiError{http.StatusNotFound, 34, "Sorry, that page does not exist."}, nil)
+	}
+}
+
+// userV1 returns the v1.1 representation of 'user'.
+func userV1(user *User) map[string]interface{} {
+
+	id, _ := strconv.ParseInt(user.Id, 10, 64)
+
+	return map[string]interface{}{
+		"id":          id,
+		"id_str":      user.Id,
+		"name":        user.Name,
+		"screen_name": user.Username,
+	}
+}
+
+// findUser returns the account whose ID is 'id'. Callers must hold 's.mu'.
+func (s *Server) findUser(id string) (*User, bool) {
+
+	for _, a := range s.accounts {
+
+		if a.user.Id == id {
+			return a.user, true
+		}
+	}
+
+	return nil, false
+}
+
+// nextId returns a new unique tweet or media ID. Callers must hold 's.mu'.
+func (s *Server) nextId() string {
+	s.next_id += 1
+	return strconv.FormatInt(s.next_id, 10)
+}
+
+func (s *Server) writeJSON(rsp http.ResponseWriter, status_code int, v interface{}) {
+
+	rsp.Header().Set("Content-Type", "application/json")
+	rsp.WriteHeader(status_code)
+
+	json.NewEncoder(rsp).Encode(v)
+}
+
+// writeError writes 'e' to 'rsp' as a v2 "problem" document or a list of v1.1 errors depending on the API
+// that 'req' was sent to.
+func (s *Server) writeError(rsp http.ResponseWriter, req *http.Request, e *apiError, header http.Header) {
+
+	for k, v := range header {
+		rsp.Header()[k] = v
+	}
+
+	if isV2(req.URL.Path) {
+
+		problem := map[string]interface{}{
+			"title":  http.StatusText(e.StatusCode),
+			"detail": e.Message,
+			"type":   "about:blank",
+			"status": e.StatusCode,
+		}
+
+		rsp.Header().Set("Content-Type", "application/problem+json")
+		rsp.WriteHeader(e.StatusCode)
+
+		json.NewEncoder(rsp).Encode(problem)
+		return
+	}
+
+	msg := map[string]interface{}{
+		"message": e.Message,
+	}
+
+	if e.Code != 0 {
+		msg["code"] = e.Code
+	}
+
+	s.writeJSON(rsp, e.StatusCode, map[string]interface{}{
+		"errors": []interface{}{msg},
+	})
+}
+
+// isV2 returns a boolean value indicating whether 'path' is a v2 API endpoint.
+func isV2(path string) bool {
+	return strings.HasPrefix(path, "/2/")
+}
+
+// parseBody returns the form-encoded or multipart parameters, and any multipart files, in 'body'.
+func parseBody(req *http.Request, body []byte) (url.Values, map[string][]byte, error) {
+
+	form := url.Values{}
+	files := make(map[string][]byte)
+
+	media_type, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	if err != nil {
+		return form, files, nil
+	}
+
+	switch media_type {
+	case "application/x-www-form-urlencoded":
+
+		form, err = url.ParseQuery(string(body))
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to parse form, %w", err)
+		}
+
+	case "multipart/form-data":
+
+		rd := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+
+		for {
+
+			part, err := rd.NextPart()
+
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return nil, nil, fmt.Errorf("Failed to parse multipart body, %w", err)
+			}
+
+			v, err := io.ReadAll(part)
+
+			if err != nil {
+				return nil, nil, fmt.Errorf("Failed to read multipart body, %w", err)
+			}
+
+			if part.FileName() != "" {
+				files[part.FormName()] = v
+			} else {
+				form.Add(part.FormName(), string(v))
+			}
+		}
+	}
+
+	return form, files, nil
+}
+
+// recordingWriter is an `http.ResponseWriter` which records the status code of a response in a `Request`.
+type recordingWriter struct {
+	http.ResponseWriter
+	request *Request
+	mu      *sync.Mutex
+}
+
+func (wr *recordingWriter) WriteHeader(status_code int) {
+
+	wr.mu.Lock()
+	wr.request.StatusCode = status_code
+	wr.mu.Unlock()
+
+	wr.ResponseWriter.WriteHeader(status_code)
+}
+
+func (tw *Tweet) copy() *Tweet {
+
+	c := *tw
+	c.MediaIds = append([]string{}, tw.MediaIds...)
+
+	return &c
+}
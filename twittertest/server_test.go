@@ -0,0 +1,98 @@
+package twittertest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewServerInvalidOptions(t *testing.T) {
+
+	opts := &ServerOptions{
+		ProcessingChecks: -1,
+	}
+
+	_, err := NewServer(context.Background(), opts)
+
+	if err == nil {
+		t.Fatalf("Expected negative processing checks to be invalid")
+	}
+}
+
+func TestServerUnsignedRequest(t *testing.T) {
+
+	s, err := NewServer(context.Background(), nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create server, %v", err)
+	}
+
+	defer s.Close()
+
+	post := func() int {
+
+		rsp, err := s.Client().Post(s.URL+ENDPOINT_TWEETS, "application/json", strings.NewReader(`{"text":"hello world"}`))
+
+		if err != nil {
+			t.Fatalf("Failed to post tweet, %v", err)
+		}
+
+		defer rsp.Body.Close()
+		return rsp.StatusCode
+	}
+
+	// Injected failures are returned before requests are authenticated
+
+	s.InjectServerError(ENDPOINT_TWEETS, http.StatusServiceUnavailable, 1)
+
+	status_code := post()
+
+	if status_code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected injected %d error, got %d", http.StatusServiceUnavailable, status_code)
+	}
+
+	status_code = post()
+
+	if status_code != http.StatusUnauthorized {
+		t.Fatalf("Expected unsigned request to fail with %d, got %d", http.StatusUnauthorized, status_code)
+	}
+
+	if len(s.RequestsFor(http.MethodPost, ENDPOINT_TWEETS)) != 2 {
+		t.Fatalf("Expected 2 requests to be recorded")
+	}
+
+	if len(s.Tweets()) != 0 {
+		t.Fatalf("Expected no tweets to be published")
+	}
+}
+
+func TestServerAddAccount(t *testing.T) {
+
+	s, err := NewServer(context.Background(), nil)
+
+	if err != nil {
+		t.Fatalf("Failed to create server, %v", err)
+	}
+
+	defer s.Close()
+
+	creds, err := s.AddAccount(&User{Id: "42", Name: "Example", Username: "example"})
+
+	if err != nil {
+		t.Fatalf("Failed to add account, %v", err)
+	}
+
+	if creds.ConsumerKey != s.Credentials().ConsumerKey || creds.AccessToken == s.Credentials().AccessToken {
+		t.Fatalf("Expected account to share the consumer key but not the access token of the default credentials")
+	}
+
+	for _, user := range []*User{nil, {Id: "42"}, {Id: "1234567890"}} {
+
+		_, err := s.AddAccount(user)
+
+		if err == nil {
+			t.Fatalf("Expected adding account %v to fail", user)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package twittertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// v2TweetRequest is the JSON body of a v2 `POST /2/tweets` request.
+type v2TweetRequest struct {
+	Text  string `json:"text"`
+	Media *struct {
+		MediaIds []string `json:"media_ids"`
+	} `json:"media,omitempty"`
+	Reply *struct {
+		InReplyToTweetId string `json:"in_reply_to_tweet_id"`
+	} `json:"reply,omitempty"`
+}
+
+func (s *Server) handleCreateTweet(rsp http.ResponseWriter, req *http.Request, user *User, body []byte) {
+
+	var tw_req *v2TweetRequest
+
+	err := json.Unmarshal(body, &tw_req)
+
+	if err != nil || tw_req == nil {
+		s.writeError(rsp, req, &apiError{http.StatusBadRequest, 0, "Invalid JSON body"}, nil)
+		return
+	}
+
+	tw := &Tweet{
+		Text:     tw_req.Text,
+		MediaIds: make([]string, 0),
+		API:      API_V2,
+		UserId:   user.Id,
+	}
+
+	if tw_req.Media != nil {
+		tw.MediaIds = tw_req.Media.MediaIds
+	}
+
+	if tw_req.Reply != nil {
+		tw.InReplyTo = tw_req.Reply.InReplyToTweetId
+	}
+
+	e := s.createTweet(tw, ENDPOINT_TWEETS)
+
+	if e != nil {
+		s.writeError(rsp, req, e, nil)
+		return
+	}
+
+	data := map[string]interface{}{
+		"id":   tw.Id,
+		"text": tw.Text,
+	}
+
+	s.writeJSON(rsp, http.StatusCreated, map[string]interface{}{"data": data})
+}
+
+func (s *Server) handleStatusesUpdate(rsp http.ResponseWriter, req *http.Request, user *User, form url.Values) {
+
+	tw := &Tweet{
+		Text:      form.Get("status"),
+		MediaIds:  make([]string, 0),
+		InReplyTo: form.Get("in_reply_to_status_id"),
+		API:       API_V1,
+		UserId:    user.Id,
+	}
+
+	if form.Get("media_ids") != "" {
+		tw.MediaIds = strings.Split(form.Get("media_ids"), ",")
+	}
+
+	e := s.createTweet(tw, ENDPOINT_STATUSES_UPDATE)
+
+	if e != nil {
+		s.writeError(rsp, req, e, nil)
+		return
+	}
+
+	s.writeJSON(rsp, http.StatusOK, s.tweetV1(tw))
+}
+
+func (s *Server) handleDeleteTweet(rsp http.ResponseWriter, req *http.Request, id string) {
+
+	s.mu.Lock()
+
+	tw, ok := s.findTweet(id)
+
+	if ok && !tw.Deleted {
+		tw.Deleted = true
+	} else {
+		ok = false
+	}
+
+	s.mu.Unlock()
+
+	if !ok {
+		s.writeError(rsp, req, &apiError{http.StatusNotFound, 144, "No status found with that ID."}, nil)
+		return
+	}
+
+	if isV2(req.URL.Path) {
+		s.writeJSON(rsp, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"deleted": true},
+		})
+		return
+	}
+
+	s.writeJSON(rsp, http.StatusOK, s.tweetV1(tw))
+}
+
+// createTweet validates 'tw' and, if valid, assigns it an ID and records it. 'path' is the endpoint used
+// to publish the tweet and determines the wording of error messages.
+func (s *Server) createTweet(tw *Tweet, path string) *apiError {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.TrimSpace(tw.Text) == "" && len(tw.MediaIds) == 0 {
+		return &apiError{http.StatusBadRequest, 170, "Missing required parameter: status."}
+	}
+
+	if StatusLength(tw.Text) > MAX_STATUS_LENGTH {
+		return &apiError{http.StatusForbidden, 186, "Tweet needs to be a bit shorter."}
+	}
+
+	if len(tw.MediaIds) > MAX_MEDIA_PER_TWEET {
+		return &apiError{http.StatusBadRequest, 324, fmt.Sprintf("Too many media IDs, maximum is %d.", MAX_MEDIA_PER_TWEET)}
+	}
+
+	for _, media_id := range tw.MediaIds {
+
+		m, ok := s.media[media_id]
+
+		if !ok || m.State != MEDIA_STATE_SUCCEEDED {
+			return &apiError{http.StatusBadRequest, 324, fmt.Sprintf("Media ID %s is invalid.", media_id)}
+		}
+	}
+
+	if tw.InReplyTo != "" {
+
+		parent, ok := s.findTweet(tw.InReplyTo)
+
+		if !ok || parent.Deleted {
+			return &apiError{http.StatusForbidden, 385, "You attempted to reply to a Tweet that is deleted or not visible to you."}
+		}
+	}
+
+	if strings.TrimSpace(tw.Text) != "" {
+
+		for _, other := range s.tweets {
+
+			if !other.Deleted && other.UserId == tw.UserId && other.Text == tw.Text {
+				return &apiError{http.StatusForbidden, ERROR_DUPLICATE_STATUS, duplicateStatusMessage(path)}
+			}
+		}
+	}
+
+	tw.Id = s.nextId()
+	tw.Created = time.Now()
+
+	s.tweets = append(s.tweets, tw)
+	return nil
+}
+
+// findTweet returns the tweet whose ID is 'id'. Callers must hold 's.mu'.
+func (s *Server) findTweet(id string) (*Tweet, bool) {
+
+	for _, tw := range s.tweets {
+
+		if tw.Id == id {
+			return tw, true
+		}
+	}
+
+	return nil, false
+}
+
+// tweetV1 returns the v1.1 representation of 'tw'.
+func (s *Server) tweetV1(tw *Tweet) map[string]interface{} {
+
+	id, _ := strconv.ParseInt(tw.Id, 10, 64)
+
+	s.mu.Lock()
+	user, ok := s.findUser(tw.UserId)
+	s.mu.Unlock()
+
+	if !ok {
+		user = s.user
+	}
+
+	rsp := map[string]interface{}{
+		"id":         id,
+		"id_str":     tw.Id,
+		"text":       tw.Text,
+		"created_at": tw.Created.Format(time.RubyDate),
+		"user":       userV1(user),
+	}
+
+	if tw.InReplyTo != "" {
+		reply_id, _ := strconv.ParseInt(tw.InReplyTo, 10, 64)
+		rsp["in_reply_to_status_id"] = reply_id
+		rsp["in_reply_to_status_id_str"] = tw.InReplyTo
+	}
+
+	return rsp
+}
@@ -0,0 +1,143 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ChimeraCoder/anaconda"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// VERIFY_EAGER is the `?verify=` option which verifies credentials when a `TwitterBroadcaster` is created,
+// retrying transient failures until `?verify-timeout=` elapses (the default).
+const VERIFY_EAGER string = "eager"
+
+// VERIFY_LAZY is the `?verify=` option which verifies credentials the first time a message is broadcast.
+const VERIFY_LAZY string = "lazy"
+
+// VERIFY_NEVER is the `?verify=` option which never verifies credentials (unless `TwitterBroadcaster.Account` is called).
+const VERIFY_NEVER string = "never"
+
+// DEFAULT_VERIFY_TIMEOUT is the default amount of time to spend retrying eager credential verification.
+const DEFAULT_VERIFY_TIMEOUT time.Duration = 30 * time.Second
+
+// verify_initial_wait and verify_max_wait are the bounds of the (exponential) backoff between eager verification attempts.
+const verify_initial_wait time.Duration = 500 * time.Millisecond
+
+const verify_max_wait time.Duration = 8 * time.Second
+
+// isValidVerify returns a boolean value indicating whether 'verify' is a known credential verification option.
+func isValidVerify(verify string) bool {
+
+	switch verify {
+	case VERIFY_EAGER, VERIFY_LAZY, VERIFY_NEVER:
+		return true
+	default:
+		return false
+	}
+}
+
+// verifyWithBackoff verifies the credentials associated with 'c', retrying transient failures (rate limits, server
+// errors and network errors) with an exponential backoff until 'timeout' elapses.
+func verifyWithBackoff(ctx context.Context, c client, timeout time.Duration, logger *log.Logger) (*Account, error) {
+
+	verify_ctx, verify_cancel := context.WithTimeout(ctx, timeout)
+	defer verify_cancel()
+
+	wait := verify_initial_wait
+
+	for attempt := 1; ; attempt++ {
+
+		a, err := c.VerifyCredentials(verify_ctx)
+
+		if err == nil {
+			return a, nil
+		}
+
+		if !isTransientError(err) {
+			return nil, fmt.Errorf("Failed to verify credentials, %w", err)
+		}
+
+		logger.Printf("Failed to verify credentials (attempt %d), retrying in %v, %v", attempt, wait, err)
+
+		select {
+		case <-verify_ctx.Done():
+			return nil, fmt.Errorf("Failed to verify credentials within %v, %w", timeout, err)
+		case <-time.After(wait):
+			// pass
+		}
+
+		wait = wait * 2
+
+		if wait > verify_max_wait {
+			wait = verify_max_wait
+		}
+	}
+}
+
+// isTransientError returns a boolean value indicating whether 'err' is likely to be temporary: rate limits, server
+// (5XX) errors, timeouts and network errors such as refused or reset connections. Everything else, for example
+// client (4XX) errors, malformed responses or media that Twitter failed to process, is considered permanent.
+func isTransientError(err error) bool {
+
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+
+	var api_err *APIError
+
+	if errors.As(err, &api_err) {
+		return isTransientStatus(api_err.StatusCode)
+	}
+
+	var anaconda_err *anaconda.ApiError
+
+	if errors.As(err, &anaconda_err) {
+		return isTransientStatus(anaconda_err.StatusCode)
+	}
+
+	return isTransientNetworkError(err)
+}
+
+// isTransientNetworkError returns a boolean value indicating whether 'err' is a network error that is likely to be
+// temporary: timeouts, failures to establish a connection and connections that were reset or closed unexpectedly.
+func isTransientNetworkError(err error) bool {
+
+	var net_err net.Error
+
+	if errors.As(err, &net_err) && net_err.Timeout() {
+		return true
+	}
+
+	var op_err *net.OpError
+
+	if errors.As(err, &op_err) {
+		return true
+	}
+
+	for _, target := range []error{syscall.ECONNRESET, syscall.ECONNREFUSED, syscall.ECONNABORTED, syscall.EPIPE, io.ErrUnexpectedEOF, io.EOF} {
+
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isTransientStatus(status_code int) bool {
+	return status_code == http.StatusTooManyRequests || status_code >= 500
+}
@@ -0,0 +1,118 @@
+package twitter_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-broadcaster-twitter/twittertest"
+)
+
+func TestVerifyEager(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	// Transient failures are retried
+
+	s.InjectServerError(twittertest.ENDPOINT_VERIFY_CREDENTIALS, http.StatusServiceUnavailable, 1)
+
+	br := newTestBroadcaster(t, s, nil, nil)
+
+	if len(s.RequestsFor(http.MethodGet, twittertest.ENDPOINT_VERIFY_CREDENTIALS)) != 2 {
+		t.Fatalf("Expected credentials to be verified after 1 retry")
+	}
+
+	a, err := br.Account(ctx)
+
+	if err != nil {
+		t.Fatalf("Failed to get account, %v", err)
+	}
+
+	if a.ScreenName != "twittertest" || a.Id != "1234567890" {
+		t.Fatalf("Unexpected account %s", a)
+	}
+
+	// The account is only verified once
+
+	if len(s.RequestsFor(http.MethodGet, twittertest.ENDPOINT_VERIFY_CREDENTIALS)) != 2 {
+		t.Fatalf("Expected account to be cached")
+	}
+
+	// Permanent failures are not retried
+
+	s.InjectFailure(&twittertest.Failure{
+		Path:       twittertest.ENDPOINT_VERIFY_CREDENTIALS,
+		StatusCode: http.StatusUnauthorized,
+		Code:       32,
+		Message:    "Could not authenticate you.",
+		Times:      1,
+	})
+
+	uri, err := s.BroadcasterURI(nil)
+
+	if err != nil {
+		t.Fatalf("Failed to derive broadcaster URI, %v", err)
+	}
+
+	_, err = twitter.NewTwitterBroadcasterWithOptions(ctx, uri, &twitter.TwitterBroadcasterOptions{HTTPClient: s.Client()})
+
+	if !errors.Is(err, twitter.ErrAuthRevoked) {
+		t.Fatalf("Expected ErrAuthRevoked, got %v", err)
+	}
+
+	if len(s.RequestsFor(http.MethodGet, twittertest.ENDPOINT_VERIFY_CREDENTIALS)) != 3 {
+		t.Fatalf("Expected revoked credentials not to be retried")
+	}
+}
+
+func TestVerifyLazy(t *testing.T) {
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("verify", twitter.VERIFY_LAZY)
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	if len(s.RequestsFor(http.MethodGet, twittertest.ENDPOINT_VERIFY_CREDENTIALS)) != 0 {
+		t.Fatalf("Expected credentials not to be verified when the broadcaster is created")
+	}
+
+	for _, body := range []string{"hello world", "hello again"} {
+
+		_, err := broadcast(t, br, body)
+
+		if err != nil {
+			t.Fatalf("Failed to broadcast message, %v", err)
+		}
+	}
+
+	if len(s.RequestsFor(http.MethodGet, twittertest.ENDPOINT_VERIFY_CREDENTIALS)) != 1 {
+		t.Fatalf("Expected credentials to be verified once")
+	}
+}
+
+func TestVerifyNever(t *testing.T) {
+
+	s := newTestServer(t, nil)
+
+	params := url.Values{}
+	params.Set("verify", twitter.VERIFY_NEVER)
+
+	br := newTestBroadcaster(t, s, params, nil)
+
+	_, err := broadcast(t, br, "hello world")
+
+	if err != nil {
+		t.Fatalf("Failed to broadcast message, %v", err)
+	}
+
+	if len(s.RequestsFor(http.MethodGet, twittertest.ENDPOINT_VERIFY_CREDENTIALS)) != 0 {
+		t.Fatalf("Expected credentials not to be verified")
+	}
+}
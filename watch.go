@@ -0,0 +1,210 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"github.com/aaronland/go-aws-session"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+	gc "gocloud.dev/runtimevar"
+	"gocloud.dev/runtimevar/awsparamstore"
+	"net/url"
+	"time"
+)
+
+// WATCH_NOTIFY is the value of the `?watch=` parameter which reloads credentials when the underlying `gocloud.dev/runtimevar`
+// variable reports a change, for example when a file:// variable is modified.
+const WATCH_NOTIFY string = "notify"
+
+// MIN_WATCH_INTERVAL is the minimum interval between attempts to reload credentials when the `?watch=` parameter is a duration.
+const MIN_WATCH_INTERVAL time.Duration = 1 * time.Second
+
+// credentialsWatcher reloads the credentials for a `TwitterBroadcaster`, replacing its client when they change.
+type credentialsWatcher struct {
+	load     credentialsLoader
+	interval time.Duration
+	variable *gc.Variable
+	// last is the most recent (JSON-encoded) credentials document successfully loaded.
+	last string
+}
+
+// newCredentialsWatcher returns a new `credentialsWatcher` instance for the credentials defined by 'query' and read
+// using 'load'. 'watch' is either `WATCH_NOTIFY`, which requires the `?credentials=` parameter, or a `time.Duration`
+// string defining how often the credentials are re-read. 'str_creds' are the credentials which are currently in use.
+func newCredentialsWatcher(ctx context.Context, query url.Values, load credentialsLoader, watch string, str_creds string) (*credentialsWatcher, error) {
+
+	w := &credentialsWatcher{
+		load: load,
+		last: str_creds,
+	}
+
+	if watch == WATCH_NOTIFY {
+
+		creds_uri := query.Get("credentials")
+
+		if creds_uri == "" {
+			return nil, fmt.Errorf("'%s' requires the ?credentials= parameter", WATCH_NOTIFY)
+		}
+
+		v, err := openStringVariable(ctx, creds_uri)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open credentials variable, %w", err)
+		}
+
+		w.variable = v
+		return w, nil
+	}
+
+	d, err := time.ParseDuration(watch)
+
+	if err != nil {
+		return nil, fmt.Errorf("Expected '%s' or a duration, %w", WATCH_NOTIFY, err)
+	}
+
+	if d < MIN_WATCH_INTERVAL {
+		return nil, fmt.Errorf("Interval must be at least %v", MIN_WATCH_INTERVAL)
+	}
+
+	w.interval = d
+	return w, nil
+}
+
+// run reloads the credentials for 'b' until 'ctx' is cancelled.
+func (w *credentialsWatcher) run(ctx context.Context, b *TwitterBroadcaster) {
+
+	if w.variable != nil {
+		w.runNotify(ctx, b)
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+
+			str_creds, err := w.load(ctx)
+
+			if err != nil {
+				b.logger.Printf("Warning: failed to read credentials, %v", err)
+				continue
+			}
+
+			w.reload(ctx, b, str_creds)
+		}
+	}
+}
+
+// runNotify reloads the credentials for 'b' each time the underlying variable changes until 'ctx' is cancelled.
+func (w *credentialsWatcher) runNotify(ctx context.Context, b *TwitterBroadcaster) {
+
+	defer w.variable.Close()
+
+	for {
+
+		snapshot, err := w.variable.Watch(ctx)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			b.logger.Printf("Warning: failed to watch credentials, %v", err)
+			continue
+		}
+
+		str_creds, ok := snapshot.Value.(string)
+
+		if !ok {
+			b.logger.Printf("Warning: unexpected credentials value %T", snapshot.Value)
+			continue
+		}
+
+		w.reload(ctx, b, str_creds)
+	}
+}
+
+// reload replaces the client for 'b' with a new client created from 'str_creds' if they are different from the
+// credentials currently in use. Unless `?verify=never` the new credentials are verified first. If the credentials
+// are invalid, or can not be verified, the current client is kept.
+func (w *credentialsWatcher) reload(ctx context.Context, b *TwitterBroadcaster, str_creds string) {
+
+	if str_creds == w.last {
+		return
+	}
+
+	creds, err := oauth.NewCredentialsFromString(ctx, str_creds)
+
+	if err != nil {
+		b.logger.Printf("Warning: failed to reload credentials, keeping current credentials, %v", err)
+		return
+	}
+
+	tw_client, err := b.factory.newClient(ctx, creds)
+
+	if err != nil {
+		b.logger.Printf("Warning: failed to reload credentials, keeping current credentials, %v", err)
+		return
+	}
+
+	var account *Account
+
+	if b.verify != VERIFY_NEVER {
+
+		a, err := verifyWithBackoff(ctx, tw_client, b.verify_timeout, b.logger)
+
+		if err != nil {
+			closeClient(tw_client)
+			b.logger.Printf("Warning: failed to reload credentials, keeping current credentials, %v", err)
+			return
+		}
+
+		account = a
+	}
+
+	b.replaceClient(tw_client, account)
+	w.last = str_creds
+
+	if account != nil {
+		b.logger.Printf("Reloaded Twitter credentials for %s", account)
+	} else {
+		b.logger.Printf("Reloaded Twitter credentials")
+	}
+}
+
+// openStringVariable opens the `gocloud.dev/runtimevar` variable defined by 'uri' using a string decoder. It
+// supports the same URIs, including awsparamstore:// URIs with `aaronland/go-aws-session` credentials, as
+// `sfomuseum/runtimevar.StringVar`.
+func openStringVariable(ctx context.Context, uri string) (*gc.Variable, error) {
+
+	u, err := url.Parse(uri)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := u.Query()
+
+	if q.Get("decoder") == "" {
+		q.Set("decoder", "string")
+		u.RawQuery = q.Encode()
+	}
+
+	if u.Scheme == "awsparamstore" && q.Get("credentials") != "" {
+
+		dsn := fmt.Sprintf("region=%s credentials=%s", q.Get("region"), q.Get("credentials"))
+
+		sess, err := session.NewSessionWithDSN(dsn)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create AWS session, %w", err)
+		}
+
+		return awsparamstore.OpenVariable(sess, u.Host, gc.StringDecoder, nil)
+	}
+
+	return gc.OpenVariable(ctx, u.String())
+}
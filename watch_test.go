@@ -0,0 +1,155 @@
+package twitter_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aaronland/go-broadcaster-twitter"
+	"github.com/aaronland/go-broadcaster-twitter/oauth"
+)
+
+// writeCredentials writes 'creds' to 'path' as JSON.
+func writeCredentials(t *testing.T, path string, creds oauth.Credentials) {
+
+	t.Helper()
+
+	enc_creds, err := json.Marshal(creds)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal credentials, %v", err)
+	}
+
+	err = os.WriteFile(path, enc_creds, 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write credentials, %v", err)
+	}
+}
+
+func TestWatchCredentials(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s := newTestServer(t, nil)
+
+	revoked := s.Credentials()
+	revoked.AccessToken = "revoked-access-token"
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	writeCredentials(t, path, revoked)
+
+	q := url.Values{}
+	q.Set("credentials", "file://"+path)
+	q.Set("api-base", s.URL)
+	q.Set("upload-base", s.URL)
+	q.Set("verify", "never")
+	q.Set("watch", "1s")
+
+	opts := &twitter.TwitterBroadcasterOptions{
+		HTTPClient: s.Client(),
+	}
+
+	br, err := twitter.NewTwitterBroadcasterWithOptions(ctx, "twitter://?"+q.Encode(), opts)
+
+	if err != nil {
+		t.Fatalf("Failed to create broadcaster, %v", err)
+	}
+
+	br.(*twitter.TwitterBroadcaster).SetLogger(ctx, log.New(io.Discard, "", 0))
+
+	_, err = broadcast(t, br, "hello world")
+
+	if !errors.Is(err, twitter.ErrAuthRevoked) {
+		t.Fatalf("Expected ErrAuthRevoked, got %v", err)
+	}
+
+	// Invalid credentials are ignored
+
+	err = os.WriteFile(path, []byte(`{"consumer_key":"a"}`), 0600)
+
+	if err != nil {
+		t.Fatalf("Failed to write credentials, %v", err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+
+	_, err = broadcast(t, br, "hello world")
+
+	if !errors.Is(err, twitter.ErrAuthRevoked) {
+		t.Fatalf("Expected current credentials to be kept, got %v", err)
+	}
+
+	writeCredentials(t, path, s.Credentials())
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+
+		_, err = broadcast(t, br, "hello world")
+
+		if err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected credentials to be reloaded, %v", err)
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	if len(s.Tweets()) != 1 {
+		t.Fatalf("Expected 1 tweet, got %d", len(s.Tweets()))
+	}
+}
+
+func TestWatchInvalid(t *testing.T) {
+
+	ctx := context.Background()
+
+	s := newTestServer(t, nil)
+
+	for _, watch := range []string{"500ms", "sometimes"} {
+
+		params := url.Values{}
+		params.Set("verify", "never")
+		params.Set("watch", watch)
+
+		uri, err := s.BroadcasterURI(params)
+
+		if err != nil {
+			t.Fatalf("Failed to derive broadcaster URI, %v", err)
+		}
+
+		_, err = twitter.NewTwitterBroadcasterWithOptions(ctx, uri, &twitter.TwitterBroadcasterOptions{HTTPClient: s.Client()})
+
+		if err == nil {
+			t.Fatalf("Expected ?watch=%s to be invalid", watch)
+		}
+	}
+
+	// Credentials assembled from individual values can only be re-read at an interval
+
+	q := url.Values{}
+	q.Set("consumer-key", "constant://?val=a")
+	q.Set("consumer-secret", "constant://?val=b")
+	q.Set("access-token", "constant://?val=c")
+	q.Set("access-secret", "constant://?val=d")
+	q.Set("verify", "never")
+	q.Set("watch", twitter.WATCH_NOTIFY)
+
+	_, err := twitter.NewTwitterBroadcaster(ctx, "twitter://?"+q.Encode())
+
+	if err == nil {
+		t.Fatalf("Expected ?watch=%s without ?credentials= to fail", twitter.WATCH_NOTIFY)
+	}
+}